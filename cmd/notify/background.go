@@ -0,0 +1,114 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	"github.com/Mavwarf/notify/internal/config"
+	"github.com/Mavwarf/notify/internal/eventlog"
+	"github.com/Mavwarf/notify/internal/paths"
+	"github.com/Mavwarf/notify/internal/tmpl"
+)
+
+// Job kinds: follow-ups that wait, run by a background notify process.
+const (
+	jobRepeats  = "repeats"  // dedup follow-up when the cooldown window closes
+	jobEscalate = "escalate" // escalation re-fires until acknowledged
+)
+
+// job is a follow-up handed to a detached notify process ("notify _job
+// <file>"), so the invoking command returns right away and closing its
+// terminal doesn't cancel the follow-up. The config is reloaded by the
+// background process rather than stored, which keeps credentials out of
+// the job file.
+type job struct {
+	Kind    string    `json:"kind"`
+	Profile string    `json:"profile"`
+	Action  string    `json:"action"`
+	At      time.Time `json:"at,omitempty"` // when a repeats job reports, or an escalation opened
+	Opts    runOpts   `json:"opts"`
+	Vars    tmpl.Vars `json:"vars"`
+}
+
+// startJob hands j to a background process. Replaced in tests.
+var startJob = spawnJob
+
+// spawnJob writes j to the jobs directory and starts a detached copy of
+// notify that runs and removes it.
+func spawnJob(j job) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("cannot determine executable path: %w", err)
+	}
+	data, err := json.Marshal(j)
+	if err != nil {
+		return fmt.Errorf("marshal job: %w", err)
+	}
+	file := filepath.Join(paths.DataDir(), paths.JobDirName, "job-"+rand.Text()+".json")
+	if err := paths.AtomicWrite(file, data); err != nil {
+		return err
+	}
+
+	args := []string{"_job", file}
+	if j.Opts.Config != "" {
+		cfgPath, _ := filepath.Abs(j.Opts.Config)
+		args = append([]string{"--config", cfgPath}, args...)
+	}
+	cmd := exec.Command(exe, args...)
+	cmd.SysProcAttr = detached()
+	if err := cmd.Start(); err != nil {
+		_ = os.Remove(file)
+		return err
+	}
+	return cmd.Process.Release()
+}
+
+// jobCmd handles "notify _job <file>" in the background process started
+// by spawnJob. Its output is discarded; errors end it silently, like
+// _hook.
+func jobCmd(args []string, configPath string) {
+	if len(args) != 1 {
+		os.Exit(1)
+	}
+	data, err := os.ReadFile(args[0])
+	_ = os.Remove(args[0])
+	if err != nil {
+		os.Exit(1)
+	}
+	var j job
+	if err := json.Unmarshal(data, &j); err != nil {
+		os.Exit(1)
+	}
+	cfg, err := loadAndValidate(configPath)
+	if err != nil {
+		os.Exit(1)
+	}
+	if err := runJob(cfg, j); err != nil {
+		os.Exit(1)
+	}
+}
+
+// runJob runs a background job against the current config.
+func runJob(cfg config.Config, j job) error {
+	profile, act, err := config.Resolve(cfg, j.Profile, j.Action)
+	if err != nil {
+		return err
+	}
+	switch j.Kind {
+	case jobRepeats:
+		return sendRepeats(cfg, profile, j.Action, act, j.Opts, j.Vars, j.At)
+	case jobEscalate:
+		if act.Escalate == nil {
+			eventlog.LogEscalation(j.Vars.AckID, profile, j.Action, eventlog.EscalationEnded)
+			return fmt.Errorf("action %q no longer escalates", j.Action)
+		}
+		runEscalation(cfg, profile, j.Action, act, j.Opts, j.Vars, j.At)
+		return nil
+	}
+	return fmt.Errorf("unknown job kind %q", j.Kind)
+}
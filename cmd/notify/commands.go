@@ -16,6 +16,8 @@ import (
 	"github.com/Mavwarf/notify/internal/dashboard"
 	"github.com/Mavwarf/notify/internal/desktop"
 	"github.com/Mavwarf/notify/internal/eventlog"
+	"github.com/Mavwarf/notify/internal/holiday"
+	"github.com/Mavwarf/notify/internal/outbox"
 	"github.com/Mavwarf/notify/internal/procwait"
 	"github.com/Mavwarf/notify/internal/runner"
 	"github.com/Mavwarf/notify/internal/silent"
@@ -23,18 +25,38 @@ import (
 	"github.com/Mavwarf/notify/internal/voice"
 )
 
-// sendTypes is the set of step types supported by "notify send".
-var sendTypes = map[string]bool{
-	"say": true, "toast": true,
-	"discord": true, "discord_voice": true,
-	"slack": true,
-	"telegram": true, "telegram_audio": true, "telegram_voice": true,
+// sendTypes is the set of step types supported by "notify send": those
+// whose handler opts in with Sendable.
+var sendTypes = func() map[string]bool {
+	types := map[string]bool{}
+	for _, typ := range config.StepTypes() {
+		if h, _ := config.LookupStep(typ); h.Sendable() {
+			types[typ] = true
+		}
+	}
+	return types
+}()
+
+// sendStep builds the single step sent by "notify send".
+func sendStep(typ, message, title string) config.Step {
+	step := config.Step{Type: typ}
+	switch typ {
+	case "toast", "terminal":
+		step.Message = message
+		step.Title = title
+	case "email":
+		step.Text = message
+		step.Subject = title
+	default:
+		step.Text = message
+	}
+	return step
 }
 
 // sendCmd sends a one-off notification of a specific type (say, toast,
 // discord, etc.) without requiring a profile or action in the config.
 func sendCmd(args []string, configPath string, opts runOpts) {
-	// Parse optional --title flag (for toast).
+	// Parse optional --title flag (toast/terminal title, email subject).
 	var title string
 	rest := make([]string, len(args))
 	copy(rest, args)
@@ -55,7 +77,12 @@ func sendCmd(args []string, configPath string, opts runOpts) {
 	message := rest[1]
 
 	if !sendTypes[stepType] {
-		fatal("unsupported send type %q\nSupported: say, toast, discord, discord_voice, slack, telegram, telegram_audio, telegram_voice", stepType)
+		supported := make([]string, 0, len(sendTypes))
+		for typ := range sendTypes {
+			supported = append(supported, typ)
+		}
+		sort.Strings(supported)
+		fatal("unsupported send type %q\nSupported: %s", stepType, strings.Join(supported, ", "))
 	}
 
 	cfg, err := loadAndValidate(configPath)
@@ -66,23 +93,17 @@ func sendCmd(args []string, configPath string, opts runOpts) {
 	opts.Volume = resolveVolume(opts.Volume, cfg)
 
 	// Build a single step from the positional args.
-	step := config.Step{Type: stepType}
-	if stepType == "toast" {
-		step.Message = message
-		if title != "" {
-			step.Title = title
-		}
-	} else {
-		step.Text = message
-	}
+	step := sendStep(stepType, message, title)
 
 	vars := baseVars("send")
+	vars.TmuxPane = os.Getenv("TMUX_PANE")
 	steps := []config.Step{step}
-	if err := runner.Execute(steps, opts.Volume, cfg.Options.Credentials, vars, nil); err != nil {
-		fatal("%v", err)
-	}
+	results, err := runner.Execute(steps, opts.Volume, cfg.Options.Credentials, vars, nil)
 	if shouldLog(cfg, opts.Log) {
-		eventlog.Log("send:"+stepType, steps, false, vars, nil)
+		eventlog.Log("send:"+stepType, steps, logResults(results), false, vars, nil)
+	}
+	if err != nil {
+		fatal("%v", err)
 	}
 	if shouldEcho(cfg, opts.Echo) {
 		printEcho(steps)
@@ -148,6 +169,81 @@ func configValidate(configPath string) {
 	fmt.Printf("Config OK: %s\n", p)
 }
 
+// outboxCmd dispatches outbox subcommands: list (default), retry, drop.
+func outboxCmd(args []string, configPath string) {
+	sub := "list"
+	if len(args) > 0 {
+		sub = args[0]
+	}
+	switch sub {
+	case "list":
+		outboxList()
+	case "retry":
+		cfg, err := loadAndValidate(configPath)
+		if err != nil {
+			fatal("%v", err)
+		}
+		id := ""
+		if len(args) > 1 {
+			id = args[1]
+		}
+		sent, failed := runner.RetryOutbox(cfg, true, id)
+		if sent+failed == 0 {
+			if id != "" {
+				fatal("no outbox entry with id %q", id)
+			}
+			fmt.Println("Outbox is empty")
+			return
+		}
+		fmt.Printf("Retried %d: %d sent, %d still failing\n", sent+failed, sent, failed)
+	case "drop":
+		if len(args) < 2 {
+			fatal("usage: notify outbox drop <id|all>")
+		}
+		if args[1] == "all" {
+			if err := outbox.Clear(); err != nil {
+				fatal("%v", err)
+			}
+			fmt.Println("Outbox cleared")
+			return
+		}
+		ok, err := outbox.Drop(args[1])
+		if err != nil {
+			fatal("%v", err)
+		}
+		if !ok {
+			fatal("no outbox entry with id %q", args[1])
+		}
+		fmt.Printf("Dropped %s\n", args[1])
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown outbox subcommand: %s\n", sub)
+		os.Exit(1)
+	}
+}
+
+// outboxList prints queued outbox entries with their retry state.
+func outboxList() {
+	entries, err := outbox.List()
+	if err != nil {
+		fatal("%v", err)
+	}
+	if len(entries) == 0 {
+		fmt.Println("Outbox is empty")
+		return
+	}
+	for _, e := range entries {
+		next := "next " + e.NextAttempt.Format("2006-01-02 15:04:05")
+		if e.GaveUp() {
+			next = "gave up (use 'notify outbox retry " + e.ID + "')"
+		}
+		fmt.Printf("%s  %s  %-8s %-14s attempts=%d  %s\n",
+			e.ID, e.Created.Format("2006-01-02 15:04:05"), e.Profile, e.Step.Type, e.Attempts, next)
+		if e.LastError != "" {
+			fmt.Printf("          last error: %s\n", e.LastError)
+		}
+	}
+}
+
 // playCmd previews a built-in sound or WAV file. With no args it lists
 // all available built-in sounds.
 func playCmd(args []string, volume int) {
@@ -270,6 +366,15 @@ func dryRun(args []string, configPath string) {
 		fmt.Printf("Silent:  no\n")
 	}
 
+	conds := runner.Conditions{AFK: afk, Holidays: holiday.Load(cfg.Options.HolidayFile)}
+	if hs := conds.Holidays; hs != nil {
+		today := "no"
+		if hs.Contains(time.Now()) {
+			today = "yes"
+		}
+		fmt.Printf("Holiday: %s (%d dates in %s)\n", today, hs.Len(), cfg.Options.HolidayFile)
+	}
+
 	p, ok := cfg.Profiles[profile]
 	if !ok {
 		fatal("profile %q not found", profile)
@@ -287,9 +392,16 @@ func dryRun(args []string, configPath string) {
 	fmt.Printf("\nActions:\n")
 	for _, aName := range actionNames {
 		act := p.Actions[aName]
-		wouldRun := runner.FilteredIndices(act.Steps, afk, false, 0)
-		fmt.Printf("\n  %s (%d/%d steps would run):\n", aName, len(wouldRun), len(act.Steps))
-		for i, s := range act.Steps {
+		severity := resolveSeverity("", &act)
+		conds.Route = cfg.Options.RouteFor(severity, afk)
+		steps := cfg.Options.RoutedSteps(act.Steps, severity)
+		wouldRun := runner.FilteredIndices(steps, conds)
+		sevInfo := ""
+		if act.Severity != "" || len(cfg.Options.Routing) > 0 {
+			sevInfo = ", " + severity
+		}
+		fmt.Printf("\n  %s (%d/%d steps would run%s):\n", aName, len(wouldRun), len(steps), sevInfo)
+		for i, s := range steps {
 			marker := "  SKIP "
 			if wouldRun[i] {
 				marker = "  RUN  "
@@ -299,19 +411,45 @@ func dryRun(args []string, configPath string) {
 			if voiceSrc != "" {
 				detail += "  " + voiceSrc
 			}
+			if failed := runner.ExplainWhen(s, conds); len(failed) > 0 {
+				detail += "  (failed: " + strings.Join(failed, ", ") + ")"
+			}
+			if s.Routed {
+				detail += "  (routing." + severity + ")"
+			} else if !runner.Routed(s, conds) {
+				detail += "  (not routed for " + severity + ")"
+			}
 			fmt.Printf("    %s[%d] %-10s %s\n", marker, i+1, s.Type, detail)
+			printFallback(s.Fallback, 1, voiceCache, cfg.Options.Voice.Voice)
+		}
+		if act.OnSuccess != "" {
+			fmt.Printf("      on_success -> %s\n", act.OnSuccess)
+		}
+		if act.OnFailure != "" {
+			fmt.Printf("      on_failure -> %s\n", act.OnFailure)
 		}
 	}
 }
 
+// printFallback renders a step's fallback chain below it in the dry-run,
+// indenting one level per nesting depth.
+func printFallback(steps []config.Step, depth int, cache *voice.Cache, voiceName string) {
+	indent := strings.Repeat("  ", depth)
+	for _, s := range steps {
+		detail := eventlog.StepSummary(s, nil)
+		if src := dryRunVoiceSource(s, cache, voiceName); src != "" {
+			detail += "  " + src
+		}
+		fmt.Printf("           %selse %-10s %s\n", indent, s.Type, detail)
+		printFallback(s.Fallback, depth+1, cache, voiceName)
+	}
+}
+
 // dryRunVoiceSource returns a parenthetical voice source label for voice-capable
-// step types (say, discord_voice, telegram_audio, telegram_voice).
+// step types (say, discord_voice, telegram_audio, telegram_voice, ...).
 // Returns "" for non-voice steps.
 func dryRunVoiceSource(s config.Step, cache *voice.Cache, voiceName string) string {
-	switch s.Type {
-	case "say", "discord_voice", "telegram_audio", "telegram_voice":
-		// voice-capable step — continue
-	default:
+	if h, ok := config.LookupStep(s.Type); !ok || !h.UsesVoice() {
 		return ""
 	}
 	if tmpl.HasDynamic(s.Text) {
@@ -425,6 +563,9 @@ func dashboardCmd(configPath string, port int, open bool) {
 // hookCmd handles the internal "_hook" command called by shell hook snippets.
 // Usage: notify _hook --command <cmd> --seconds <N> --exit <code> [profile]
 func hookCmd(args []string, configPath string, opts runOpts) {
+	// Output stays empty: the hook never sees the command's output, so
+	// output: conditions are false here. shell-hook install warns about
+	// them, since the hook's own stderr is discarded.
 	opts.RunMode = true
 	var command string
 	seconds := 0
@@ -477,11 +618,13 @@ func hookCmd(args []string, configPath string, opts runOpts) {
 
 	elapsed := time.Duration(seconds) * time.Second
 	opts.Elapsed = elapsed
+	opts.ExitCode = &exitCode
 	dispatchActions(cfg, profile, actionArg, opts,
 		func(v *tmpl.Vars) {
 			v.Command = command
 			v.Duration = formatDuration(elapsed)
 			v.DurationSay = formatDurationSay(elapsed)
+			v.TmuxPane = os.Getenv("TMUX_PANE") // the hook runs in the command's shell
 		})
 }
 
@@ -15,7 +15,7 @@ import (
 
 func TestSendTypesContainsExpected(t *testing.T) {
 	expected := []string{
-		"say", "toast",
+		"say", "toast", "terminal", "tmux",
 		"discord", "discord_voice",
 		"slack",
 		"telegram", "telegram_audio", "telegram_voice",
@@ -27,8 +27,16 @@ func TestSendTypesContainsExpected(t *testing.T) {
 	}
 }
 
+func TestSendTypesBuildValidSteps(t *testing.T) {
+	for typ := range sendTypes {
+		if errs := config.StepFieldErrors(sendStep(typ, "x", "")); len(errs) != 0 {
+			t.Errorf("send %s: %v", typ, errs)
+		}
+	}
+}
+
 func TestSendTypesExcludesNonSendable(t *testing.T) {
-	excluded := []string{"sound", "webhook", "plugin", "mqtt"}
+	excluded := []string{"sound", "webhook", "plugin", "mqtt", "pagerduty", "opsgenie"}
 	for _, typ := range excluded {
 		if sendTypes[typ] {
 			t.Errorf("sendTypes should not include %q", typ)
@@ -0,0 +1,18 @@
+//go:build windows
+
+package main
+
+import "syscall"
+
+// detachedProcess is the DETACHED_PROCESS creation flag: the child gets no
+// console, so closing the invoking console window doesn't end it.
+const detachedProcess = 0x00000008
+
+// detached starts a background job without a console and in its own
+// process group, so Ctrl-C in the invoking console doesn't reach it.
+func detached() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{
+		CreationFlags: detachedProcess | syscall.CREATE_NEW_PROCESS_GROUP,
+		HideWindow:    true,
+	}
+}
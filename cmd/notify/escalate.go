@@ -0,0 +1,120 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/Mavwarf/notify/internal/config"
+	"github.com/Mavwarf/notify/internal/eventlog"
+	"github.com/Mavwarf/notify/internal/tmpl"
+)
+
+// escalateSleep waits between escalation re-fires. Replaced in tests.
+var escalateSleep = time.Sleep
+
+// escalate opens an escalation for an action with an "escalate" block
+// after its first notification and hands the re-fires to a background
+// job (runEscalation), so the invoking command returns right away and
+// Ctrl-C or closing the terminal doesn't cancel it. vars.AckID identifies
+// the escalation; its state changes are recorded in the event log, where
+// acknowledgements are looked up. If the job can't start, the re-fires run
+// here instead.
+func escalate(cfg config.Config, profile, action string, act *config.Action, opts runOpts, vars tmpl.Vars) {
+	id := vars.AckID
+	// Log timestamps have second resolution; only records from this
+	// escalation onwards are scanned for the acknowledgement.
+	started := time.Now().Truncate(time.Second)
+	eventlog.LogEscalation(id, profile, action, eventlog.EscalationOpen)
+	fmt.Fprintf(os.Stderr, "notify: escalating %s every %s until acknowledged (notify ack %s)\n", action, act.Escalate.Every, id)
+
+	j := job{Kind: jobEscalate, Profile: profile, Action: action, At: started, Opts: opts, Vars: vars}
+	if err := startJob(j); err != nil {
+		fmt.Fprintf(os.Stderr, "notify: background escalation: %v; escalating in the foreground\n", err)
+		runEscalation(cfg, profile, action, act, opts, vars, started)
+	}
+}
+
+// runEscalation re-fires the escalation opened at started until it is
+// acknowledged or act.Escalate.Max re-fires have run; config validation
+// requires Max >= 1 so it always ends.
+func runEscalation(cfg config.Config, profile, action string, act *config.Action, opts runOpts, vars tmpl.Vars, started time.Time) {
+	e := act.Escalate
+	id := vars.AckID
+
+	nextProfile, next, nextAct := profile, action, act
+	if e.Action != "" {
+		p, a, err := config.Resolve(cfg, profile, e.Action)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "notify: escalate: %v\n", err)
+			eventlog.LogEscalation(id, profile, action, eventlog.EscalationEnded)
+			return
+		}
+		nextProfile, next, nextAct = p, e.Action, a
+	}
+
+	opts.Escalation = id
+	for n := 1; n <= e.Max; n++ {
+		escalateSleep(e.Interval())
+		if escalationAcked(id, started) {
+			fmt.Fprintf(os.Stderr, "notify: %s acknowledged\n", id)
+			return
+		}
+		if err := executeAction(cfg, nextProfile, next, nextAct, opts, vars); err != nil {
+			fmt.Fprintf(os.Stderr, "notify: escalate %s: %v\n", id, err)
+		}
+	}
+	eventlog.LogEscalation(id, profile, action, eventlog.EscalationEnded)
+	fmt.Fprintf(os.Stderr, "notify: %s not acknowledged after %d re-fires, giving up\n", id, e.Max)
+}
+
+// escalationAcked reports whether the escalation was acknowledged, looking
+// only at log records since it started. Event log errors count as not
+// acknowledged, so a broken log keeps paging rather than going quiet.
+func escalationAcked(id string, since time.Time) bool {
+	e, ok, err := eventlog.FindEscalation(id, since)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "notify: escalate: %v\n", err)
+		return false
+	}
+	return ok && e.State == eventlog.EscalationAcked
+}
+
+// newAckID returns a short random escalation ID.
+func newAckID() string {
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%08x", time.Now().UnixNano()&0xffffffff)
+	}
+	return hex.EncodeToString(b)
+}
+
+// ackCmd handles "notify ack [id]": acknowledges an escalation, or lists
+// the open ones when no ID is given.
+func ackCmd(args []string) {
+	if len(args) == 0 {
+		ackList()
+		return
+	}
+	e, err := eventlog.Ack(args[0])
+	if err != nil {
+		fatal("%v", err)
+	}
+	fmt.Printf("Acknowledged %s (%s/%s)\n", e.ID, e.Profile, e.Action)
+}
+
+func ackList() {
+	open, err := eventlog.OpenEscalations()
+	if err != nil {
+		fatal("%v", err)
+	}
+	if len(open) == 0 {
+		fmt.Println("No open escalations")
+		return
+	}
+	for _, e := range open {
+		fmt.Printf("%s  %s  %s/%s\n", e.ID, e.Opened.Format("2006-01-02 15:04:05"), e.Profile, e.Action)
+	}
+}
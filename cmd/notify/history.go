@@ -64,6 +64,9 @@ func historyCmd(args []string) {
 		kind := eventlog.KindString(e.Kind)
 		fmt.Printf("%s  profile=%s  action=%s  %s",
 			e.Time.Format("2006-01-02 15:04:05"), e.Profile, e.Action, kind)
+		if e.Repeat > 0 {
+			fmt.Printf("  repeat=%d", e.Repeat)
+		}
 		if e.ClaudeHook != "" {
 			fmt.Printf("  claude_hook=%s", e.ClaudeHook)
 		}
@@ -71,12 +74,40 @@ func historyCmd(args []string) {
 			fmt.Printf("  claude_message=%q", e.ClaudeMessage)
 		}
 		fmt.Println()
+		for n, r := range e.Steps {
+			if r.Status != "" {
+				fmt.Println(formatStepResult(n+1, r))
+			}
+		}
 		if i < len(entries)-1 {
 			fmt.Println()
 		}
 	}
 }
 
+// formatStepResult renders one step's delivery result as an indented
+// history line, e.g. "  step[2] telegram  queued  2 attempts  2.1s  <error>".
+func formatStepResult(num int, r eventlog.StepResult) string {
+	status := r.Status
+	switch status {
+	case "ok":
+		status = green(status)
+	case "failed", "queued":
+		status = red(status)
+	case "fallback":
+		status = yellow(status)
+	}
+	line := fmt.Sprintf("  step[%d] %s  %s", num, r.Type, status)
+	if r.Attempts > 0 {
+		line += "  " + pluralize(r.Attempts, "attempt", "attempts")
+		line += "  " + formatDuration(r.Latency)
+	}
+	if r.Error != "" {
+		line += "  " + r.Error
+	}
+	return line
+}
+
 // historySummary renders an aggregated summary table of notification counts
 // grouped by profile and action, over the last N days (default 7) or all time.
 func historySummary(args []string) {
@@ -155,6 +186,7 @@ func dim(s string) string    { return ansi("\033[2m", s) }
 func cyan(s string) string   { return ansi("\033[36m", s) }
 func green(s string) string  { return ansi("\033[32m", s) }
 func yellow(s string) string { return ansi("\033[33m", s) }
+func red(s string) string    { return ansi("\033[31m", s) }
 
 // fmtNum formats an integer with dot as thousands separator (e.g. 1234 → "1.234").
 func fmtNum(n int) string {
@@ -859,3 +859,24 @@ func TestHistoryRemoveNotFound(t *testing.T) {
 		t.Errorf("expected 1 entry unchanged, got %d", len(entries))
 	}
 }
+
+// --- formatStepResult ---
+
+func TestFormatStepResult(t *testing.T) {
+	tests := []struct {
+		r    eventlog.StepResult
+		want string
+	}{
+		{eventlog.StepResult{Type: "sound", Status: "ok", Attempts: 1, Latency: 5 * time.Millisecond},
+			"  step[1] sound  ok  1 attempt  5ms"},
+		{eventlog.StepResult{Type: "telegram", Status: "queued", Attempts: 2, Latency: 2100 * time.Millisecond, Error: "telegram: timeout"},
+			"  step[1] telegram  queued  2 attempts  2s  telegram: timeout"},
+		{eventlog.StepResult{Type: "say", Status: "skipped"},
+			"  step[1] say  skipped"},
+	}
+	for _, tt := range tests {
+		if got := formatStepResult(1, tt.r); got != tt.want {
+			t.Errorf("formatStepResult(%+v) = %q, want %q", tt.r, got, tt.want)
+		}
+	}
+}
@@ -4,11 +4,13 @@ import (
 	"bufio"
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"os/exec"
 	"runtime"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
@@ -16,10 +18,12 @@ import (
 
 	"net/url"
 
+	"github.com/Mavwarf/notify/internal/batch"
 	"github.com/Mavwarf/notify/internal/config"
 	"github.com/Mavwarf/notify/internal/cooldown"
 	"github.com/Mavwarf/notify/internal/desktop"
 	"github.com/Mavwarf/notify/internal/eventlog"
+	"github.com/Mavwarf/notify/internal/holiday"
 	"github.com/Mavwarf/notify/internal/idle"
 	"github.com/Mavwarf/notify/internal/runner"
 	"github.com/Mavwarf/notify/internal/silent"
@@ -68,6 +72,28 @@ type runOpts struct {
 	Elapsed  time.Duration
 	Delay    time.Duration
 	AtTime   string
+	Chain    []string // actions already run in this on_success/on_failure chain
+	ExitCode *int     // wrapped command exit code, for exit: conditions
+	Output   string   // full captured command output, for output: conditions
+	Severity string   // --severity override of the action's severity
+	Config   string   // --config path, for background jobs to reload
+
+	// Escalation is the ack ID when re-firing an escalation. Re-fires skip
+	// cooldown and don't open a new escalation.
+	Escalation string
+}
+
+// conditions returns the state that step when conditions are evaluated
+// against for this invocation.
+func (o runOpts) conditions(cfg config.Config, afk bool) runner.Conditions {
+	return runner.Conditions{
+		AFK:      afk,
+		Run:      o.RunMode,
+		Elapsed:  o.Elapsed,
+		ExitCode: o.ExitCode,
+		Output:   o.Output,
+		Holidays: holiday.Load(cfg.Options.HolidayFile),
+	}
 }
 
 // fatal prints an error message to stderr and exits with code 1.
@@ -89,6 +115,7 @@ type parsedFlags struct {
 	protocolURI  string
 	delayDur     time.Duration
 	atTime       string
+	severity     string
 	matches      []matchPair
 	args         []string // positional arguments after flag extraction
 }
@@ -186,6 +213,13 @@ func parseFlags(args []string) parsedFlags {
 			} else {
 				fatal("--at requires a time (e.g. 14:30, 2:30PM)")
 			}
+		case "--severity", "-S":
+			if i+1 < len(args) && config.ValidSeverity(args[i+1]) {
+				f.severity = args[i+1]
+				i++
+			} else {
+				fatal("--severity requires info, warning, or critical")
+			}
 		default:
 			filtered = append(filtered, args[i])
 		}
@@ -254,26 +288,32 @@ func main() {
 	case "protocol":
 		protocolCmd(f.args[1:])
 	case "send":
-		opts := runOpts{Volume: f.volume, Log: f.logFlag, Echo: f.echoFlag}
+		opts := runOpts{Config: f.configPath, Volume: f.volume, Log: f.logFlag, Echo: f.echoFlag}
 		sendCmd(f.args[1:], f.configPath, opts)
 	case "silent":
 		silentCmd(f.args[1:], f.configPath, f.logFlag)
+	case "outbox":
+		outboxCmd(f.args[1:], f.configPath)
+	case "ack":
+		ackCmd(f.args[1:])
 	case "run":
-		opts := runOpts{Volume: f.volume, Log: f.logFlag, Echo: f.echoFlag, Cooldown: f.cooldownFlag}
+		opts := runOpts{Config: f.configPath, Volume: f.volume, Log: f.logFlag, Echo: f.echoFlag, Cooldown: f.cooldownFlag, Severity: f.severity}
 		runWrapped(f.args[1:], f.configPath, opts, f.matches, f.heartbeatSec)
 	case "watch":
-		opts := runOpts{Volume: f.volume, Log: f.logFlag, Echo: f.echoFlag, Cooldown: f.cooldownFlag}
+		opts := runOpts{Config: f.configPath, Volume: f.volume, Log: f.logFlag, Echo: f.echoFlag, Cooldown: f.cooldownFlag, Severity: f.severity}
 		watchCmd(f.args[1:], f.configPath, opts)
 	case "shell-hook":
 		shellHookCmd(f.args[1:], f.configPath)
+	case "_job":
+		jobCmd(f.args[1:], f.configPath)
 	case "_hook":
-		opts := runOpts{Volume: f.volume, Log: f.logFlag, Echo: f.echoFlag, Cooldown: f.cooldownFlag}
+		opts := runOpts{Config: f.configPath, Volume: f.volume, Log: f.logFlag, Echo: f.echoFlag, Cooldown: f.cooldownFlag, Severity: f.severity}
 		hookCmd(f.args[1:], f.configPath, opts)
 	case "pipe":
-		opts := runOpts{Volume: f.volume, Log: f.logFlag, Echo: f.echoFlag, Cooldown: f.cooldownFlag}
+		opts := runOpts{Config: f.configPath, Volume: f.volume, Log: f.logFlag, Echo: f.echoFlag, Cooldown: f.cooldownFlag, Severity: f.severity}
 		runPipe(f.args[1:], f.configPath, opts, f.matches)
 	default:
-		opts := runOpts{Volume: f.volume, Log: f.logFlag, Echo: f.echoFlag, Cooldown: f.cooldownFlag, Delay: f.delayDur, AtTime: f.atTime}
+		opts := runOpts{Config: f.configPath, Volume: f.volume, Log: f.logFlag, Echo: f.echoFlag, Cooldown: f.cooldownFlag, Severity: f.severity, Delay: f.delayDur, AtTime: f.atTime}
 		runAction(f.args, f.configPath, opts)
 	}
 }
@@ -357,8 +397,11 @@ func runWrapped(args []string, configPath string, opts runOpts, matches []matchP
 
 	profile = resolveProfile(cfg, profile, explicit)
 
+	// The pane the command runs in, for tmux steps.
+	pane := os.Getenv("TMUX_PANE")
+
 	// Determine whether output capture is needed.
-	captureOutput := len(matches) > 0 || cfg.Options.OutputLines > 0
+	captureOutput := len(matches) > 0 || cfg.Options.OutputLines > 0 || usesOutputCondition(cfg)
 
 	// Execute the wrapped command.
 	start := time.Now()
@@ -396,6 +439,7 @@ func runWrapped(args []string, configPath string, opts runOpts, matches []matchP
 							v.Command = cmdStr
 							v.Duration = formatDuration(elapsed)
 							v.DurationSay = formatDurationSay(elapsed)
+							v.TmuxPane = pane
 						})
 				}
 			}
@@ -438,17 +482,53 @@ func runWrapped(args []string, configPath string, opts runOpts, matches []matchP
 	// Error deliberately ignored: the wrapped command's exit code takes
 	// priority so the caller can distinguish command failure from notify failure.
 	opts.Elapsed = elapsed
+	opts.ExitCode = &exitCode
+	opts.Output = fullOutput
 	dispatchActions(cfg, profile, actionArg, opts,
 		func(v *tmpl.Vars) {
 			v.Command = strings.Join(cmdArgs, " ")
 			v.Duration = formatDuration(elapsed)
 			v.DurationSay = formatDurationSay(elapsed)
 			v.Output = outputSnippet
+			v.TmuxPane = pane
 		})
 
 	os.Exit(exitCode)
 }
 
+// usesOutputCondition reports whether any step in the config has an
+// output: condition, which requires capturing the wrapped command's output.
+func usesOutputCondition(cfg config.Config) bool {
+	for _, p := range cfg.Profiles {
+		for _, a := range p.Actions {
+			if stepsUseOutputCondition(a.Steps) {
+				return true
+			}
+		}
+	}
+	for _, r := range cfg.Options.Routing {
+		if stepsUseOutputCondition(r.Steps) {
+			return true
+		}
+	}
+	return false
+}
+
+// stepsUseOutputCondition reports whether any of steps has an output:
+// atom in its parsed when condition.
+func stepsUseOutputCondition(steps []config.Step) bool {
+	for _, s := range steps {
+		e, err := s.Condition()
+		if err != nil || e == nil {
+			continue
+		}
+		if e.HasAtom(func(a string) bool { return strings.HasPrefix(a, "output:") }) {
+			return true
+		}
+	}
+	return false
+}
+
 // runPipe reads stdin line-by-line and triggers a notification per line.
 // With --match flags, only matching lines fire (using the matched action);
 // without --match, every line triggers the "ready" action.
@@ -503,6 +583,15 @@ func dispatchActions(cfg config.Config, profile, actionArg string,
 	actions := strings.Split(actionArg, ",")
 	var failed bool
 
+	// Retry previously failed remote steps whose backoff has elapsed, in
+	// parallel with this invocation's own steps.
+	outboxDone := make(chan struct{})
+	go func() {
+		defer close(outboxDone)
+		runner.RetryOutbox(cfg, false, "")
+	}()
+	defer func() { <-outboxDone }()
+
 	for _, action := range actions {
 		if silent.IsSilent() {
 			if shouldLog(cfg, opts.Log) {
@@ -522,6 +611,17 @@ func dispatchActions(cfg config.Config, profile, actionArg string,
 		if extraVars != nil {
 			extraVars(&vars)
 		}
+		if d := config.BatchWindow(cfg.Profiles[resolved], *act); d > 0 {
+			fireStale := func(v tmpl.Vars) {
+				if err := executeAction(cfg, resolved, action, act, opts, v); err != nil {
+					fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+					failed = true
+				}
+			}
+			if !waitBatch(action, d, &vars, shouldLog(cfg, opts.Log), fireStale) {
+				continue
+			}
+		}
 		if err := executeAction(cfg, resolved, action, act, opts, vars); err != nil {
 			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 			failed = true
@@ -534,6 +634,50 @@ func dispatchActions(cfg config.Config, profile, actionArg string,
 	return nil
 }
 
+// waitBatch adds this invocation to the batch window for action. The
+// process that opens the window waits until it closes, sets {batch_count}
+// and {batch_list} in vars, and returns true to fire the combined
+// notification. Every other process returns false: its notification is
+// part of the batch, and it is logged as batched when logIt is set. If
+// the window it replaced was abandoned by a killed opener, that window's
+// items are passed to fireStale first, as their own combined notification.
+func waitBatch(action string, d time.Duration, vars *tmpl.Vars, logIt bool, fireStale func(tmpl.Vars)) bool {
+	t := batch.Join(action, vars.Profile, d)
+	if len(t.Stale) > 0 {
+		stale := *vars
+		setBatchVars(&stale, t.Stale)
+		fireStale(stale)
+	}
+	if !t.Opened {
+		fmt.Fprintf(os.Stderr, "notify: %s batched (%d so far, fires at %s)\n",
+			action, t.Count, t.Deadline.Format("15:04:05"))
+		if logIt {
+			eventlog.LogBatched(vars.Profile, action, t.Count)
+		}
+		return false
+	}
+	time.Sleep(time.Until(t.Deadline))
+	setBatchVars(vars, batch.Close(action, t, vars.Profile))
+	return true
+}
+
+// setBatchVars sets {batch_count} and {batch_list} for a batch's items.
+func setBatchVars(vars *tmpl.Vars, items []string) {
+	vars.BatchCount = strconv.Itoa(len(items))
+	vars.BatchList = batchList(items)
+}
+
+// batchList joins the distinct batch items in arrival order.
+func batchList(items []string) string {
+	var uniq []string
+	for _, it := range items {
+		if !slices.Contains(uniq, it) {
+			uniq = append(uniq, it)
+		}
+	}
+	return strings.Join(uniq, ", ")
+}
+
 // loadAndValidate loads and validates the config file, returning an error
 // on any problem (instead of calling os.Exit directly).
 func loadAndValidate(configPath string) (config.Config, error) {
@@ -556,35 +700,133 @@ func executeAction(cfg config.Config, profile, action string, act *config.Action
 	opts runOpts, vars tmpl.Vars) error {
 
 	cdEnabled, cdSec := resolveCooldown(act, cfg, opts.Cooldown)
-	if cdEnabled && cdSec > 0 && cooldown.Check(profile, action, cdSec) {
-		if shouldLog(cfg, opts.Log) {
-			eventlog.LogCooldown(profile, action, cdSec)
+	// Escalation re-fires and dedup follow-ups (whose Flush already
+	// started the next window) bypass the cooldown.
+	record := cdEnabled && cdSec > 0 && opts.Escalation == "" && vars.RepeatCount == ""
+	if record && cooldown.Check(profile, action, cdSec) {
+		if !resolveDedup(act, cfg) {
+			if shouldLog(cfg, opts.Log) {
+				eventlog.LogCooldown(profile, action, cdSec)
+			}
+			return nil
 		}
+		countRepeat(cfg, profile, action, act, opts, vars, cdSec)
 		return nil
 	}
+	escalating := act.Escalate != nil && opts.Escalation == ""
+	if escalating {
+		vars.AckID = newAckID()
+	}
 
 	afk := detectAFK(cfg)
 
 	creds := config.MergeCredentials(cfg.Options.Credentials, cfg.Profiles[profile].Credentials)
 
 	desk := cfg.Profiles[profile].Desktop
-	filtered := runner.FilterSteps(act.Steps, afk, opts.RunMode, opts.Elapsed)
-	err := runner.Execute(filtered, opts.Volume, creds, vars, desk)
-	if cdEnabled && cdSec > 0 {
+	vars.Severity = resolveSeverity(opts.Severity, act)
+	vars.Action = action
+	if opts.ExitCode != nil {
+		vars.ExitCode = strconv.Itoa(*opts.ExitCode)
+	}
+	cond := opts.conditions(cfg, afk)
+	cond.Repeat = vars.RepeatCount != ""
+	cond.Route = cfg.Options.RouteFor(vars.Severity, afk)
+	filtered := runner.FilterSteps(cfg.Options.RoutedSteps(act.Steps, vars.Severity), cond)
+	results, err := runner.Execute(filtered, opts.Volume, creds, vars, desk)
+	if record {
 		cooldown.Record(profile, action)
 		if shouldLog(cfg, opts.Log) {
 			eventlog.LogCooldownRecord(profile, action, cdSec)
 		}
 	}
 	if shouldLog(cfg, opts.Log) {
-		eventlog.Log(action, filtered, afk, vars, desk)
+		eventlog.Log(action, filtered, logResults(results), afk, vars, desk)
 	}
 	if shouldEcho(cfg, opts.Echo) {
 		printEcho(filtered)
 	}
+	if next := chainedAction(act, err); next != "" {
+		if chainErr := runChained(cfg, profile, action, next, opts, vars); chainErr != nil {
+			err = errors.Join(err, chainErr)
+		}
+	}
+	if escalating {
+		escalate(cfg, profile, action, act, opts, vars)
+	}
 	return err
 }
 
+// countRepeat counts an invocation that arrived within the cooldown
+// window in dedup mode. The first repeat in a window starts a background
+// job that sends the follow-up when the window closes; later repeats only
+// add to the count. If the job can't start, the follow-up is sent from
+// here after waiting, so the count isn't lost.
+func countRepeat(cfg config.Config, profile, action string, act *config.Action, opts runOpts, vars tmpl.Vars, cdSec int) {
+	n, closes := cooldown.Suppress(profile, action, cdSec)
+	if shouldLog(cfg, opts.Log) {
+		eventlog.LogDedup(profile, action, cdSec, n)
+	}
+	if n != 1 {
+		return
+	}
+	j := job{Kind: jobRepeats, Profile: profile, Action: action, At: closes, Opts: opts, Vars: vars}
+	if err := startJob(j); err != nil {
+		fmt.Fprintf(os.Stderr, "notify: background follow-up: %v; waiting for the cooldown window instead\n", err)
+		if err := sendRepeats(cfg, profile, action, act, opts, vars, closes); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		}
+	}
+}
+
+// repeatSleep waits for a cooldown window to close. Replaced in tests.
+var repeatSleep = time.Sleep
+
+// sendRepeats waits until the cooldown window closes at closes and sends
+// the follow-up with the number of repeats counted in it, if any.
+func sendRepeats(cfg config.Config, profile, action string, act *config.Action, opts runOpts, vars tmpl.Vars, closes time.Time) error {
+	repeatSleep(time.Until(closes))
+	n := cooldown.Flush(profile, action)
+	if n == 0 {
+		return nil
+	}
+	vars.RepeatCount = strconv.Itoa(n)
+	return executeAction(cfg, profile, action, act, opts, vars)
+}
+
+// chainedAction returns the action to run after act finished with the
+// given execution error: OnFailure if any step failed, OnSuccess otherwise.
+func chainedAction(act *config.Action, err error) string {
+	if err != nil {
+		return act.OnFailure
+	}
+	return act.OnSuccess
+}
+
+// runChained executes the on_success/on_failure action next after from,
+// with the same template vars. Validation rejects loops; opts.Chain guards
+// against them at runtime too, in case the config was never validated.
+func runChained(cfg config.Config, profile, from, next string, opts runOpts, vars tmpl.Vars) error {
+	chain := append(append([]string(nil), opts.Chain...), from)
+	if slices.Contains(chain, next) {
+		return fmt.Errorf("%s: chained action %q already ran (loop)", from, next)
+	}
+	resolved, act, err := config.Resolve(cfg, profile, next)
+	if err != nil {
+		return err
+	}
+	opts.Chain = chain
+	return executeAction(cfg, resolved, next, act, opts, vars)
+}
+
+// logResults converts runner step results into their event log form.
+func logResults(results []runner.StepResult) []eventlog.StepResult {
+	out := make([]eventlog.StepResult, len(results))
+	for i, r := range results {
+		out[i] = eventlog.StepResult{Type: r.Type, Status: r.Status, Error: r.Error, Latency: r.Latency, Attempts: r.Attempts}
+	}
+	return out
+}
+
 // baseVars returns a Vars with profile, time, date, and hostname pre-filled.
 func baseVars(profile string) tmpl.Vars {
 	host, _ := os.Hostname()
@@ -675,6 +917,21 @@ func resolveCooldown(act *config.Action, cfg config.Config, flag bool) (bool, in
 	return enabled, sec
 }
 
+// resolveDedup reports whether repeats within the cooldown window are
+// collapsed into a follow-up instead of dropped (config or per-action).
+func resolveDedup(act *config.Action, cfg config.Config) bool {
+	return cfg.Options.Dedup || act.Dedup
+}
+
+// resolveSeverity returns the effective severity of an action. CLI flag
+// wins if set, then the action's "severity", then info.
+func resolveSeverity(flag string, act *config.Action) string {
+	if flag != "" {
+		return flag
+	}
+	return act.EffectiveSeverity()
+}
+
 // resolveHeartbeat returns the effective heartbeat interval in seconds.
 // CLI flag wins if > 0, otherwise config value is used. 0 = disabled.
 func resolveHeartbeat(cfg config.Config, flagSec int) int {
@@ -858,13 +1115,25 @@ func pluralize(n int, singular, plural string) string {
 }
 
 // handleProtocolURI handles a notify:// protocol activation URI.
-// Called when Windows launches the exe via toast click.
+// Called when Windows launches the exe via toast click. Supported:
+// notify://switch?desktop=N and notify://ack?id=ID.
 func handleProtocolURI(uri string) {
 	u, err := url.Parse(uri)
 	if err != nil {
 		fatal("invalid protocol URI: %v", err)
 	}
-	if u.Host != "switch" {
+	switch u.Host {
+	case "switch":
+	case "ack":
+		id := u.Query().Get("id")
+		if id == "" {
+			fatal("missing id parameter in URI")
+		}
+		if _, err := eventlog.Ack(id); err != nil {
+			fatal("%v", err)
+		}
+		return
+	default:
 		fatal("unknown protocol action: %s", u.Host)
 	}
 	dStr := u.Query().Get("desktop")
@@ -956,6 +1225,7 @@ Options:
   --heartbeat, -H <dur>  Periodic notification during "run" (e.g. 5m, 2m30s)
   --delay, -D <dur>      Sleep before firing (e.g. 5s, 10m, 1h)
   --at, -A <time>        Fire at a specific time (e.g. 14:30, 2:30PM)
+  --severity, -S <level> Override the action's severity (info, warning, critical)
   --port, -p <1-65535>   Port for "dashboard" command (default: 8080)
   --open, -O             Open dashboard in a browser window (app mode)
   --protocol <URI>       Handle a notify:// protocol activation (internal)
@@ -964,8 +1234,8 @@ Commands:
   init                   Interactive config generator (or --defaults for quick setup)
   send <type> <message>  Send a one-off notification (e.g. send telegram "hello")
                          Supported: say, toast, discord, discord_voice, slack,
-                         telegram, telegram_audio, telegram_voice
-                         --title <title>  Set toast title (toast only)
+                         telegram, telegram_audio, telegram_voice, email
+                         --title <title>  Set toast title or email subject
   pipe [profile]         Read stdin line-by-line, trigger action on pattern match
                          Without --match, every line triggers "ready"
                          {output} = the matched line
@@ -1006,6 +1276,10 @@ Commands:
   protocol unregister    Remove notify:// URI handler
   protocol status        Show protocol registration and virtual desktop info
   silent [duration|off]  Suppress all notifications for a duration (e.g. 1h, 30m)
+  outbox [list]          Show remote steps queued for retry after failed delivery
+  outbox retry [id]      Retry all queued steps (or one) now, ignoring backoff
+  outbox drop <id|all>   Remove a queued step (or all) from the outbox
+  ack [id]               Acknowledge an escalating notification (no id: list open ones)
   list, -l, --list       List all profiles and actions
   version, -V           Show version and build date
   help, -h, --help       Show this help message
@@ -1,11 +1,18 @@
 package main
 
 import (
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/Mavwarf/notify/internal/batch"
 	"github.com/Mavwarf/notify/internal/config"
+	"github.com/Mavwarf/notify/internal/eventlog"
+	"github.com/Mavwarf/notify/internal/paths"
 	"github.com/Mavwarf/notify/internal/tmpl"
 )
 
@@ -486,3 +493,267 @@ func TestCredStatusNotConfigured(t *testing.T) {
 		t.Errorf("credStatus(false) = %q, want \" not configured\"", got)
 	}
 }
+
+func TestChainedAction(t *testing.T) {
+	act := &config.Action{OnSuccess: "done", OnFailure: "escalate"}
+	if got := chainedAction(act, nil); got != "done" {
+		t.Errorf("success: got %q, want %q", got, "done")
+	}
+	if got := chainedAction(act, errors.New("boom")); got != "escalate" {
+		t.Errorf("failure: got %q, want %q", got, "escalate")
+	}
+	if got := chainedAction(&config.Action{}, errors.New("boom")); got != "" {
+		t.Errorf("no chain: got %q, want empty", got)
+	}
+}
+
+func TestRunChainedStopsLoop(t *testing.T) {
+	cfg := config.Config{Profiles: map[string]config.Profile{
+		"default": {Actions: map[string]config.Action{
+			"ready": {Steps: []config.Step{{Type: "sound", Sound: "blip"}}},
+		}},
+	}}
+	err := runChained(cfg, "default", "escalate", "ready", runOpts{Chain: []string{"ready"}}, tmpl.Vars{})
+	if err == nil || !strings.Contains(err.Error(), "already ran") {
+		t.Errorf("err = %v, want loop error", err)
+	}
+}
+
+func TestUsesOutputCondition(t *testing.T) {
+	cfg := config.Config{Profiles: map[string]config.Profile{
+		"default": {Actions: map[string]config.Action{
+			"error": {Steps: []config.Step{{Type: "sound", Sound: "error", When: "exit:!0"}}},
+		}},
+	}}
+	if usesOutputCondition(cfg) {
+		t.Error("usesOutputCondition = true, want false")
+	}
+	cfg.Profiles["ci"] = config.Profile{Actions: map[string]config.Action{
+		"error": {Steps: []config.Step{{Type: "slack", Text: "x", When: "output:/FATAL/"}}},
+	}}
+	if !usesOutputCondition(cfg) {
+		t.Error("usesOutputCondition = false, want true")
+	}
+}
+
+func TestStepsUseOutputCondition(t *testing.T) {
+	tests := []struct {
+		when string
+		want bool
+	}{
+		{"", false},
+		{"exit:!0", false},
+		{"exit:!0 and not output:/ok/", true},
+		{"afk or (run and output:/FATAL/i)", true},
+		{"output:", false}, // doesn't parse; Validate reports it
+	}
+	for _, tt := range tests {
+		steps := []config.Step{{Type: "sound", Sound: "error", When: tt.when}}
+		if got := stepsUseOutputCondition(steps); got != tt.want {
+			t.Errorf("stepsUseOutputCondition(%q) = %v, want %v", tt.when, got, tt.want)
+		}
+	}
+}
+
+func TestBatchList(t *testing.T) {
+	tests := []struct {
+		items []string
+		want  string
+	}{
+		{[]string{"api"}, "api"},
+		{[]string{"api", "web", "api"}, "api, web"},
+		{[]string{"web", "web"}, "web"},
+	}
+	for _, tt := range tests {
+		if got := batchList(tt.items); got != tt.want {
+			t.Errorf("batchList(%v) = %q, want %q", tt.items, got, tt.want)
+		}
+	}
+}
+
+func TestResolveDedup(t *testing.T) {
+	tests := []struct {
+		name   string
+		global bool
+		action bool
+		want   bool
+	}{
+		{"off", false, false, false},
+		{"global", true, false, true},
+		{"per-action", false, true, true},
+	}
+	for _, tt := range tests {
+		cfg := config.Config{Options: config.Options{Dedup: tt.global}}
+		if got := resolveDedup(&config.Action{Dedup: tt.action}, cfg); got != tt.want {
+			t.Errorf("%s: resolveDedup = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestResolveSeverity(t *testing.T) {
+	tests := []struct {
+		name   string
+		flag   string
+		action string
+		want   string
+	}{
+		{"default", "", "", config.SeverityInfo},
+		{"action", "", "warning", "warning"},
+		{"flag wins", "critical", "warning", "critical"},
+	}
+	for _, tt := range tests {
+		if got := resolveSeverity(tt.flag, &config.Action{Severity: tt.action}); got != tt.want {
+			t.Errorf("%s: resolveSeverity = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestEscalateInBackground(t *testing.T) {
+	defer setupTestStore(t, "")()
+	var jobs []job
+	orig := startJob
+	startJob = func(j job) error {
+		jobs = append(jobs, j)
+		return nil
+	}
+	defer func() { startJob = orig }()
+
+	act := &config.Action{Escalate: &config.Escalate{Every: "1m", Max: 3}}
+	escalate(config.Config{}, "boss", "ready", act, runOpts{}, tmpl.Vars{AckID: "ab12cd34"})
+
+	if len(jobs) != 1 || jobs[0].Kind != jobEscalate || jobs[0].Vars.AckID != "ab12cd34" {
+		t.Fatalf("jobs = %+v, want one escalate job for ab12cd34", jobs)
+	}
+	e, ok, _ := eventlog.FindEscalation("ab12cd34", time.Time{})
+	if !ok || e.State != eventlog.EscalationOpen {
+		t.Errorf("escalation = %+v, want open", e)
+	}
+}
+
+func TestEscalateStopsWhenAcked(t *testing.T) {
+	defer setupTestStore(t, "")()
+	sleeps := 0
+	orig := escalateSleep
+	escalateSleep = func(time.Duration) {
+		sleeps++
+		if sleeps == 2 {
+			eventlog.Ack("ab12cd34")
+		}
+	}
+	defer func() { escalateSleep = orig }()
+
+	act := &config.Action{Escalate: &config.Escalate{Every: "1m", Max: 5}}
+	started := time.Now().Truncate(time.Second)
+	eventlog.LogEscalation("ab12cd34", "boss", "ready", eventlog.EscalationOpen)
+	runEscalation(config.Config{}, "boss", "ready", act, runOpts{}, tmpl.Vars{AckID: "ab12cd34"}, started)
+
+	if sleeps != 2 {
+		t.Errorf("sleeps = %d, want 2 (stop at the first check after ack)", sleeps)
+	}
+	e, ok, _ := eventlog.FindEscalation("ab12cd34", time.Time{})
+	if !ok || e.State != eventlog.EscalationAcked {
+		t.Errorf("escalation = %+v, want acked", e)
+	}
+}
+
+func TestEscalateGivesUpAfterMax(t *testing.T) {
+	defer setupTestStore(t, "")()
+	sleeps := 0
+	orig := escalateSleep
+	escalateSleep = func(time.Duration) { sleeps++ }
+	defer func() { escalateSleep = orig }()
+
+	act := &config.Action{Escalate: &config.Escalate{Every: "1m", Max: 3}}
+	started := time.Now().Truncate(time.Second)
+	eventlog.LogEscalation("ab12cd34", "boss", "ready", eventlog.EscalationOpen)
+	runEscalation(config.Config{}, "boss", "ready", act, runOpts{}, tmpl.Vars{AckID: "ab12cd34"}, started)
+
+	if sleeps != 3 {
+		t.Errorf("sleeps = %d, want 3", sleeps)
+	}
+	e, ok, _ := eventlog.FindEscalation("ab12cd34", time.Time{})
+	if !ok || e.State != eventlog.EscalationEnded {
+		t.Errorf("escalation = %+v, want ended", e)
+	}
+}
+
+func TestDedupFollowUpInBackground(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	out := filepath.Join(t.TempDir(), "out.jsonl")
+	act := config.Action{Dedup: true, CooldownSeconds: 60, Steps: []config.Step{{Type: "file", Path: out, Text: "{repeat_count}"}}}
+	cfg := config.Config{
+		Options:  config.Options{Cooldown: true},
+		Profiles: map[string]config.Profile{"boss": {Actions: map[string]config.Action{"ready": act}}},
+	}
+	var jobs []job
+	origStart, origSleep := startJob, repeatSleep
+	startJob = func(j job) error {
+		jobs = append(jobs, j)
+		return nil
+	}
+	repeatSleep = func(time.Duration) {}
+	defer func() { startJob, repeatSleep = origStart, origSleep }()
+
+	// The first invocation notifies; the three repeats return right away
+	// and only the first of them starts the follow-up job.
+	for range 4 {
+		if err := executeAction(cfg, "boss", "ready", &act, runOpts{}, baseVars("boss")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(jobs) != 1 || jobs[0].Kind != jobRepeats {
+		t.Fatalf("jobs = %+v, want one repeats job", jobs)
+	}
+	if time.Until(jobs[0].At) < 59*time.Second {
+		t.Errorf("job at %v, want when the 60s window closes", jobs[0].At)
+	}
+
+	if err := runJob(cfg, jobs[0]); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[1], `"text":"3"`) {
+		t.Errorf("file sink = %q, want the notification and a follow-up for 3 repeats", lines)
+	}
+}
+
+func TestWaitBatchFiresStaleWindow(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	// A window whose opener was killed long before it could fire.
+	stale := fmt.Sprintf(`{"ready": {"id": "dead", "deadline": %q, "items": ["alpha", "beta"]}}`,
+		time.Now().Add(-time.Hour).Format(time.RFC3339))
+	if err := paths.AtomicWrite(filepath.Join(paths.DataDir(), paths.BatchFileName), []byte(stale)); err != nil {
+		t.Fatal(err)
+	}
+
+	var fired []tmpl.Vars
+	vars := tmpl.Vars{Profile: "gamma"}
+	if !waitBatch("ready", 0, &vars, false, func(v tmpl.Vars) { fired = append(fired, v) }) {
+		t.Fatal("waitBatch = false, want this invocation to open the new window")
+	}
+	if len(fired) != 1 || fired[0].BatchCount != "2" || fired[0].BatchList != "alpha, beta" {
+		t.Errorf("fired = %+v, want the stale window's alpha and beta", fired)
+	}
+	if vars.BatchCount != "1" || vars.BatchList != "gamma" {
+		t.Errorf("vars = %+v, want a batch of gamma alone", vars)
+	}
+}
+
+func TestWaitBatchLogsJoined(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	defer setupTestStore(t, "")()
+	batch.Join("ready", "alpha", time.Hour)
+
+	vars := tmpl.Vars{Profile: "beta"}
+	if waitBatch("ready", time.Hour, &vars, true, func(tmpl.Vars) { t.Error("nothing is stale") }) {
+		t.Fatal("waitBatch = true, want the invocation to join the open window")
+	}
+	entries, _ := eventlog.Default.Entries(0)
+	if len(entries) != 1 || entries[0].Kind != eventlog.KindBatched || entries[0].Profile != "beta" {
+		t.Errorf("entries = %+v, want one batched entry for beta", entries)
+	}
+}
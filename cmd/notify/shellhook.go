@@ -74,15 +74,14 @@ func resolveShell(override string) string {
 func shellHookInstall(configPath, shellOverride string, thresholdOverride int) {
 	sh := resolveShell(shellOverride)
 
+	cfg, cfgErr := config.Load(configPath)
+
 	// Resolve threshold: CLI flag > config > default.
 	threshold := DefaultShellHookThreshold
 	if thresholdOverride >= 0 {
 		threshold = thresholdOverride
-	} else {
-		cfg, err := config.Load(configPath)
-		if err == nil && cfg.Options.ShellHookThreshold > 0 {
-			threshold = cfg.Options.ShellHookThreshold
-		}
+	} else if cfgErr == nil && cfg.Options.ShellHookThreshold > 0 {
+		threshold = cfg.Options.ShellHookThreshold
 	}
 
 	// Resolve notify binary path.
@@ -98,6 +97,11 @@ func shellHookInstall(configPath, shellOverride string, thresholdOverride int) {
 	}
 
 	fmt.Printf("notify: shell hook installed in %s (threshold: %ds)\n", configFile, threshold)
+	// The hook runs detached with its output discarded, so warn now: it
+	// only sees the exit code, and output: conditions never match there.
+	if cfgErr == nil && usesOutputCondition(cfg) {
+		fmt.Fprintf(os.Stderr, "notify: warning: output: conditions never match from the shell hook, which doesn't capture command output (use notify run)\n")
+	}
 
 	switch sh {
 	case "bash":
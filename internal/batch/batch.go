@@ -0,0 +1,131 @@
+// Package batch collects notifications that arrive within a time window
+// into one combined notification. State lives in batch.json in the data
+// directory so concurrent notify processes share the same window.
+package batch
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Mavwarf/notify/internal/paths"
+)
+
+// staleAfter is how long past its deadline an unclosed batch is kept. An
+// older one was abandoned by a killed opener: the next Join replaces it
+// and hands its items to the caller to fire (Ticket.Stale).
+const staleAfter = time.Minute
+
+// window is one open batch. The process that opened it (identified by ID)
+// fires the combined notification after Deadline.
+type window struct {
+	ID       string    `json:"id"`
+	Deadline time.Time `json:"deadline"`
+	Items    []string  `json:"items"`
+}
+
+// Ticket is the result of Join. Opened is true for the process that
+// opened the window: it must wait until Deadline, then call Close and
+// fire the combined notification. Otherwise the item was added to a
+// window another process will fire, and Count is the batch size so far.
+// Stale holds the items of an abandoned window this Join replaced; the
+// caller fires them right away so they aren't lost.
+type Ticket struct {
+	Opened   bool
+	ID       string
+	Deadline time.Time
+	Count    int
+	Stale    []string
+}
+
+// Join adds item to the open batch for key, or opens a new batch that
+// closes after d. Errors are printed to stderr and open a batch that
+// closes immediately (fail-open: the notification fires on its own).
+func Join(key, item string, d time.Duration) Ticket {
+	return join(statePath(), key, item, d, time.Now())
+}
+
+// Close removes the batch opened with the given ticket and returns its
+// items in arrival order. If the batch can't be read, returns just the
+// opener's item so the notification still fires.
+func Close(key string, t Ticket, item string) []string {
+	return closeBatch(statePath(), key, t, item)
+}
+
+func join(path, key, item string, d time.Duration, now time.Time) Ticket {
+	var t Ticket
+	err := update(path, func(state map[string]window) {
+		var stale []string
+		if w, ok := state[key]; ok {
+			if now.Sub(w.Deadline) < staleAfter {
+				w.Items = append(w.Items, item)
+				state[key] = w
+				t = Ticket{ID: w.ID, Deadline: w.Deadline, Count: len(w.Items)}
+				return
+			}
+			stale = w.Items
+		}
+		w := window{ID: newID(), Deadline: now.Add(d), Items: []string{item}}
+		state[key] = w
+		t = Ticket{Opened: true, ID: w.ID, Deadline: w.Deadline, Count: 1, Stale: stale}
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "batch: %v\n", err)
+		return Ticket{Opened: true, Deadline: now, Count: 1}
+	}
+	return t
+}
+
+func closeBatch(path, key string, t Ticket, item string) []string {
+	items := []string{item}
+	err := update(path, func(state map[string]window) {
+		if w, ok := state[key]; ok && w.ID == t.ID {
+			items = w.Items
+			delete(state, key)
+		}
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "batch: %v\n", err)
+	}
+	return items
+}
+
+// update applies fn to the batch state under the state file lock and
+// writes it back. A missing or corrupt file is treated as empty.
+func update(path string, fn func(map[string]window)) error {
+	unlock, err := paths.Lock(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	state := map[string]window{}
+	if data, err := os.ReadFile(path); err == nil {
+		_ = json.Unmarshal(data, &state) // ignore corrupt; overwrite
+	}
+	fn(state)
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal: %w", err)
+	}
+	if err := paths.AtomicWrite(path, data); err != nil {
+		return fmt.Errorf("write: %w", err)
+	}
+	return nil
+}
+
+func newID() string {
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%08x", time.Now().UnixNano()&0xffffffff)
+	}
+	return hex.EncodeToString(b)
+}
+
+func statePath() string {
+	return filepath.Join(paths.DataDir(), paths.BatchFileName)
+}
@@ -0,0 +1,111 @@
+package batch
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestJoinOpensWindow(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "batch.json")
+	now := time.Now()
+
+	tk := join(path, "ready", "a", 5*time.Second, now)
+	if !tk.Opened {
+		t.Fatal("first join should open the window")
+	}
+	if !tk.Deadline.Equal(now.Add(5 * time.Second)) {
+		t.Errorf("Deadline = %v, want %v", tk.Deadline, now.Add(5*time.Second))
+	}
+	if tk.Count != 1 {
+		t.Errorf("Count = %d, want 1", tk.Count)
+	}
+}
+
+func TestJoinAddsToOpenWindow(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "batch.json")
+	now := time.Now()
+
+	first := join(path, "ready", "a", 5*time.Second, now)
+	second := join(path, "ready", "b", 5*time.Second, now.Add(time.Second))
+	if second.Opened {
+		t.Fatal("second join should not open a new window")
+	}
+	if second.ID != first.ID || !second.Deadline.Equal(first.Deadline) {
+		t.Error("second join should join the first window")
+	}
+	if second.Count != 2 {
+		t.Errorf("Count = %d, want 2", second.Count)
+	}
+}
+
+func TestJoinSeparateKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "batch.json")
+	now := time.Now()
+
+	join(path, "ready", "a", 5*time.Second, now)
+	if tk := join(path, "error", "a", 5*time.Second, now); !tk.Opened {
+		t.Error("different key should open its own window")
+	}
+}
+
+func TestJoinReplacesStaleWindow(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "batch.json")
+	now := time.Now()
+
+	first := join(path, "ready", "a", 5*time.Second, now)
+	later := now.Add(5*time.Second + staleAfter + time.Second)
+	second := join(path, "ready", "b", 5*time.Second, later)
+	if !second.Opened || second.ID == first.ID {
+		t.Fatal("stale window should be replaced")
+	}
+	if !slices.Equal(second.Stale, []string{"a"}) {
+		t.Errorf("Stale = %v, want the abandoned window's [a]", second.Stale)
+	}
+	if items := closeBatch(path, "ready", second, "b"); !slices.Equal(items, []string{"b"}) {
+		t.Errorf("items = %v, want [b]", items)
+	}
+}
+
+func TestCloseReturnsItemsInOrder(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "batch.json")
+	now := time.Now()
+
+	tk := join(path, "ready", "a", 5*time.Second, now)
+	join(path, "ready", "b", 5*time.Second, now)
+	join(path, "ready", "a", 5*time.Second, now)
+
+	items := closeBatch(path, "ready", tk, "a")
+	if want := []string{"a", "b", "a"}; !slices.Equal(items, want) {
+		t.Errorf("items = %v, want %v", items, want)
+	}
+	if next := join(path, "ready", "c", 5*time.Second, now); !next.Opened {
+		t.Error("join after Close should open a new window")
+	}
+}
+
+func TestCloseMismatchedID(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "batch.json")
+	now := time.Now()
+
+	join(path, "ready", "a", 5*time.Second, now)
+	items := closeBatch(path, "ready", Ticket{Opened: true, ID: "other"}, "z")
+	if !slices.Equal(items, []string{"z"}) {
+		t.Errorf("items = %v, want [z]", items)
+	}
+	// The other process's window is left alone.
+	if tk := join(path, "ready", "b", 5*time.Second, now); tk.Opened {
+		t.Error("window should still be open")
+	}
+}
+
+func TestJoinCorruptFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "batch.json")
+	os.WriteFile(path, []byte("{not json"), 0644)
+
+	if tk := join(path, "ready", "a", 5*time.Second, time.Now()); !tk.Opened {
+		t.Error("corrupt state should be treated as empty")
+	}
+}
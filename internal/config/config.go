@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
+	"regexp"
 	"sort"
 	"strconv"
 	"strings"
@@ -41,13 +43,38 @@ const (
 
 // Credentials holds secret values for remote notification actions.
 type Credentials struct {
-	DiscordWebhook string `json:"discord_webhook,omitempty"`
-	SlackWebhook   string `json:"slack_webhook,omitempty"`
-	TelegramToken  string `json:"telegram_token,omitempty"`
-	TelegramChatID string `json:"telegram_chat_id,omitempty"`
-	OpenAIAPIKey   string `json:"openai_api_key,omitempty"`
+	DiscordWebhook string `json:"discord_webhook,omitempty" secret:"true"`
+	SlackWebhook   string `json:"slack_webhook,omitempty" secret:"true"`
+	TelegramToken  string `json:"telegram_token,omitempty" secret:"true"`
+	TelegramChatID string `json:"telegram_chat_id,omitempty" secret:"true"`
+	OpenAIAPIKey   string `json:"openai_api_key,omitempty" secret:"true"`
 	MQTTUsername   string `json:"mqtt_username,omitempty"`
-	MQTTPassword   string `json:"mqtt_password,omitempty"`
+	MQTTPassword   string `json:"mqtt_password,omitempty" secret:"true"`
+	SMTPHost       string `json:"smtp_host,omitempty"` // host or host:port (default port 587; 465 = implicit TLS)
+	SMTPUsername   string `json:"smtp_username,omitempty"`
+	SMTPPassword   string `json:"smtp_password,omitempty" secret:"true"`
+	SMTPFrom       string `json:"smtp_from,omitempty"`
+	SMTPTo         string `json:"smtp_to,omitempty"`                      // default recipients, comma-separated
+	SMTPTLS        string `json:"smtp_tls,omitempty"`                     // "none": send without TLS (default: require STARTTLS off localhost)
+	NtfyURL        string `json:"ntfy_url,omitempty"`                     // ntfy server (default https://ntfy.sh)
+	NtfyToken      string `json:"ntfy_token,omitempty" secret:"true"`     // ntfy access token
+	PushoverToken  string `json:"pushover_token,omitempty" secret:"true"` // Pushover application API token
+	PushoverUser   string `json:"pushover_user,omitempty" secret:"true"`  // Pushover user or group key
+	GotifyURL      string `json:"gotify_url,omitempty"`                   // Gotify server base URL
+	GotifyToken    string `json:"gotify_token,omitempty" secret:"true"`   // Gotify application token
+	MatrixURL      string `json:"matrix_url,omitempty"`                   // Matrix homeserver base URL
+	MatrixToken    string `json:"matrix_token,omitempty" secret:"true"`   // Matrix access token
+	MatrixRoom     string `json:"matrix_room,omitempty"`                  // default room ID or alias
+	TeamsWebhook   string `json:"teams_webhook,omitempty" secret:"true"`  // Teams incoming webhook or Workflows URL
+	MattermostHook string `json:"mattermost_webhook,omitempty" secret:"true"`
+	GoogleChatHook string `json:"googlechat_webhook,omitempty" secret:"true"`
+	PagerDutyKey   string `json:"pagerduty_key,omitempty" secret:"true"` // Events API v2 integration (routing) key
+	PagerDutyURL   string `json:"pagerduty_url,omitempty"`               // Events API host (default https://events.pagerduty.com)
+	OpsgenieKey    string `json:"opsgenie_key,omitempty" secret:"true"`  // API integration key
+	OpsgenieURL    string `json:"opsgenie_url,omitempty"`                // API host (default https://api.opsgenie.com)
+	RedisPassword  string `json:"redis_password,omitempty" secret:"true"`
+	NATSPassword   string `json:"nats_password,omitempty" secret:"true"`
+	NATSToken      string `json:"nats_token,omitempty" secret:"true"`
 }
 
 // VoiceConfig holds settings for AI voice generation.
@@ -61,21 +88,24 @@ type VoiceConfig struct {
 
 // Options holds global settings parsed from the "config" key.
 type Options struct {
-	AFKThresholdSeconds int               `json:"afk_threshold_seconds,omitempty"`
-	DefaultVolume       int               `json:"default_volume,omitempty"`
-	Log                 bool              `json:"log,omitempty"`
-	Echo                bool              `json:"echo,omitempty"`
-	Cooldown            bool              `json:"cooldown,omitempty"`
-	CooldownSeconds     int               `json:"cooldown_seconds,omitempty"`
-	ExitCodes           map[string]string `json:"exit_codes,omitempty"`
-	OutputLines         int               `json:"output_lines,omitempty"`
-	HeartbeatSeconds    int               `json:"heartbeat_seconds,omitempty"`
-	ShellHookThreshold  int               `json:"shell_hook_threshold,omitempty"`
-	Storage             string            `json:"storage,omitempty"`        // "sqlite" (default) or "file"
-	RetentionDays       int               `json:"retention_days,omitempty"`  // 0 = keep forever, >0 = auto-prune
-	MaxDesktops         int               `json:"max_desktops,omitempty"`   // 0 = default (4)
-	Voice               VoiceConfig       `json:"openai_voice,omitempty"`
-	Credentials         Credentials       `json:"credentials,omitempty"`
+	AFKThresholdSeconds int                      `json:"afk_threshold_seconds,omitempty"`
+	DefaultVolume       int                      `json:"default_volume,omitempty"`
+	Log                 bool                     `json:"log,omitempty"`
+	Echo                bool                     `json:"echo,omitempty"`
+	Cooldown            bool                     `json:"cooldown,omitempty"`
+	CooldownSeconds     int                      `json:"cooldown_seconds,omitempty"`
+	Dedup               bool                     `json:"dedup,omitempty"` // count repeats within cooldown and send a follow-up
+	ExitCodes           map[string]string        `json:"exit_codes,omitempty"`
+	OutputLines         int                      `json:"output_lines,omitempty"`
+	HeartbeatSeconds    int                      `json:"heartbeat_seconds,omitempty"`
+	ShellHookThreshold  int                      `json:"shell_hook_threshold,omitempty"`
+	Storage             string                   `json:"storage,omitempty"`        // "sqlite" (default) or "file"
+	RetentionDays       int                      `json:"retention_days,omitempty"` // 0 = keep forever, >0 = auto-prune
+	MaxDesktops         int                      `json:"max_desktops,omitempty"`   // 0 = default (4)
+	HolidayFile         string                   `json:"holiday_file,omitempty"`   // .ics or date list for the "holiday" condition
+	Routing             map[string]SeverityRoute `json:"routing,omitempty"`        // step types per severity and AFK state
+	Voice               VoiceConfig              `json:"openai_voice,omitempty"`
+	Credentials         Credentials              `json:"credentials,omitempty"`
 }
 
 // Config holds the top-level configuration: global options and profiles.
@@ -119,6 +149,7 @@ type Profile struct {
 	Desktop     *int              `json:"-"` // 1-4, virtual desktop to switch to on toast click
 	Credentials *Credentials      `json:"-"`
 	Match       *MatchRule        `json:"-"`
+	Batch       string            `json:"-"` // batching window for all actions, e.g. "5s"
 	Actions     map[string]Action `json:"-"`
 }
 
@@ -142,6 +173,9 @@ func (p Profile) MarshalJSON() ([]byte, error) {
 	if p.Match != nil {
 		m["match"] = p.Match
 	}
+	if p.Batch != "" {
+		m["batch"] = p.Batch
+	}
 	for k, v := range p.Actions {
 		m[k] = v
 	}
@@ -193,6 +227,12 @@ func (p *Profile) UnmarshalJSON(data []byte) error {
 		p.Match = &rule
 		delete(raw, "match")
 	}
+	if b, ok := raw["batch"]; ok {
+		if err := json.Unmarshal(b, &p.Batch); err != nil {
+			return fmt.Errorf("batch: %w", err)
+		}
+		delete(raw, "batch")
+	}
 	p.Actions = make(map[string]Action, len(raw))
 	for k, v := range raw {
 		var a Action
@@ -204,34 +244,124 @@ func (p *Profile) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
-// Action holds an ordered list of steps to execute.
+// Action holds an ordered list of steps to execute. OnSuccess and
+// OnFailure name another action of the same profile to run afterwards,
+// depending on whether every step was delivered; Escalate re-fires it
+// until acknowledged. Severity selects the row of the global routing
+// table that decides which step types run.
 type Action struct {
-	CooldownSeconds int    `json:"cooldown_seconds,omitempty"`
-	Steps           []Step `json:"steps"`
+	CooldownSeconds int       `json:"cooldown_seconds,omitempty"`
+	Steps           []Step    `json:"steps"`
+	OnSuccess       string    `json:"on_success,omitempty"`
+	OnFailure       string    `json:"on_failure,omitempty"`
+	Batch           string    `json:"batch,omitempty"`    // overrides the profile's batch window; "0s" disables
+	Dedup           bool      `json:"dedup,omitempty"`    // count repeats within cooldown and send a follow-up
+	Severity        string    `json:"severity,omitempty"` // "info" (default), "warning", or "critical"
+	Escalate        *Escalate `json:"escalate,omitempty"`
+}
+
+// Escalate re-fires an action until it is acknowledged (`notify ack <id>`,
+// the dashboard, or notify://ack?id=...). The invocation that fired the
+// action stays in the foreground and fires Action (default: the same
+// action) every Every, at most Max times.
+type Escalate struct {
+	Every  string `json:"every"`            // interval between re-fires, e.g. "5m"
+	Action string `json:"action,omitempty"` // action to fire instead of repeating this one
+	Max    int    `json:"max"`              // stop after this many re-fires (required, at least 1)
+}
+
+// MinEscalateInterval is the shortest allowed escalation interval.
+const MinEscalateInterval = 30 * time.Second
+
+// Interval returns the parsed Every duration (0 if invalid).
+func (e *Escalate) Interval() time.Duration {
+	d, err := time.ParseDuration(e.Every)
+	if err != nil {
+		return 0
+	}
+	return d
 }
 
 // Step is a single unit of work within an action.
 type Step struct {
-	Type    string            `json:"type"`              // "sound" | "say" | "toast" | "discord" | "discord_voice" | "slack" | "telegram" | "telegram_audio" | "telegram_voice" | "webhook" | "plugin" | "mqtt"
-	Sound   string            `json:"sound,omitempty"`   // type=sound
-	Text    string            `json:"text,omitempty"`    // type=say, discord, discord_voice, slack, telegram, telegram_audio, telegram_voice, webhook, plugin, mqtt
-	Title   string            `json:"title,omitempty"`   // type=toast
-	Message string            `json:"message,omitempty"` // type=toast
-	URL     string            `json:"url,omitempty"`     // type=webhook
-	Headers map[string]string `json:"headers,omitempty"` // type=webhook
-	Command string            `json:"command,omitempty"` // type=plugin
-	Timeout *int              `json:"timeout,omitempty"` // type=plugin (seconds, default 10)
-	Broker  string            `json:"broker,omitempty"`  // type=mqtt
-	Topic   string            `json:"topic,omitempty"`   // type=mqtt
-	Retain  bool              `json:"retain,omitempty"`  // type=mqtt (default false)
-	QoS     *int              `json:"qos,omitempty"`     // type=mqtt (0, 1, or 2; default 0)
-	Volume  *int              `json:"volume,omitempty"`  // per-step override, nil = use default
-	When    string            `json:"when,omitempty"`    // "" | "never" | "afk" | "present" | "run" | "direct" | "hours:X-Y" | "long:DURATION"
-}
-
-// validStepTypes is the set of recognized step types.
-var validStepTypes = map[string]bool{
-	"sound": true, "say": true, "toast": true, "discord": true, "discord_voice": true, "slack": true, "telegram": true, "telegram_audio": true, "telegram_voice": true, "webhook": true, "plugin": true, "mqtt": true,
+	Type     string            `json:"type"`                // a registered step type (see StepTypes): "sound", "say", "slack", ...
+	Sound    string            `json:"sound,omitempty"`     // type=sound; type=pushover: a Pushover sound name
+	Text     string            `json:"text,omitempty"`      // type=say, discord, discord_voice, slack, telegram, telegram_audio, telegram_voice, webhook, plugin, mqtt, email, ntfy, pushover, gotify, matrix, teams, mattermost, googlechat, pagerduty, opsgenie, syslog, file, tmux, redis, nats
+	Title    string            `json:"title,omitempty"`     // type=toast, terminal, ntfy, pushover, gotify, teams, googlechat (default: profile name)
+	Message  string            `json:"message,omitempty"`   // type=toast, terminal
+	URL      string            `json:"url,omitempty"`       // type=webhook
+	Headers  map[string]string `json:"headers,omitempty"`   // type=webhook
+	Command  string            `json:"command,omitempty"`   // type=plugin
+	Timeout  *int              `json:"timeout,omitempty"`   // type=plugin (seconds, default 10)
+	Broker   string            `json:"broker,omitempty"`    // type=mqtt; redis (redis://, rediss://); nats (nats://, tls://)
+	Topic    string            `json:"topic,omitempty"`     // type=mqtt, ntfy; redis: channel or stream key; nats: subject
+	Retain   bool              `json:"retain,omitempty"`    // type=mqtt (default false)
+	QoS      *int              `json:"qos,omitempty"`       // type=mqtt (0, 1, or 2; default 0)
+	To       string            `json:"to,omitempty"`        // type=email (comma-separated, default credentials.smtp_to)
+	Subject  string            `json:"subject,omitempty"`   // type=email (default: profile name)
+	Attach   string            `json:"attach,omitempty"`    // type=email: "output" attaches {output} as output.txt; type=teams: "output" adds it as a code block
+	Priority string            `json:"priority,omitempty"`  // type=ntfy (min, low, default, high, urgent or 1-5), pushover (lowest, low, normal, high, emergency or -2 to 2), gotify (0-10), pagerduty (critical, error, warning, info), opsgenie (P1-P5), syslog (emerg ... debug or 0-7); default from severity
+	Tags     []string          `json:"tags,omitempty"`      // type=ntfy (emoji shortcodes or labels)
+	Click    string            `json:"click,omitempty"`     // type=ntfy, pushover, gotify: URL opened from the notification
+	Actions  []NtfyAction      `json:"actions,omitempty"`   // type=ntfy: up to 3 action buttons
+	Device   string            `json:"device,omitempty"`    // type=pushover: device name(s), comma-separated (default: all)
+	Retry    int               `json:"retry,omitempty"`     // type=pushover emergency: seconds between repeats (min 30, default 60)
+	Expire   int               `json:"expire,omitempty"`    // type=pushover emergency: seconds to keep repeating (max 10800, default 3600)
+	Markdown bool              `json:"markdown,omitempty"`  // type=gotify: render the text as markdown
+	Room     string            `json:"room,omitempty"`      // type=matrix: room ID or alias (default credentials.matrix_room)
+	HTML     string            `json:"html,omitempty"`      // type=matrix: HTML formatted body (text is the plain fallback)
+	Username string            `json:"username,omitempty"`  // type=mattermost: sender name override
+	Icon     string            `json:"icon,omitempty"`      // type=mattermost: sender icon override (URL or :emoji:)
+	Channel  string            `json:"channel,omitempty"`   // type=mattermost: channel override (name or @user)
+	Thread   string            `json:"thread,omitempty"`    // type=googlechat: thread key (default "notify-{profile}")
+	Event    string            `json:"event,omitempty"`     // type=pagerduty, opsgenie: "trigger" or "resolve" (default: resolve on success, else trigger)
+	Incident string            `json:"incident,omitempty"`  // type=pagerduty, opsgenie: incident (dedup) key (default "notify/{profile}/{command}")
+	Address  string            `json:"address,omitempty"`   // type=syslog: udp://host:port, tcp://host:port, unix:///path, or "journal" (default: local journald or syslog)
+	Facility string            `json:"facility,omitempty"`  // type=syslog: user (default), daemon, local0-local7, ...
+	Path     string            `json:"path,omitempty"`      // type=file: file or named pipe to append JSON lines to (relative to the config file)
+	MaxSize  string            `json:"max_size,omitempty"`  // type=file: rotate the file past this size ("10MB", "512KB"; default: never)
+	Keep     int               `json:"keep,omitempty"`      // type=file: rotated files to keep (default 3)
+	Protocol string            `json:"protocol,omitempty"`  // type=terminal: osc9, osc777, or osc99 (default: detected from TERM)
+	Bell     bool              `json:"bell,omitempty"`      // type=terminal: also ring the terminal bell
+	Mode     string            `json:"mode,omitempty"`      // type=tmux: flag (default), display, or rename
+	Stream   bool              `json:"stream,omitempty"`    // type=redis: XADD to the stream "topic" instead of PUBLISH
+	MaxLen   int               `json:"max_len,omitempty"`   // type=redis stream: approximate max length (default: no trimming)
+	JS       bool              `json:"jetstream,omitempty"` // type=nats: wait for a JetStream stream to store the message
+	Volume   *int              `json:"volume,omitempty"`    // per-step override, nil = use default
+	When     string            `json:"when,omitempty"`      // "" | "never" | "afk" | "present" | "run" | "direct" | "hours:X-Y" | "long:DURATION" | "exit:SPEC" | "output:/RE/" | "days:D-D" | "date:A..B" | "holiday" | "repeat", combined with and/or/not
+	Cond     *WhenExpr         `json:"-"`                   // When, parsed once by Load (nil: parsed on use)
+	Routed   bool              `json:"-"`                   // added by the severity routing table (Options.RoutedSteps)
+	Fallback []Step            `json:"fallback,omitempty"`  // steps run in order when this step fails (may nest)
+}
+
+// Condition returns the step's parsed when condition: Cond if Load set
+// it, otherwise When parsed now. A step without a condition returns nil.
+func (s Step) Condition() (*WhenExpr, error) {
+	if s.Cond != nil || s.When == "" {
+		return s.Cond, nil
+	}
+	return ParseWhen(s.When)
+}
+
+// NtfyAction is an action button on an ntfy notification.
+type NtfyAction struct {
+	Action string `json:"action"`           // "view", "http", or "broadcast"
+	Label  string `json:"label"`            // button text
+	URL    string `json:"url,omitempty"`    // view, http
+	Method string `json:"method,omitempty"` // http (default POST)
+	Body   string `json:"body,omitempty"`   // http
+	Clear  bool   `json:"clear,omitempty"`  // dismiss the notification when tapped
+}
+
+// Flatten returns steps followed depth-first by their fallback steps, for
+// callers that need to inspect every step that could possibly run.
+func Flatten(steps []Step) []Step {
+	var out []Step
+	for _, s := range steps {
+		out = append(out, s)
+		out = append(out, Flatten(s.Fallback)...)
+	}
+	return out
 }
 
 // builtinSounds is the set of built-in sound names. Kept in sync with
@@ -299,18 +429,27 @@ func Validate(cfg Config) error {
 	if vc.MinUses < 0 {
 		errs = append(errs, fmt.Sprintf("config: openai_voice.min_uses %d must not be negative", vc.MinUses))
 	}
+	errs = append(errs, validateRouting(cfg.Options)...)
+	errs = append(errs, validateCredentials("config", cfg.Options.Credentials)...)
 
 	// Per-profile checks.
 	for pName, profile := range cfg.Profiles {
+		if profile.Credentials != nil {
+			errs = append(errs, validateCredentials("profiles."+pName, *profile.Credentials)...)
+		}
 		maxD := cfg.Options.MaxDesktopsLimit()
 		if profile.Desktop != nil && (*profile.Desktop < 1 || *profile.Desktop > maxD) {
 			errs = append(errs, fmt.Sprintf("profiles.%s: desktop must be 1-%d, got %d", pName, maxD, *profile.Desktop))
 		}
+		if err := validateBatch(profile.Batch); err != nil {
+			errs = append(errs, fmt.Sprintf("profiles.%s: %v", pName, err))
+		}
 	}
 
 	errs = append(errs, validateAliases(cfg.Profiles)...)
 	errs = append(errs, validateMatchRules(cfg.Profiles)...)
 	errs = append(errs, validateSteps(cfg)...)
+	errs = append(errs, validateChains(cfg.Profiles)...)
 
 	if len(errs) == 0 {
 		return nil
@@ -318,6 +457,16 @@ func Validate(cfg Config) error {
 	return fmt.Errorf("config validation:\n  %s", strings.Join(errs, "\n  "))
 }
 
+// validateCredentials checks credential values that select a behavior
+// rather than hold a secret or address.
+func validateCredentials(prefix string, c Credentials) []string {
+	var errs []string
+	if c.SMTPTLS != "" && c.SMTPTLS != "none" {
+		errs = append(errs, fmt.Sprintf(`%s: credentials.smtp_tls %q is not valid (use "none" or omit)`, prefix, c.SMTPTLS))
+	}
+	return errs
+}
+
 // validateAliases checks for alias conflicts (shadowing profile names,
 // duplicate aliases across profiles).
 func validateAliases(profiles map[string]Profile) []string {
@@ -358,6 +507,119 @@ func validateMatchRules(profiles map[string]Profile) []string {
 	return errs
 }
 
+// validateChains checks on_success/on_failure references. The named action
+// must exist in the same profile, or in "default" where Resolve falls back
+// to, and following the references from any action must never lead back to
+// an action already in the chain.
+func validateChains(profiles map[string]Profile) []string {
+	var errs []string
+	pNames := make([]string, 0, len(profiles))
+	for name := range profiles {
+		pNames = append(pNames, name)
+	}
+	sort.Strings(pNames)
+
+	for _, pName := range pNames {
+		profile := profiles[pName]
+		lookup := func(name string) (Action, bool) {
+			if a, ok := profile.Actions[name]; ok {
+				return a, true
+			}
+			if pName != "default" {
+				a, ok := profiles["default"].Actions[name]
+				return a, ok
+			}
+			return Action{}, false
+		}
+
+		aNames := make([]string, 0, len(profile.Actions))
+		for name := range profile.Actions {
+			aNames = append(aNames, name)
+		}
+		sort.Strings(aNames)
+
+		reported := map[string]bool{}
+		for _, aName := range aNames {
+			a := profile.Actions[aName]
+			refs := [][2]string{{"on_success", a.OnSuccess}, {"on_failure", a.OnFailure}}
+			if a.Escalate != nil {
+				refs = append(refs, [2]string{"escalate.action", a.Escalate.Action})
+			}
+			for _, ref := range refs {
+				if ref[1] == "" {
+					continue
+				}
+				if _, ok := lookup(ref[1]); !ok {
+					errs = append(errs, fmt.Sprintf("profiles.%s.%s: %s action %q not found", pName, aName, ref[0], ref[1]))
+				}
+			}
+
+			loop := findChainLoop(aName, lookup)
+			if loop == nil {
+				continue
+			}
+			// Report each loop once, and only in a profile that defines at
+			// least one of its actions (loops made purely of inherited
+			// "default" actions are reported under "default").
+			members := append([]string(nil), loop[:len(loop)-1]...)
+			sort.Strings(members)
+			key := strings.Join(members, ",")
+			own := false
+			for _, m := range members {
+				if _, ok := profile.Actions[m]; ok {
+					own = true
+				}
+			}
+			if own && !reported[key] {
+				reported[key] = true
+				errs = append(errs, fmt.Sprintf("profiles.%s: on_success/on_failure chain loops: %s", pName, strings.Join(loop, " -> ")))
+			}
+		}
+	}
+	return errs
+}
+
+// findChainLoop follows on_success/on_failure references depth-first from
+// start and returns the first loop found as a path that begins and ends
+// with the same action, or nil if every chain terminates.
+func findChainLoop(start string, lookup func(string) (Action, bool)) []string {
+	var path []string
+	onPath := map[string]bool{}
+	done := map[string]bool{}
+	var visit func(name string) []string
+	visit = func(name string) []string {
+		if onPath[name] {
+			for i, n := range path {
+				if n == name {
+					return append(append([]string(nil), path[i:]...), name)
+				}
+			}
+		}
+		if done[name] {
+			return nil
+		}
+		a, ok := lookup(name)
+		if !ok {
+			return nil
+		}
+		onPath[name] = true
+		path = append(path, name)
+		for _, next := range []string{a.OnSuccess, a.OnFailure} {
+			if next == "" {
+				continue
+			}
+			if loop := visit(next); loop != nil {
+				return loop
+			}
+		}
+		path = path[:len(path)-1]
+		onPath[name] = false
+		done[name] = true
+		return nil
+	}
+	return visit(start)
+}
+
 // validateSteps checks per-action and per-step constraints: required fields,
 // credential availability, value ranges.
 func validateSteps(cfg Config) []string {
@@ -372,96 +634,106 @@ func validateSteps(cfg Config) []string {
 			if action.CooldownSeconds < 0 {
 				errs = append(errs, fmt.Sprintf("%s: cooldown_seconds %d must not be negative", prefix, action.CooldownSeconds))
 			}
-			for i, s := range action.Steps {
-				sp := fmt.Sprintf("%s.steps[%d]", prefix, i)
-				if !validStepTypes[s.Type] {
-					errs = append(errs, fmt.Sprintf("%s: unknown type %q", sp, s.Type))
+			if err := validateBatch(action.Batch); err != nil {
+				errs = append(errs, fmt.Sprintf("%s: %v", prefix, err))
+			}
+			if action.Severity != "" && !ValidSeverity(action.Severity) {
+				errs = append(errs, fmt.Sprintf("%s: severity %q is not valid (use info, warning, or critical)", prefix, action.Severity))
+			}
+			if e := action.Escalate; e != nil {
+				if e.Interval() < MinEscalateInterval {
+					errs = append(errs, fmt.Sprintf("%s: escalate.every %q must be a duration of at least %s", prefix, e.Every, MinEscalateInterval))
 				}
-				if err := validateWhen(s.When); err != nil {
-					errs = append(errs, fmt.Sprintf("%s: %v", sp, err))
+				if e.Max < 1 {
+					errs = append(errs, fmt.Sprintf("%s: escalate.max %d must be at least 1", prefix, e.Max))
 				}
-				if s.Volume != nil && (*s.Volume < 0 || *s.Volume > 100) {
-					errs = append(errs, fmt.Sprintf("%s: volume %d out of range 0-100", sp, *s.Volume))
+			}
+			for i, s := range action.Steps {
+				sp := fmt.Sprintf("%s.steps[%d]", prefix, i)
+				errs = append(errs, validateStep(sp, s, creds, false)...)
+				if cfg.Options.HolidayFile == "" && whenUsesAtom(s.When, "holiday") {
+					errs = append(errs, fmt.Sprintf("%s: holiday condition requires config.holiday_file", sp))
 				}
-				errs = append(errs, validateStepFields(sp, s, creds)...)
 			}
 		}
 	}
 	return errs
 }
 
-// validateStepFields checks required fields for a specific step type.
-func validateStepFields(sp string, s Step, creds Credentials) []string {
+// validateStep checks a single step and, recursively, its fallback chain.
+// Fallback steps run whenever their parent fails, so a "when" condition
+// on them is rejected rather than silently ignored.
+func validateStep(sp string, s Step, creds Credentials, isFallback bool) []string {
 	var errs []string
-	switch s.Type {
-	case "sound":
-		if s.Sound == "" {
-			errs = append(errs, fmt.Sprintf("%s: sound step requires \"sound\" field", sp))
-		}
-	case "say":
-		if s.Text == "" {
-			errs = append(errs, fmt.Sprintf("%s: say step requires \"text\" field", sp))
-		}
-	case "toast":
-		if s.Message == "" {
-			errs = append(errs, fmt.Sprintf("%s: toast step requires \"message\" field", sp))
-		}
-	case "discord", "discord_voice":
-		if s.Text == "" {
-			errs = append(errs, fmt.Sprintf("%s: %s step requires \"text\" field", sp, s.Type))
-		}
-		if creds.DiscordWebhook == "" {
-			errs = append(errs, fmt.Sprintf("%s: %s step requires credentials.discord_webhook", sp, s.Type))
-		}
-	case "slack":
-		if s.Text == "" {
-			errs = append(errs, fmt.Sprintf("%s: slack step requires \"text\" field", sp))
-		}
-		if creds.SlackWebhook == "" {
-			errs = append(errs, fmt.Sprintf("%s: slack step requires credentials.slack_webhook", sp))
-		}
-	case "telegram", "telegram_audio", "telegram_voice":
-		if s.Text == "" {
-			errs = append(errs, fmt.Sprintf("%s: %s step requires \"text\" field", sp, s.Type))
-		}
-		if creds.TelegramToken == "" || creds.TelegramChatID == "" {
-			errs = append(errs, fmt.Sprintf("%s: %s step requires credentials.telegram_token and telegram_chat_id", sp, s.Type))
-		}
-	case "webhook":
-		if s.URL == "" {
-			errs = append(errs, fmt.Sprintf("%s: webhook step requires \"url\" field", sp))
-		}
-		if s.Text == "" {
-			errs = append(errs, fmt.Sprintf("%s: webhook step requires \"text\" field", sp))
-		}
-	case "plugin":
-		if s.Command == "" {
-			errs = append(errs, fmt.Sprintf("%s: plugin step requires \"command\" field", sp))
-		}
-		if s.Timeout != nil && *s.Timeout < 0 {
-			errs = append(errs, fmt.Sprintf("%s: plugin timeout must not be negative", sp))
-		}
-	case "mqtt":
-		if s.Broker == "" {
-			errs = append(errs, fmt.Sprintf("%s: mqtt step requires \"broker\" field", sp))
-		}
-		if s.Topic == "" {
-			errs = append(errs, fmt.Sprintf("%s: mqtt step requires \"topic\" field", sp))
-		}
-		if s.Text == "" {
-			errs = append(errs, fmt.Sprintf("%s: mqtt step requires \"text\" field", sp))
-		}
-		if s.QoS != nil && (*s.QoS < 0 || *s.QoS > 2) {
-			errs = append(errs, fmt.Sprintf("%s: mqtt qos must be 0, 1, or 2", sp))
+	h, ok := LookupStep(s.Type)
+	if !ok {
+		errs = append(errs, fmt.Sprintf("%s: unknown type %q", sp, s.Type))
+	}
+	if isFallback && s.When != "" {
+		errs = append(errs, fmt.Sprintf("%s: fallback steps must not have a \"when\" condition", sp))
+	} else if err := validateWhen(s.When); err != nil {
+		errs = append(errs, fmt.Sprintf("%s: %v", sp, err))
+	}
+	if s.Volume != nil && (*s.Volume < 0 || *s.Volume > 100) {
+		errs = append(errs, fmt.Sprintf("%s: volume %d out of range 0-100", sp, *s.Volume))
+	}
+	if ok {
+		for _, e := range h.Validate(s, creds) {
+			errs = append(errs, fmt.Sprintf("%s: %s", sp, e))
 		}
 	}
+	for j, fb := range s.Fallback {
+		errs = append(errs, validateStep(fmt.Sprintf("%s.fallback[%d]", sp, j), fb, creds, true)...)
+	}
 	return errs
 }
 
-// validateWhen checks that a when condition string is recognized.
+// MaxBatchWindow is the longest allowed batching window. The process that
+// opens a window waits for it, so it must stay short.
+const MaxBatchWindow = 10 * time.Minute
+
+// validateBatch checks a batch window duration string ("" means unset).
+func validateBatch(batch string) error {
+	if batch == "" {
+		return nil
+	}
+	d, err := time.ParseDuration(batch)
+	if err != nil || d < 0 || d > MaxBatchWindow {
+		return fmt.Errorf("batch %q must be a duration between 0s and %s", batch, MaxBatchWindow)
+	}
+	return nil
+}
+
+// BatchWindow returns the batching window for an action of profile p: the
+// action's "batch" if set, otherwise the profile's. Zero means batching
+// is off.
+func BatchWindow(p Profile, a Action) time.Duration {
+	batch := p.Batch
+	if a.Batch != "" {
+		batch = a.Batch
+	}
+	d, err := time.ParseDuration(batch)
+	if err != nil || d < 0 {
+		return 0
+	}
+	return d
+}
+
+// validateWhen checks that a when condition parses (see ParseWhen) and
+// that every atom in it is recognized.
 func validateWhen(when string) error {
+	if when == "" {
+		return nil
+	}
+	_, err := ParseWhen(when)
+	return err
+}
+
+// validateWhenAtom checks a single condition atom such as "afk" or
+// "hours:8-22".
+func validateWhenAtom(when string) error {
 	switch when {
-	case "", "afk", "present", "run", "direct", "never":
+	case "afk", "present", "run", "direct", "never", "holiday", "repeat":
 		return nil
 	default:
 		if strings.HasPrefix(when, "hours:") {
@@ -470,6 +742,22 @@ func validateWhen(when string) error {
 		if strings.HasPrefix(when, "long:") {
 			return validateLongSpec(when[5:])
 		}
+		if strings.HasPrefix(when, "exit:") {
+			_, _, err := ParseExitSpec(strings.TrimPrefix(when[5:], "!"))
+			return err
+		}
+		if strings.HasPrefix(when, "output:") {
+			_, err := ParseOutputSpec(when[7:])
+			return err
+		}
+		if strings.HasPrefix(when, "days:") {
+			_, err := ParseDaysSpec(when[5:])
+			return err
+		}
+		if strings.HasPrefix(when, "date:") {
+			_, _, err := ParseDateSpec(when[5:])
+			return err
+		}
 		return fmt.Errorf("unknown when condition %q", when)
 	}
 }
@@ -500,6 +788,92 @@ func validateLongSpec(spec string) error {
 	return nil
 }
 
+// ParseExitSpec parses an exit code condition ("2" or "2-5", without the
+// optional "!" prefix) into an inclusive range.
+func ParseExitSpec(spec string) (lo, hi int, err error) {
+	if a, b, ok := strings.Cut(spec, "-"); ok && a != "" {
+		lo, err1 := strconv.Atoi(a)
+		hi, err2 := strconv.Atoi(b)
+		if err1 != nil || err2 != nil || lo < 0 || hi < lo {
+			return 0, 0, fmt.Errorf("invalid exit spec %q (expected N, A-B with A <= B, or !N)", spec)
+		}
+		return lo, hi, nil
+	}
+	n, err := strconv.Atoi(spec)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid exit spec %q (expected N, A-B with A <= B, or !N)", spec)
+	}
+	return n, n, nil
+}
+
+// ParseOutputSpec compiles an output condition of the form "/regex/" or
+// "/regex/i" (case-insensitive).
+func ParseOutputSpec(spec string) (*regexp.Regexp, error) {
+	pattern, flags := "", ""
+	if strings.HasPrefix(spec, "/") {
+		if end := strings.LastIndex(spec, "/"); end > 0 {
+			pattern, flags = spec[1:end], spec[end+1:]
+		}
+	}
+	if pattern == "" || (flags != "" && flags != "i") {
+		return nil, fmt.Errorf("invalid output spec %q (expected /regex/ or /regex/i)", spec)
+	}
+	if flags == "i" {
+		pattern = "(?i)" + pattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid output spec %q: %v", spec, err)
+	}
+	return re, nil
+}
+
+// weekdays maps the three-letter day names used in "days:" conditions.
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// ParseDaysSpec parses a weekday condition such as "mon-fri", "sat,sun",
+// or "mon-wed,fri". Ranges wrap around the week ("fri-mon").
+func ParseDaysSpec(spec string) (map[time.Weekday]bool, error) {
+	days := map[time.Weekday]bool{}
+	for _, part := range strings.Split(spec, ",") {
+		from, to, isRange := strings.Cut(part, "-")
+		start, ok1 := weekdays[from]
+		end, ok2 := weekdays[to]
+		if !isRange {
+			end, ok2 = start, ok1
+		}
+		if !ok1 || !ok2 {
+			return nil, fmt.Errorf("invalid days spec %q (expected e.g. mon-fri or sat,sun)", spec)
+		}
+		for d := start; ; d = (d + 1) % 7 {
+			days[d] = true
+			if d == end {
+				break
+			}
+		}
+	}
+	return days, nil
+}
+
+// ParseDateSpec parses a date condition: a single date "2026-12-24" or an
+// inclusive range "2026-12-24..2027-01-02". The dates are returned in
+// YYYY-MM-DD form, which compares correctly as strings.
+func ParseDateSpec(spec string) (from, to string, err error) {
+	from, to, isRange := strings.Cut(spec, "..")
+	if !isRange {
+		to = from
+	}
+	start, err1 := time.Parse("2006-01-02", from)
+	end, err2 := time.Parse("2006-01-02", to)
+	if err1 != nil || err2 != nil || end.Before(start) {
+		return "", "", fmt.Errorf("invalid date spec %q (expected YYYY-MM-DD or YYYY-MM-DD..YYYY-MM-DD)", spec)
+	}
+	return from, to, nil
+}
+
 // DefaultConfig returns a built-in config with a "default" profile containing
 // four basic actions (ready, error, done, attention) using only local audio
 // steps. Used as a fallback when no config file exists so that basic commands
@@ -640,7 +1014,8 @@ func MatchProfile(cfg Config, dir string) string {
 
 // readConfig reads a JSON config file from disk, parses it into a Config,
 // resolves profile inheritance, expands environment variables in credentials,
-// and makes relative sound paths absolute against the config file's directory.
+// makes relative sound and holiday file paths absolute against the config
+// file's directory, and parses step when conditions.
 func readConfig(path string) (Config, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
@@ -654,7 +1029,11 @@ func readConfig(path string) (Config, error) {
 		return Config{}, fmt.Errorf("config %s: %w", path, err)
 	}
 	expandEnvCredentials(&cfg)
-	resolveSoundPaths(&cfg, filepath.Dir(path))
+	resolvePaths(&cfg, filepath.Dir(path))
+	parseConditions(&cfg)
+	if hf := cfg.Options.HolidayFile; hf != "" && !filepath.IsAbs(hf) {
+		cfg.Options.HolidayFile = filepath.Join(filepath.Dir(path), hf)
+	}
 	return cfg, nil
 }
 
@@ -700,6 +1079,9 @@ func resolveInheritance(cfg *Config) error {
 		if profile.Desktop == nil {
 			profile.Desktop = parentProfile.Desktop
 		}
+		if profile.Batch == "" {
+			profile.Batch = parentProfile.Batch
+		}
 		if profile.Credentials == nil {
 			profile.Credentials = parentProfile.Credentials
 		} else if parentProfile.Credentials != nil {
@@ -733,26 +1115,13 @@ func resolveInheritance(cfg *Config) error {
 	return nil
 }
 
-// resolveSoundPaths resolves relative sound file paths against the config
-// file's directory. Built-in sound names are left unchanged.
-func resolveSoundPaths(cfg *Config, configDir string) {
+// resolvePaths resolves relative sound file paths and file step paths
+// against the config file's directory. Built-in sound names are left
+// unchanged.
+func resolvePaths(cfg *Config, configDir string) {
 	for pName, profile := range cfg.Profiles {
 		for actionName, action := range profile.Actions {
-			changed := false
-			for i := range action.Steps {
-				s := &action.Steps[i]
-				if s.Type != "sound" || s.Sound == "" {
-					continue
-				}
-				if _, ok := builtinSounds[s.Sound]; ok {
-					continue
-				}
-				if !filepath.IsAbs(s.Sound) {
-					s.Sound = filepath.Join(configDir, s.Sound)
-					changed = true
-				}
-			}
-			if changed {
+			if resolveStepPaths(action.Steps, configDir) {
 				profile.Actions[actionName] = action
 			}
 		}
@@ -760,19 +1129,70 @@ func resolveSoundPaths(cfg *Config, configDir string) {
 	}
 }
 
-// fields returns pointers to all credential string fields, in a stable order.
-// Used by MergeCredentials and expandEnvCredentials so that adding a new
-// credential only requires updating the struct and this method.
+// resolveStepPaths resolves paths in steps and their fallback chains in
+// place. Returns true if any path was changed.
+func resolveStepPaths(steps []Step, configDir string) bool {
+	changed := false
+	for i := range steps {
+		s := &steps[i]
+		if resolveStepPaths(s.Fallback, configDir) {
+			changed = true
+		}
+		if s.Type == "file" && s.Path != "" && !filepath.IsAbs(s.Path) {
+			s.Path = filepath.Join(configDir, s.Path)
+			changed = true
+		}
+		if s.Type != "sound" || s.Sound == "" {
+			continue
+		}
+		if _, ok := builtinSounds[s.Sound]; ok {
+			continue
+		}
+		if !filepath.IsAbs(s.Sound) {
+			s.Sound = filepath.Join(configDir, s.Sound)
+			changed = true
+		}
+	}
+	return changed
+}
+
+// parseConditions parses every step's when condition once at load, so
+// the runner doesn't re-parse it (and recompile output regexes) on every
+// evaluation. Fallback steps can't have a condition. Conditions that
+// don't parse are left nil for Validate to report.
+func parseConditions(cfg *Config) {
+	parse := func(steps []Step) {
+		for i := range steps {
+			s := &steps[i]
+			if s.When == "" {
+				continue
+			}
+			if e, err := ParseWhen(s.When); err == nil {
+				s.Cond = e
+			}
+		}
+	}
+	for _, profile := range cfg.Profiles {
+		for _, action := range profile.Actions {
+			parse(action.Steps)
+		}
+	}
+	for _, r := range cfg.Options.Routing {
+		parse(r.Steps)
+	}
+}
+
+// fields returns pointers to all credential string fields, in struct
+// order (the order of credentialKeys). Used by MergeCredentials and
+// expandEnvCredentials so that adding a new credential only requires
+// updating the struct.
 func (c *Credentials) fields() []*string {
-	return []*string{
-		&c.DiscordWebhook,
-		&c.SlackWebhook,
-		&c.TelegramToken,
-		&c.TelegramChatID,
-		&c.OpenAIAPIKey,
-		&c.MQTTUsername,
-		&c.MQTTPassword,
+	v := reflect.ValueOf(c).Elem()
+	ptrs := make([]*string, v.NumField())
+	for i := range ptrs {
+		ptrs[i] = v.Field(i).Addr().Interface().(*string)
 	}
+	return ptrs
 }
 
 // MergeCredentials returns global credentials with any non-empty profile
@@ -2,8 +2,10 @@ package config
 
 import (
 	"encoding/json"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 )
 
 // p is a shorthand for constructing Profile with Actions in tests.
@@ -367,7 +369,7 @@ func TestValidateUnknownStepType(t *testing.T) {
 	cfg := Config{
 		Profiles: map[string]Profile{
 			"default": p(map[string]Action{
-				"ready": {Steps: []Step{{Type: "email", Text: "hi"}}},
+				"ready": {Steps: []Step{{Type: "fax", Text: "hi"}}},
 			}),
 		},
 	}
@@ -375,11 +377,209 @@ func TestValidateUnknownStepType(t *testing.T) {
 	if err == nil {
 		t.Fatal("expected error for unknown step type")
 	}
-	if !strings.Contains(err.Error(), `unknown type "email"`) {
+	if !strings.Contains(err.Error(), `unknown type "fax"`) {
 		t.Errorf("unexpected error: %v", err)
 	}
 }
 
+func TestValidateFallbackChain(t *testing.T) {
+	cfg := Config{
+		Profiles: map[string]Profile{
+			"default": p(map[string]Action{
+				"ready": {Steps: []Step{{
+					Type: "slack", Text: "hi",
+					Fallback: []Step{{
+						Type: "discord", Text: "hi",
+						Fallback: []Step{{Type: "toast"}},
+					}},
+				}}},
+			}),
+		},
+	}
+	err := Validate(cfg)
+	if err == nil {
+		t.Fatal("expected errors in fallback chain")
+	}
+	for _, want := range []string{
+		`profiles.default.ready.steps[0]: slack step requires credentials.slack_webhook`,
+		`profiles.default.ready.steps[0].fallback[0]: discord step requires credentials.discord_webhook`,
+		`profiles.default.ready.steps[0].fallback[0].fallback[0]: toast step requires "message" field`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error missing %q:\n%v", want, err)
+		}
+	}
+}
+
+func TestValidateFallbackWhenRejected(t *testing.T) {
+	cfg := Config{
+		Profiles: map[string]Profile{
+			"default": p(map[string]Action{
+				"ready": {Steps: []Step{{
+					Type: "say", Text: "hi",
+					Fallback: []Step{{Type: "toast", Message: "hi", When: "afk"}},
+				}}},
+			}),
+		},
+	}
+	err := Validate(cfg)
+	if err == nil || !strings.Contains(err.Error(), `fallback steps must not have a "when" condition`) {
+		t.Errorf("err = %v, want when rejected on fallback step", err)
+	}
+}
+
+func TestFlatten(t *testing.T) {
+	steps := []Step{
+		{Type: "slack", Fallback: []Step{{Type: "discord", Fallback: []Step{{Type: "toast"}}}}},
+		{Type: "sound"},
+	}
+	var types []string
+	for _, s := range Flatten(steps) {
+		types = append(types, s.Type)
+	}
+	if got := strings.Join(types, ","); got != "slack,discord,toast,sound" {
+		t.Errorf("Flatten = %s, want slack,discord,toast,sound", got)
+	}
+}
+
+func TestResolvePathsInFallback(t *testing.T) {
+	cfg := Config{
+		Profiles: map[string]Profile{
+			"default": p(map[string]Action{
+				"ready": {Steps: []Step{{
+					Type: "say", Text: "hi",
+					Fallback: []Step{{Type: "sound", Sound: "chime.wav"}, {Type: "file", Path: "notify.jsonl"}},
+				}}},
+			}),
+		},
+	}
+	resolvePaths(&cfg, "/cfg")
+	fallback := cfg.Profiles["default"].Actions["ready"].Steps[0].Fallback
+	if want := filepath.Join("/cfg", "chime.wav"); fallback[0].Sound != want {
+		t.Errorf("fallback sound = %q, want %q", fallback[0].Sound, want)
+	}
+	if want := filepath.Join("/cfg", "notify.jsonl"); fallback[1].Path != want {
+		t.Errorf("fallback file path = %q, want %q", fallback[1].Path, want)
+	}
+}
+
+func TestParseConditions(t *testing.T) {
+	cfg := Config{
+		Profiles: map[string]Profile{
+			"default": p(map[string]Action{
+				"ready": {Steps: []Step{
+					{Type: "sound", Sound: "blip"},
+					{Type: "sound", Sound: "blip", When: "run and output:/FATAL/i"},
+					{Type: "sound", Sound: "blip", When: "afk and"},
+				}},
+			}),
+		},
+	}
+	parseConditions(&cfg)
+	steps := cfg.Profiles["default"].Actions["ready"].Steps
+	if steps[0].Cond != nil || steps[2].Cond != nil {
+		t.Errorf("Cond set for an empty or invalid condition: %+v, %+v", steps[0].Cond, steps[2].Cond)
+	}
+	e := steps[1].Cond
+	if e == nil || e.String() != "run and output:/FATAL/i" {
+		t.Fatalf("Cond = %v, want the parsed condition", e)
+	}
+	if re := e.Args[1].Output; re == nil || !re.MatchString("fatal: disk full") {
+		t.Errorf("output atom regexp = %v, want compiled case-insensitive pattern", re)
+	}
+	if got, err := steps[1].Condition(); got != e || err != nil {
+		t.Errorf("Condition() = %v, %v; want the stored expression", got, err)
+	}
+}
+
+func TestValidateChainedActions(t *testing.T) {
+	cfg := Config{
+		Profiles: map[string]Profile{
+			"default": p(map[string]Action{
+				"escalate": {Steps: []Step{{Type: "sound", Sound: "alert"}}},
+			}),
+			"boss": p(map[string]Action{
+				"ready": {Steps: []Step{{Type: "sound", Sound: "blip"}}, OnFailure: "retry"},
+				"retry": {Steps: []Step{{Type: "sound", Sound: "blip"}}, OnFailure: "escalate"},
+			}),
+		},
+	}
+	if err := Validate(cfg); err != nil {
+		t.Errorf("expected valid chain (escalate resolved via default), got: %v", err)
+	}
+}
+
+func TestValidateChainedActionNotFound(t *testing.T) {
+	cfg := Config{
+		Profiles: map[string]Profile{
+			"default": p(map[string]Action{
+				"ready": {Steps: []Step{{Type: "sound", Sound: "blip"}}, OnSuccess: "missing"},
+			}),
+		},
+	}
+	err := Validate(cfg)
+	if err == nil || !strings.Contains(err.Error(), `profiles.default.ready: on_success action "missing" not found`) {
+		t.Errorf("err = %v, want on_success not found", err)
+	}
+}
+
+func TestValidateChainedActionLoops(t *testing.T) {
+	step := []Step{{Type: "sound", Sound: "blip"}}
+	tests := []struct {
+		name    string
+		actions map[string]Action
+		want    string
+	}{
+		{"self", map[string]Action{
+			"ready": {Steps: step, OnFailure: "ready"},
+		}, "ready -> ready"},
+		{"two", map[string]Action{
+			"ready": {Steps: step, OnSuccess: "done"},
+			"done":  {Steps: step, OnFailure: "ready"},
+		}, "done -> ready -> done"},
+		{"three", map[string]Action{
+			"a": {Steps: step, OnFailure: "b"},
+			"b": {Steps: step, OnFailure: "c"},
+			"c": {Steps: step, OnSuccess: "a"},
+		}, "a -> b -> c -> a"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(Config{Profiles: map[string]Profile{"default": p(tt.actions)}})
+			if err == nil {
+				t.Fatal("expected loop error")
+			}
+			if !strings.Contains(err.Error(), "chain loops: "+tt.want) {
+				t.Errorf("err = %v, want loop %q", err, tt.want)
+			}
+			if n := strings.Count(err.Error(), "chain loops"); n != 1 {
+				t.Errorf("loop reported %d times, want once", n)
+			}
+		})
+	}
+}
+
+func TestValidateChainedLoopThroughDefault(t *testing.T) {
+	step := []Step{{Type: "sound", Sound: "blip"}}
+	cfg := Config{
+		Profiles: map[string]Profile{
+			"default": p(map[string]Action{
+				"ready": {Steps: step, OnFailure: "escalate"},
+			}),
+			"boss": p(map[string]Action{
+				"escalate": {Steps: step, OnSuccess: "ready"},
+			}),
+		},
+	}
+	err := Validate(cfg)
+	if err == nil {
+		t.Fatal("expected loop error")
+	}
+	if !strings.Contains(err.Error(), "profiles.boss: on_success/on_failure chain loops: escalate -> ready -> escalate") {
+		t.Errorf("err = %v, want loop through default", err)
+	}
+}
+
 func TestValidateNeverCondition(t *testing.T) {
 	cfg := Config{
 		Profiles: map[string]Profile{
@@ -572,6 +772,22 @@ func TestValidateDefaultVolumeOutOfRange(t *testing.T) {
 	}
 }
 
+func TestValidateSMTPTLS(t *testing.T) {
+	cfg := Config{
+		Options: Options{Credentials: Credentials{SMTPTLS: "none"}},
+		Profiles: map[string]Profile{
+			"default": p(map[string]Action{"ready": {Steps: []Step{{Type: "sound", Sound: "blip"}}}}),
+		},
+	}
+	if err := Validate(cfg); err != nil {
+		t.Errorf("smtp_tls none: %v", err)
+	}
+	cfg.Options.Credentials.SMTPTLS = "off"
+	if err := Validate(cfg); err == nil || !strings.Contains(err.Error(), `credentials.smtp_tls "off" is not valid`) {
+		t.Errorf("err = %v, want smtp_tls rejected", err)
+	}
+}
+
 func TestValidateMultipleErrors(t *testing.T) {
 	cfg := Config{
 		Options: Options{DefaultVolume: 200},
@@ -2003,3 +2219,194 @@ func TestDefaultConfigBuiltinNotSerialized(t *testing.T) {
 		t.Error("Builtin field should not appear in JSON output")
 	}
 }
+
+func TestValidateCalendarConditions(t *testing.T) {
+	tests := []struct {
+		when    string
+		holiday string
+		wantErr string
+	}{
+		{"days:mon-fri", "", ""},
+		{"days:sat,sun and not holiday", "holidays.ics", ""},
+		{"date:2026-12-24..2027-01-02", "", ""},
+		{"date:2026-12-24", "", ""},
+		{"days:monday", "", "invalid days spec"},
+		{"days:mon-", "", "invalid days spec"},
+		{"date:2026-13-01", "", "invalid date spec"},
+		{"date:2027-01-02..2026-12-24", "", "invalid date spec"},
+		{"present and not holiday", "", "holiday condition requires config.holiday_file"},
+	}
+	for _, tt := range tests {
+		cfg := Config{
+			Options: Options{HolidayFile: tt.holiday},
+			Profiles: map[string]Profile{
+				"default": p(map[string]Action{
+					"ready": {Steps: []Step{{Type: "sound", Sound: "blip", When: tt.when}}},
+				}),
+			},
+		}
+		err := Validate(cfg)
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Errorf("when=%q: unexpected error: %v", tt.when, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("when=%q: error = %v, want %q", tt.when, err, tt.wantErr)
+		}
+	}
+}
+
+func TestParseDaysSpec(t *testing.T) {
+	tests := []struct {
+		spec string
+		want []time.Weekday
+	}{
+		{"mon-fri", []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}},
+		{"sat,sun", []time.Weekday{time.Saturday, time.Sunday}},
+		{"fri-mon", []time.Weekday{time.Friday, time.Saturday, time.Sunday, time.Monday}},
+		{"mon-tue,thu", []time.Weekday{time.Monday, time.Tuesday, time.Thursday}},
+		{"wed", []time.Weekday{time.Wednesday}},
+	}
+	for _, tt := range tests {
+		got, err := ParseDaysSpec(tt.spec)
+		if err != nil {
+			t.Errorf("ParseDaysSpec(%q): %v", tt.spec, err)
+			continue
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("ParseDaysSpec(%q) = %v, want %v", tt.spec, got, tt.want)
+			continue
+		}
+		for _, d := range tt.want {
+			if !got[d] {
+				t.Errorf("ParseDaysSpec(%q) missing %v", tt.spec, d)
+			}
+		}
+	}
+}
+
+func TestCredentialKeysMatchFields(t *testing.T) {
+	var c Credentials
+	if len(credentialKeys) != len(c.fields()) {
+		t.Fatalf("credentialKeys has %d names, fields() has %d", len(credentialKeys), len(c.fields()))
+	}
+	for i, f := range c.fields() {
+		*f = "set"
+		if !c.Configured(credentialKeys[i]) {
+			t.Errorf("credentialKeys[%d] = %q does not name fields()[%d]", i, credentialKeys[i], i)
+		}
+		*f = ""
+	}
+}
+
+func TestUnmarshalBatch(t *testing.T) {
+	data := []byte(`{
+		"profiles": {
+			"default": {
+				"batch": "5s",
+				"ready": { "steps": [{"type": "sound", "sound": "success"}] },
+				"error": { "batch": "0s", "steps": [{"type": "sound", "sound": "error"}] }
+			},
+			"quiet": { "extends": "default" }
+		}
+	}`)
+
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if err := resolveInheritance(&cfg); err != nil {
+		t.Fatalf("resolveInheritance: %v", err)
+	}
+
+	def := cfg.Profiles["default"]
+	if def.Batch != "5s" {
+		t.Errorf("Batch = %q, want 5s", def.Batch)
+	}
+	if _, ok := def.Actions["batch"]; ok {
+		t.Error("batch should not be parsed as an action")
+	}
+	if got := BatchWindow(def, def.Actions["ready"]); got != 5*time.Second {
+		t.Errorf("BatchWindow(ready) = %v, want 5s", got)
+	}
+	if got := BatchWindow(def, def.Actions["error"]); got != 0 {
+		t.Errorf("BatchWindow(error) = %v, want 0 (action override)", got)
+	}
+	if got := cfg.Profiles["quiet"].Batch; got != "5s" {
+		t.Errorf("quiet.Batch = %q, want inherited 5s", got)
+	}
+}
+
+func TestValidateBatch(t *testing.T) {
+	tests := []struct {
+		batch string
+		ok    bool
+	}{
+		{"", true},
+		{"0s", true},
+		{"5s", true},
+		{"10m", true},
+		{"11m", false},
+		{"-1s", false},
+		{"soon", false},
+	}
+	for _, tt := range tests {
+		err := validateBatch(tt.batch)
+		if (err == nil) != tt.ok {
+			t.Errorf("validateBatch(%q) = %v, want ok=%v", tt.batch, err, tt.ok)
+		}
+	}
+}
+
+func TestValidateEscalate(t *testing.T) {
+	step := []Step{{Type: "sound", Sound: "blip"}}
+	tests := []struct {
+		name string
+		e    Escalate
+		want string
+	}{
+		{"ok", Escalate{Every: "5m", Max: 3}, ""},
+		{"other action", Escalate{Every: "30s", Action: "page", Max: 1}, ""},
+		{"too short", Escalate{Every: "10s", Max: 1}, `escalate.every "10s" must be a duration of at least 30s`},
+		{"bad duration", Escalate{Every: "often", Max: 1}, `escalate.every "often"`},
+		{"no max", Escalate{Every: "1m"}, "escalate.max 0 must be at least 1"},
+		{"negative max", Escalate{Every: "1m", Max: -1}, "escalate.max -1 must be at least 1"},
+		{"unknown action", Escalate{Every: "1m", Action: "missing", Max: 1}, `escalate.action action "missing" not found`},
+	}
+	for _, tt := range tests {
+		e := tt.e
+		cfg := Config{
+			Profiles: map[string]Profile{
+				"default": p(map[string]Action{
+					"ready": {Steps: step, Escalate: &e},
+					"page":  {Steps: step},
+				}),
+			},
+		}
+		err := Validate(cfg)
+		if tt.want == "" {
+			if err != nil {
+				t.Errorf("%s: unexpected error: %v", tt.name, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: err = %v, want containing %q", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestUnmarshalEscalate(t *testing.T) {
+	var a Action
+	if err := json.Unmarshal([]byte(`{"steps":[],"escalate":{"every":"5m","action":"page","max":3}}`), &a); err != nil {
+		t.Fatal(err)
+	}
+	if a.Escalate == nil || a.Escalate.Every != "5m" || a.Escalate.Action != "page" || a.Escalate.Max != 3 {
+		t.Fatalf("Escalate = %+v", a.Escalate)
+	}
+	if got := a.Escalate.Interval(); got != 5*time.Minute {
+		t.Errorf("Interval() = %v, want 5m", got)
+	}
+}
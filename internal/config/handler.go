@@ -0,0 +1,184 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/Mavwarf/notify/internal/tmpl"
+)
+
+// StepHandler implements one step type. Each type registers its handler
+// with RegisterStep (the built-in types live in internal/steps, one file
+// per type), and every place that depends on the type — validation,
+// dry-run and event log summaries, the dashboard, and the runner — looks
+// the handler up instead of switching on the type name.
+type StepHandler interface {
+	// Validate returns problems with the step's type-specific fields and
+	// credentials, without the step path prefix (e.g. `slack step
+	// requires "text" field`).
+	Validate(s Step, creds Credentials) []string
+
+	// Credentials returns the JSON names of the credentials the step
+	// requires (e.g. "slack_webhook"), in Credentials field order. One the
+	// step can set itself, like an email "to", is left out when it does.
+	Credentials(s Step) []string
+
+	// Summary returns the "key=value" parts describing the step. When
+	// vars is non-nil, template variables are expanded (logging mode);
+	// when nil, raw values are shown (dry-run mode).
+	Summary(s Step, vars *tmpl.Vars) []string
+
+	// Execute delivers the step. Remote network calls must go through
+	// env.Deliver so the caller's retry policy applies.
+	Execute(s Step, env StepEnv) error
+
+	// Sequential reports whether the step uses the local audio pipeline
+	// and must run one after another with other sequential steps.
+	Sequential() bool
+
+	// UsesVoice reports whether the step speaks its text via TTS, so the
+	// text is recorded for AI voice generation and served from the voice
+	// cache.
+	UsesVoice() bool
+
+	// Sendable reports whether "notify send" offers the type: a step
+	// built from just a message is complete, and sending it outside an
+	// action run means something (an incident step, which opens and
+	// resolves incidents by a run's outcome, does not).
+	Sendable() bool
+}
+
+// StepEnv is the invocation state passed to StepHandler.Execute.
+type StepEnv struct {
+	Volume  int // effective volume 0-100 (per-step override applied)
+	Creds   Credentials
+	Vars    tmpl.Vars
+	Desktop *int // virtual desktop of the profile, for toast click actions
+
+	// SendID is a random ID for this delivery of the step. The runner's
+	// retry and later outbox retries reuse it, so handlers can pass it as
+	// an idempotency key (the Matrix transaction ID); every new
+	// notification gets a new one.
+	SendID string
+
+	// Deliver runs a remote network call and decides the retry policy.
+	// Local preparation (TTS rendering, conversion) happens outside it so
+	// a missing TTS engine is never queued for retry. Its error wraps
+	// ErrQueued when the step was written to the outbox.
+	Deliver func(send func() error) error
+}
+
+// ErrQueued marks step errors whose step was written to the outbox for a
+// later retry.
+var ErrQueued = errors.New("queued for retry")
+
+var (
+	stepMu       sync.RWMutex
+	stepHandlers = map[string]StepHandler{}
+)
+
+// RegisterStep makes a step type available under the given name. It is
+// meant to be called from init functions and panics if the name is
+// already registered.
+func RegisterStep(typ string, h StepHandler) {
+	stepMu.Lock()
+	defer stepMu.Unlock()
+	if _, dup := stepHandlers[typ]; dup {
+		panic(fmt.Sprintf("config: step type %q registered twice", typ))
+	}
+	stepHandlers[typ] = h
+}
+
+// LookupStep returns the handler for a step type.
+func LookupStep(typ string) (StepHandler, bool) {
+	stepMu.RLock()
+	defer stepMu.RUnlock()
+	h, ok := stepHandlers[typ]
+	return h, ok
+}
+
+// StepTypes returns the registered step type names, sorted.
+func StepTypes() []string {
+	stepMu.RLock()
+	defer stepMu.RUnlock()
+	types := make([]string, 0, len(stepHandlers))
+	for typ := range stepHandlers {
+		types = append(types, typ)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// credentialKeys are the JSON names of the fields returned by
+// Credentials.fields, in the same order, and secretKeys those tagged
+// `secret:"true"`. Both are read from the struct tags.
+var credentialKeys, secretKeys = credentialTags()
+
+func credentialTags() (keys []string, secrets map[string]bool) {
+	secrets = map[string]bool{}
+	t := reflect.TypeOf(Credentials{})
+	for i := range t.NumField() {
+		key, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		keys = append(keys, key)
+		if t.Field(i).Tag.Get("secret") == "true" {
+			secrets[key] = true
+		}
+	}
+	return keys, secrets
+}
+
+// Configured reports whether the credential with the given JSON name
+// (e.g. "slack_webhook") is set.
+func (c Credentials) Configured(key string) bool {
+	for i, f := range c.fields() {
+		if credentialKeys[i] == key {
+			return *f != ""
+		}
+	}
+	return false
+}
+
+// Redacted returns c with the value of every set secret credential
+// replaced by "***", for showing the config without revealing secrets.
+func (c Credentials) Redacted() Credentials {
+	for i, f := range c.fields() {
+		if secretKeys[credentialKeys[i]] && *f != "" {
+			*f = "***"
+		}
+	}
+	return c
+}
+
+// allCredentials returns Credentials with every field set to a
+// placeholder, so a handler's Validate reports only field problems.
+func allCredentials() Credentials {
+	var c Credentials
+	for _, f := range c.fields() {
+		*f = "x"
+	}
+	return c
+}
+
+// StepFieldErrors validates a step's type-specific fields as if every
+// credential were configured. An unregistered type is reported as such.
+func StepFieldErrors(s Step) []string {
+	h, ok := LookupStep(s.Type)
+	if !ok {
+		return []string{fmt.Sprintf("unknown type %q", s.Type)}
+	}
+	return h.Validate(s, allCredentials())
+}
+
+// StepCredentials returns the JSON names of the credentials a step
+// needs, as declared by its handler. An unregistered type needs none.
+func StepCredentials(s Step) []string {
+	h, ok := LookupStep(s.Type)
+	if !ok {
+		return nil
+	}
+	return h.Credentials(s)
+}
@@ -0,0 +1,131 @@
+package config_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/Mavwarf/notify/internal/config"
+	// Register the built-in step handlers. Being an external test package,
+	// this also makes them available to the package's internal tests.
+	_ "github.com/Mavwarf/notify/internal/steps"
+)
+
+func TestBuiltinStepTypesRegistered(t *testing.T) {
+	want := []string{
+		"discord", "discord_voice", "email", "file", "googlechat", "gotify", "matrix", "mattermost",
+		"mqtt", "nats", "ntfy", "opsgenie", "pagerduty", "plugin", "pushover", "redis", "say",
+		"slack", "sound", "syslog", "teams", "telegram", "telegram_audio", "telegram_voice",
+		"terminal", "tmux", "toast", "webhook",
+	}
+	if got := config.StepTypes(); !slices.Equal(got, want) {
+		t.Errorf("StepTypes() = %v, want %v", got, want)
+	}
+}
+
+func TestStepHandlerCapabilities(t *testing.T) {
+	tests := []struct {
+		typ        string
+		sequential bool
+		voice      bool
+	}{
+		{"sound", true, false},
+		{"say", true, true},
+		{"toast", false, false},
+		{"discord", false, false},
+		{"discord_voice", false, true},
+		{"telegram_voice", false, true},
+		{"webhook", false, false},
+	}
+	for _, tt := range tests {
+		h, ok := config.LookupStep(tt.typ)
+		if !ok {
+			t.Errorf("LookupStep(%q) not found", tt.typ)
+			continue
+		}
+		if h.Sequential() != tt.sequential || h.UsesVoice() != tt.voice {
+			t.Errorf("%s: Sequential=%v UsesVoice=%v, want %v %v",
+				tt.typ, h.Sequential(), h.UsesVoice(), tt.sequential, tt.voice)
+		}
+	}
+	if _, ok := config.LookupStep("fax"); ok {
+		t.Error("LookupStep(fax) found an unregistered type")
+	}
+}
+
+func TestRegisterStepDuplicatePanics(t *testing.T) {
+	h, _ := config.LookupStep("sound")
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic on duplicate registration")
+		}
+	}()
+	config.RegisterStep("sound", h)
+}
+
+func TestStepCredentials(t *testing.T) {
+	tests := []struct {
+		step config.Step
+		want []string
+	}{
+		{config.Step{Type: "slack", Text: "hi"}, []string{"slack_webhook"}},
+		{config.Step{Type: "telegram_voice", Text: "hi"}, []string{"telegram_token", "telegram_chat_id"}},
+		{config.Step{Type: "mqtt", Broker: "b", Topic: "t", Text: "hi"}, nil},
+		{config.Step{Type: "redis", Broker: "redis://localhost", Topic: "t", Text: "hi"}, nil},
+		{config.Step{Type: "nats", Broker: "nats://localhost", Topic: "t", Text: "hi"}, nil},
+		{config.Step{Type: "email", Text: "hi"}, []string{"smtp_host", "smtp_from", "smtp_to"}},
+		{config.Step{Type: "email", To: "me@example.com", Text: "hi"}, []string{"smtp_host", "smtp_from"}},
+		{config.Step{Type: "pushover", Text: "hi"}, []string{"pushover_token", "pushover_user"}},
+		{config.Step{Type: "gotify", Text: "hi"}, []string{"gotify_url", "gotify_token"}},
+		{config.Step{Type: "matrix", Text: "hi"}, []string{"matrix_url", "matrix_token", "matrix_room"}},
+		{config.Step{Type: "matrix", Room: "!r:example.org", Text: "hi"}, []string{"matrix_url", "matrix_token"}},
+		{config.Step{Type: "teams", Text: "hi", Attach: "output"}, []string{"teams_webhook"}},
+		{config.Step{Type: "mattermost", Text: "hi", Channel: "builds"}, []string{"mattermost_webhook"}},
+		{config.Step{Type: "googlechat", Text: "hi"}, []string{"googlechat_webhook"}},
+		{config.Step{Type: "pagerduty", Text: "hi"}, []string{"pagerduty_key"}},
+		{config.Step{Type: "opsgenie", Text: "hi"}, []string{"opsgenie_key"}},
+		{config.Step{Type: "syslog", Text: "hi"}, nil},
+		{config.Step{Type: "file", Path: "/tmp/notify.jsonl"}, nil},
+		{config.Step{Type: "terminal", Message: "hi"}, nil},
+		{config.Step{Type: "tmux", Text: "hi"}, nil},
+		{config.Step{Type: "sound", Sound: "blip"}, nil},
+		{config.Step{Type: "bogus"}, nil},
+	}
+	for _, tt := range tests {
+		if got := config.StepCredentials(tt.step); !slices.Equal(got, tt.want) {
+			t.Errorf("StepCredentials(%s) = %v, want %v", tt.step.Type, got, tt.want)
+		}
+	}
+}
+
+func TestStepFieldErrorsIgnoresCredentials(t *testing.T) {
+	if errs := config.StepFieldErrors(config.Step{Type: "discord", Text: "hi"}); len(errs) != 0 {
+		t.Errorf("discord with text: %v", errs)
+	}
+	if errs := config.StepFieldErrors(config.Step{Type: "webhook", Text: "hi"}); len(errs) != 1 {
+		t.Errorf("webhook without url: %v, want one error", errs)
+	}
+}
+
+func TestCredentialsConfigured(t *testing.T) {
+	c := config.Credentials{SlackWebhook: "https://hooks.slack.com/x"}
+	if !c.Configured("slack_webhook") {
+		t.Error("slack_webhook should be configured")
+	}
+	if c.Configured("discord_webhook") || c.Configured("nope") {
+		t.Error("unset or unknown credentials should not be configured")
+	}
+}
+
+func TestCredentialsRedacted(t *testing.T) {
+	c := config.Credentials{SlackWebhook: "https://hooks.slack.com/x", MQTTUsername: "notify", MQTTPassword: "secret"}
+	got := c.Redacted()
+	if got.SlackWebhook != "***" || got.MQTTPassword != "***" {
+		t.Errorf("secrets not redacted: %+v", got)
+	}
+	if got.MQTTUsername != "notify" || got.DiscordWebhook != "" {
+		t.Errorf("non-secret or unset credentials changed: %+v", got)
+	}
+	if c.SlackWebhook == "***" {
+		t.Error("Redacted modified the receiver")
+	}
+}
@@ -0,0 +1,116 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Severity levels for Action.Severity and the --severity flag. An action
+// without a severity is SeverityInfo.
+const (
+	SeverityInfo     = "info"
+	SeverityWarning  = "warning"
+	SeverityCritical = "critical"
+)
+
+// Severities lists the severity levels from lowest to highest.
+var Severities = []string{SeverityInfo, SeverityWarning, SeverityCritical}
+
+// ValidSeverity reports whether s is a known severity level.
+func ValidSeverity(s string) bool {
+	for _, v := range Severities {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
+
+// EffectiveSeverity returns the action's severity, or SeverityInfo if
+// unset.
+func (a *Action) EffectiveSeverity() string {
+	if a.Severity == "" {
+		return SeverityInfo
+	}
+	return a.Severity
+}
+
+// SeverityRoute is the routing table entry for one severity. Present and
+// AFK list the step types of an action's own steps that may run, by AFK
+// state: a nil list (omitted in JSON) places no restriction; an empty list
+// runs none of them. Steps are added to every action of the severity and
+// are not limited by Present and AFK; their "when" condition ("afk",
+// "present", ...) picks the state they run in.
+type SeverityRoute struct {
+	Present []string `json:"present"`
+	AFK     []string `json:"afk"`
+	Steps   []Step   `json:"steps,omitempty"`
+}
+
+// RouteFor returns the step types the routing table allows for severity
+// in the given AFK state, or nil when every type may run.
+func (o *Options) RouteFor(severity string, afk bool) []string {
+	r, ok := o.Routing[severity]
+	if !ok {
+		return nil
+	}
+	if afk {
+		return r.AFK
+	}
+	return r.Present
+}
+
+// RoutedSteps returns steps followed by the steps the routing table adds
+// for severity, which are marked Routed.
+func (o *Options) RoutedSteps(steps []Step, severity string) []Step {
+	extra := o.Routing[severity].Steps
+	if len(extra) == 0 {
+		return steps
+	}
+	out := make([]Step, 0, len(steps)+len(extra))
+	out = append(out, steps...)
+	for _, s := range extra {
+		s.Routed = true
+		out = append(out, s)
+	}
+	return out
+}
+
+// validateRouting checks the severity names, step types, and steps of the
+// routing table. Its steps are shared by every profile, so they are
+// checked against the global credentials.
+func validateRouting(o Options) []string {
+	routing := o.Routing
+	names := make([]string, 0, len(routing))
+	for name := range routing {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var errs []string
+	for _, name := range names {
+		if !ValidSeverity(name) {
+			errs = append(errs, fmt.Sprintf("config: routing key %q is not a severity (use info, warning, or critical)", name))
+			continue
+		}
+		r := routing[name]
+		for _, list := range []struct {
+			state string
+			types []string
+		}{{"present", r.Present}, {"afk", r.AFK}} {
+			for _, t := range list.types {
+				if _, ok := LookupStep(t); !ok {
+					errs = append(errs, fmt.Sprintf("config: routing.%s.%s: unknown step type %q", name, list.state, t))
+				}
+			}
+		}
+		for i, s := range r.Steps {
+			sp := fmt.Sprintf("config: routing.%s.steps[%d]", name, i)
+			errs = append(errs, validateStep(sp, s, o.Credentials, false)...)
+			if o.HolidayFile == "" && whenUsesAtom(s.When, "holiday") {
+				errs = append(errs, fmt.Sprintf("%s: holiday condition requires config.holiday_file", sp))
+			}
+		}
+	}
+	return errs
+}
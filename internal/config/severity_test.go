@@ -0,0 +1,99 @@
+package config
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestUnmarshalRouting(t *testing.T) {
+	data := `{
+		"config": {
+			"routing": {
+				"info":     { "present": ["sound", "toast"], "afk": [] },
+				"critical": { "afk": ["telegram"] }
+			}
+		},
+		"profiles": {}
+	}`
+	var cfg Config
+	if err := json.Unmarshal([]byte(data), &cfg); err != nil {
+		t.Fatal(err)
+	}
+	o := cfg.Options
+	if got := o.RouteFor(SeverityInfo, false); !slices.Equal(got, []string{"sound", "toast"}) {
+		t.Errorf("info/present = %v", got)
+	}
+	if got := o.RouteFor(SeverityInfo, true); got == nil || len(got) != 0 {
+		t.Errorf("info/afk = %#v, want empty non-nil (run nothing)", got)
+	}
+	if got := o.RouteFor(SeverityCritical, false); got != nil {
+		t.Errorf("critical/present = %v, want nil (no restriction)", got)
+	}
+	if got := o.RouteFor(SeverityCritical, true); !slices.Equal(got, []string{"telegram"}) {
+		t.Errorf("critical/afk = %v", got)
+	}
+	if got := o.RouteFor(SeverityWarning, true); got != nil {
+		t.Errorf("warning (no row) = %v, want nil", got)
+	}
+}
+
+func TestRoutedSteps(t *testing.T) {
+	o := Options{Routing: map[string]SeverityRoute{
+		SeverityCritical: {Steps: []Step{{Type: "telegram", Text: "{action}"}}},
+	}}
+	own := []Step{{Type: "sound", Sound: "blip"}}
+
+	got := o.RoutedSteps(own, SeverityCritical)
+	if len(got) != 2 || got[0].Routed || got[1].Type != "telegram" || !got[1].Routed {
+		t.Errorf("critical = %+v, want sound then the routed telegram step", got)
+	}
+	if o.Routing[SeverityCritical].Steps[0].Routed {
+		t.Error("RoutedSteps modified the routing table")
+	}
+	if got := o.RoutedSteps(own, SeverityInfo); len(got) != 1 {
+		t.Errorf("info = %+v, want only the action's steps", got)
+	}
+}
+
+func TestEffectiveSeverity(t *testing.T) {
+	if got := (&Action{}).EffectiveSeverity(); got != SeverityInfo {
+		t.Errorf("unset = %q, want info", got)
+	}
+	if got := (&Action{Severity: "critical"}).EffectiveSeverity(); got != "critical" {
+		t.Errorf("critical = %q", got)
+	}
+}
+
+func TestValidateSeverity(t *testing.T) {
+	cfg := Config{
+		Options: Options{
+			Routing: map[string]SeverityRoute{
+				"critical": {AFK: []string{"telegram", "pager"}},
+				"warning":  {Steps: []Step{{Type: "telegram", Text: "hi", When: "afk and"}}},
+				"urgent":   {},
+			},
+		},
+		Profiles: map[string]Profile{
+			"default": p(map[string]Action{
+				"ready": {Steps: []Step{{Type: "sound", Sound: "blip"}}, Severity: "high"},
+			}),
+		},
+	}
+	err := Validate(cfg)
+	if err == nil {
+		t.Fatal("expected errors")
+	}
+	for _, want := range []string{
+		`routing.critical.afk: unknown step type "pager"`,
+		`routing key "urgent" is not a severity`,
+		`routing.warning.steps[0]: telegram step requires credentials.telegram_token`,
+		`routing.warning.steps[0]: when condition ends unexpectedly`,
+		`profiles.default.ready: severity "high" is not valid`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("missing %q in:\n%v", want, err)
+		}
+	}
+}
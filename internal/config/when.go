@@ -0,0 +1,273 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// WhenExpr is a parsed step "when" condition. A condition is either a
+// single atom ("afk", "hours:8-22", "long:5m", "exit:!0", ...) or a boolean
+// combination of atoms using "and", "or", "not", and parentheses:
+//
+//	afk and long:5m
+//	present or hours:22-8
+//	not (run or hours:9-17)
+//
+// "not" binds tightest, then "and", then "or". Keywords are lowercase.
+type WhenExpr struct {
+	Op     string         // "atom", "and", "or", or "not"
+	Atom   string         // condition text when Op is "atom"
+	Args   []*WhenExpr    // operands for "and"/"or" (2+) and "not" (1)
+	Output *regexp.Regexp // compiled pattern of an "output:" atom
+}
+
+// ParseWhen parses a when condition and checks every atom for validity.
+// The empty string is not a valid expression; callers treat "" as "always".
+func ParseWhen(s string) (*WhenExpr, error) {
+	toks := tokenizeWhen(s)
+	if len(toks) == 0 {
+		return nil, fmt.Errorf("empty when condition")
+	}
+	p := &whenParser{toks: toks}
+	e, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.toks) {
+		return nil, fmt.Errorf("when condition %q: unexpected %q", s, p.toks[p.pos])
+	}
+	return e, nil
+}
+
+// Eval evaluates the expression, calling atom to decide each atom node.
+// Evaluation short-circuits like Go's && and ||.
+func (e *WhenExpr) Eval(atom func(*WhenExpr) bool) bool {
+	switch e.Op {
+	case "and":
+		for _, a := range e.Args {
+			if !a.Eval(atom) {
+				return false
+			}
+		}
+		return true
+	case "or":
+		for _, a := range e.Args {
+			if a.Eval(atom) {
+				return true
+			}
+		}
+		return false
+	case "not":
+		return !e.Args[0].Eval(atom)
+	default:
+		return atom(e)
+	}
+}
+
+// Explain returns the sub-clauses responsible for the expression being
+// false: the first false operand of an "and", every operand of an "or",
+// and the negated clause of a "not". Returns nil if the expression is true.
+func (e *WhenExpr) Explain(atom func(*WhenExpr) bool) []string {
+	if e.Eval(atom) {
+		return nil
+	}
+	switch e.Op {
+	case "and":
+		for _, a := range e.Args {
+			if !a.Eval(atom) {
+				return a.Explain(atom)
+			}
+		}
+	case "or":
+		var out []string
+		for _, a := range e.Args {
+			out = append(out, a.Explain(atom)...)
+		}
+		return out
+	case "not":
+		return []string{e.String()}
+	}
+	return []string{e.Atom}
+}
+
+// whenUsesAtom reports whether the when condition contains the given atom.
+// Conditions that don't parse report false; validateWhen flags them.
+func whenUsesAtom(when, atom string) bool {
+	if when == "" {
+		return false
+	}
+	e, err := ParseWhen(when)
+	if err != nil {
+		return false
+	}
+	return e.HasAtom(func(a string) bool { return a == atom })
+}
+
+// HasAtom reports whether match is true for any atom in the expression,
+// negated or not.
+func (e *WhenExpr) HasAtom(match func(atom string) bool) bool {
+	if e.Op == "atom" {
+		return match(e.Atom)
+	}
+	for _, a := range e.Args {
+		if a.HasAtom(match) {
+			return true
+		}
+	}
+	return false
+}
+
+// String renders the expression back to condition syntax, adding
+// parentheses only where precedence requires them.
+func (e *WhenExpr) String() string {
+	switch e.Op {
+	case "and", "or":
+		parts := make([]string, len(e.Args))
+		for i, a := range e.Args {
+			parts[i] = a.String()
+			// An "or" inside an "and" needs parentheses.
+			if e.Op == "and" && a.Op == "or" {
+				parts[i] = "(" + parts[i] + ")"
+			}
+		}
+		return strings.Join(parts, " "+e.Op+" ")
+	case "not":
+		inner := e.Args[0].String()
+		if e.Args[0].Op == "and" || e.Args[0].Op == "or" {
+			inner = "(" + inner + ")"
+		}
+		return "not " + inner
+	default:
+		return e.Atom
+	}
+}
+
+// tokenizeWhen splits a condition into words and parentheses. An
+// "output:/regex/" atom is kept whole, so the regex may contain spaces
+// and parentheses; a backslash escapes a "/" inside it.
+func tokenizeWhen(s string) []string {
+	var toks []string
+	start := -1 // start of the current word, -1 if none
+	flush := func(end int) {
+		if start >= 0 {
+			toks = append(toks, s[start:end])
+			start = -1
+		}
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if start < 0 && strings.HasPrefix(s[i:], "output:/") {
+			start = i
+			i += len("output:/")
+			for i < len(s) && s[i] != '/' {
+				if s[i] == '\\' {
+					i++
+				}
+				i++
+			}
+			continue // the closing "/" and any flags are read as word chars
+		}
+		switch c {
+		case '(', ')':
+			flush(i)
+			toks = append(toks, string(c))
+		case ' ', '\t', '\n':
+			flush(i)
+		default:
+			if start < 0 {
+				start = i
+			}
+		}
+	}
+	flush(len(s))
+	return toks
+}
+
+// whenParser is a recursive-descent parser over tokenizeWhen output.
+type whenParser struct {
+	toks []string
+	pos  int
+}
+
+func (p *whenParser) peek() string {
+	if p.pos < len(p.toks) {
+		return p.toks[p.pos]
+	}
+	return ""
+}
+
+func (p *whenParser) parseOr() (*WhenExpr, error) {
+	return p.parseBinary("or", p.parseAnd)
+}
+
+func (p *whenParser) parseAnd() (*WhenExpr, error) {
+	return p.parseBinary("and", p.parseUnary)
+}
+
+// parseBinary parses one or more operands separated by op, flattening
+// them into a single node.
+func (p *whenParser) parseBinary(op string, operand func() (*WhenExpr, error)) (*WhenExpr, error) {
+	first, err := operand()
+	if err != nil {
+		return nil, err
+	}
+	args := []*WhenExpr{first}
+	for p.peek() == op {
+		p.pos++
+		next, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, next)
+	}
+	if len(args) == 1 {
+		return first, nil
+	}
+	return &WhenExpr{Op: op, Args: args}, nil
+}
+
+func (p *whenParser) parseUnary() (*WhenExpr, error) {
+	if p.peek() == "not" {
+		p.pos++
+		inner, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &WhenExpr{Op: "not", Args: []*WhenExpr{inner}}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *whenParser) parsePrimary() (*WhenExpr, error) {
+	tok := p.peek()
+	switch tok {
+	case "":
+		return nil, fmt.Errorf("when condition ends unexpectedly")
+	case "(":
+		p.pos++
+		e, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ")" {
+			return nil, fmt.Errorf("when condition: missing \")\"")
+		}
+		p.pos++
+		return e, nil
+	case ")", "and", "or":
+		return nil, fmt.Errorf("when condition: unexpected %q", tok)
+	}
+	p.pos++
+	if strings.HasPrefix(tok, "output:") {
+		re, err := ParseOutputSpec(tok[7:])
+		if err != nil {
+			return nil, err
+		}
+		return &WhenExpr{Op: "atom", Atom: tok, Output: re}, nil
+	}
+	if err := validateWhenAtom(tok); err != nil {
+		return nil, err
+	}
+	return &WhenExpr{Op: "atom", Atom: tok}, nil
+}
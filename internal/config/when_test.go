@@ -0,0 +1,190 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// atoms returns an atom evaluator that is true for exactly the given atoms.
+func atoms(trueAtoms ...string) func(*WhenExpr) bool {
+	set := map[string]bool{}
+	for _, a := range trueAtoms {
+		set[a] = true
+	}
+	return func(a *WhenExpr) bool { return set[a.Atom] }
+}
+
+func TestParseWhenString(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"afk", "afk"},
+		{"afk and long:5m", "afk and long:5m"},
+		{"present or hours:22-8", "present or hours:22-8"},
+		{"afk or present and run", "afk or present and run"},
+		{"(afk or present) and run", "(afk or present) and run"},
+		{"not (run or direct)", "not (run or direct)"},
+		{"not not afk", "not not afk"},
+		{"  ( afk )  ", "afk"},
+		{"afk and present and run", "afk and present and run"},
+	}
+	for _, tt := range tests {
+		e, err := ParseWhen(tt.in)
+		if err != nil {
+			t.Errorf("ParseWhen(%q) error: %v", tt.in, err)
+			continue
+		}
+		if got := e.String(); got != tt.want {
+			t.Errorf("ParseWhen(%q).String() = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseWhenErrors(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"", "empty"},
+		{"afk and", "ends unexpectedly"},
+		{"(afk", `missing ")"`},
+		{"afk)", `unexpected ")"`},
+		{"afk present", `unexpected "present"`},
+		{"or afk", `unexpected "or"`},
+		{"afk and bogus", `unknown when condition "bogus"`},
+		{"not hours:25-3", "invalid hours spec"},
+		{"AFK", `unknown when condition "AFK"`},
+	}
+	for _, tt := range tests {
+		_, err := ParseWhen(tt.in)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("ParseWhen(%q) err = %v, want containing %q", tt.in, err, tt.want)
+		}
+	}
+}
+
+func TestWhenEval(t *testing.T) {
+	tests := []struct {
+		expr string
+		true []string
+		want bool
+	}{
+		{"afk and long:5m", []string{"afk", "long:5m"}, true},
+		{"afk and long:5m", []string{"afk"}, false},
+		{"present or hours:22-8", []string{"hours:22-8"}, true},
+		{"present or hours:22-8", nil, false},
+		{"not run", nil, true},
+		{"not run", []string{"run"}, false},
+		{"afk or present and run", []string{"afk"}, true},
+		{"(afk or present) and run", []string{"afk"}, false},
+	}
+	for _, tt := range tests {
+		e, err := ParseWhen(tt.expr)
+		if err != nil {
+			t.Fatalf("ParseWhen(%q): %v", tt.expr, err)
+		}
+		if got := e.Eval(atoms(tt.true...)); got != tt.want {
+			t.Errorf("Eval(%q, true=%v) = %v, want %v", tt.expr, tt.true, got, tt.want)
+		}
+	}
+}
+
+func TestWhenExplain(t *testing.T) {
+	tests := []struct {
+		expr string
+		true []string
+		want []string
+	}{
+		{"afk and long:5m", []string{"afk", "long:5m"}, nil},
+		{"afk and long:5m", []string{"afk"}, []string{"long:5m"}},
+		{"present or hours:22-8", nil, []string{"present", "hours:22-8"}},
+		{"not (run or direct)", []string{"direct"}, []string{"not (run or direct)"}},
+		{"(afk or present) and long:5m", nil, []string{"afk", "present"}},
+	}
+	for _, tt := range tests {
+		e, err := ParseWhen(tt.expr)
+		if err != nil {
+			t.Fatalf("ParseWhen(%q): %v", tt.expr, err)
+		}
+		if got := e.Explain(atoms(tt.true...)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Explain(%q, true=%v) = %q, want %q", tt.expr, tt.true, got, tt.want)
+		}
+	}
+}
+
+func TestValidateWhenExpression(t *testing.T) {
+	cfg := Config{
+		Profiles: map[string]Profile{
+			"default": p(map[string]Action{
+				"ready": {Steps: []Step{
+					{Type: "sound", Sound: "blip", When: "afk and long:5m"},
+					{Type: "sound", Sound: "blip", When: "present or (hours:22-8 and not run)"},
+				}},
+			}),
+		},
+	}
+	if err := Validate(cfg); err != nil {
+		t.Errorf("expected valid expressions, got: %v", err)
+	}
+}
+
+func TestTokenizeWhenOutputRegex(t *testing.T) {
+	got := tokenizeWhen(`exit:!0 and (output:/FATAL (core)|panic: x/i or long:5m)`)
+	want := []string{"exit:!0", "and", "(", "output:/FATAL (core)|panic: x/i", "or", "long:5m", ")"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("tokenizeWhen = %q, want %q", got, want)
+	}
+	got = tokenizeWhen(`output:/a\/b c/`)
+	if len(got) != 1 || got[0] != `output:/a\/b c/` {
+		t.Errorf("escaped slash: tokenizeWhen = %q", got)
+	}
+}
+
+func TestParseExitSpec(t *testing.T) {
+	tests := []struct {
+		spec   string
+		lo, hi int
+		ok     bool
+	}{
+		{"0", 0, 0, true},
+		{"2-5", 2, 5, true},
+		{"127", 127, 127, true},
+		{"5-2", 0, 0, false},
+		{"abc", 0, 0, false},
+		{"", 0, 0, false},
+		{"1-", 0, 0, false},
+	}
+	for _, tt := range tests {
+		lo, hi, err := ParseExitSpec(tt.spec)
+		if (err == nil) != tt.ok || lo != tt.lo || hi != tt.hi {
+			t.Errorf("ParseExitSpec(%q) = %d, %d, %v; want %d, %d, ok=%v", tt.spec, lo, hi, err, tt.lo, tt.hi, tt.ok)
+		}
+	}
+}
+
+func TestParseOutputSpec(t *testing.T) {
+	re, err := ParseOutputSpec("/fatal/i")
+	if err != nil || !re.MatchString("FATAL error") {
+		t.Errorf("ParseOutputSpec(/fatal/i) = %v, %v; want case-insensitive match", re, err)
+	}
+	for _, bad := range []string{"fatal", "//", "/fatal/x", "/(unclosed/"} {
+		if _, err := ParseOutputSpec(bad); err == nil {
+			t.Errorf("ParseOutputSpec(%q) err = nil, want error", bad)
+		}
+	}
+}
+
+func TestValidateExitAndOutputConditions(t *testing.T) {
+	valid := []string{"exit:0", "exit:!0", "exit:2-5", "exit:!2-5", "output:/FATAL/", "exit:!0 and output:/FATAL|panic/"}
+	for _, when := range valid {
+		if err := validateWhen(when); err != nil {
+			t.Errorf("validateWhen(%q) = %v, want nil", when, err)
+		}
+	}
+	invalid := []string{"exit:", "exit:x", "exit:5-2", "output:FATAL", "output:/[/"}
+	for _, when := range invalid {
+		if err := validateWhen(when); err == nil {
+			t.Errorf("validateWhen(%q) = nil, want error", when)
+		}
+	}
+}
@@ -1,4 +1,10 @@
 // Package cooldown implements per-profile rate limiting for notifications.
+//
+// State lives in cooldown.json in the data directory: one entry per
+// profile/action holding the time of the last notification and, in dedup
+// mode, how many repeats were suppressed since. An entry without
+// suppressed repeats is stored as a bare RFC 3339 timestamp, the format
+// used before dedup existed.
 package cooldown
 
 import (
@@ -11,6 +17,34 @@ import (
 	"github.com/Mavwarf/notify/internal/paths"
 )
 
+// entry is the cooldown state of one profile/action.
+type entry struct {
+	Last       time.Time `json:"last"`
+	Suppressed int       `json:"suppressed,omitempty"` // repeats counted since Last (dedup mode)
+}
+
+func (e entry) MarshalJSON() ([]byte, error) {
+	if e.Suppressed == 0 {
+		return json.Marshal(e.Last.Format(time.RFC3339))
+	}
+	type plain entry
+	return json.Marshal(plain(e))
+}
+
+func (e *entry) UnmarshalJSON(data []byte) error {
+	var ts string
+	if err := json.Unmarshal(data, &ts); err == nil {
+		last, err := time.Parse(time.RFC3339, ts)
+		if err != nil {
+			return err
+		}
+		*e = entry{Last: last}
+		return nil
+	}
+	type plain entry
+	return json.Unmarshal(data, (*plain)(e))
+}
+
 // Check returns true if the given profile/action is still within its cooldown
 // window. A missing or unreadable state file is treated as "not on cooldown"
 // (fail-open: if checking fails, notifications are allowed through rather
@@ -19,64 +53,139 @@ func Check(profile, action string, cooldownSeconds int) bool {
 	return check(statePath(), profile, action, cooldownSeconds)
 }
 
-// Record writes the current timestamp for the given profile/action.
+// Record writes the current timestamp for the given profile/action and
+// starts a new window with no suppressed repeats.
 // Errors are printed to stderr but never fatal (best-effort).
 func Record(profile, action string) {
 	record(statePath(), profile, action)
 }
 
+// Suppress counts a repeat of profile/action within its cooldown window
+// (dedup mode). It returns the number of repeats counted in the window so
+// far, including this one, and when the window closes. The caller that
+// gets 1 is responsible for the follow-up notification: it waits until
+// the window closes and calls Flush. Errors are printed to stderr and
+// return 1 with a window that is already closed, so the repeat is
+// reported right away instead of lost.
+func Suppress(profile, action string, cooldownSeconds int) (int, time.Time) {
+	return suppress(statePath(), profile, action, cooldownSeconds)
+}
+
+// Flush returns the number of repeats suppressed since the last
+// notification and starts a new window at the current time, so repeats
+// arriving during the follow-up are counted toward the next one.
+func Flush(profile, action string) int {
+	return flush(statePath(), profile, action)
+}
+
 func check(path, profile, action string, cooldownSeconds int) bool {
-	data, err := os.ReadFile(path)
+	state, err := load(path)
 	if err != nil {
-		return false // missing or unreadable → allow
-	}
-
-	var state map[string]string
-	if err := json.Unmarshal(data, &state); err != nil {
-		return false // corrupt → allow
+		return false // missing, unreadable, or corrupt → allow
 	}
 
-	key := paths.CooldownKey(profile, action)
-	ts, ok := state[key]
+	e, ok := state[paths.CooldownKey(profile, action)]
 	if !ok {
 		return false
 	}
 
-	last, err := time.Parse(time.RFC3339, ts)
+	return time.Since(e.Last) < time.Duration(cooldownSeconds)*time.Second
+}
+
+func record(path, profile, action string) {
+	err := update(path, func(state map[string]entry) {
+		state[paths.CooldownKey(profile, action)] = entry{Last: time.Now()}
+	})
 	if err != nil {
-		return false
+		fmt.Fprintf(os.Stderr, "cooldown: %v\n", err)
 	}
+}
 
-	return time.Since(last) < time.Duration(cooldownSeconds)*time.Second
+func suppress(path, profile, action string, cooldownSeconds int) (int, time.Time) {
+	n, closes := 1, time.Now()
+	err := update(path, func(state map[string]entry) {
+		key := paths.CooldownKey(profile, action)
+		e, ok := state[key]
+		if !ok {
+			e.Last = time.Now() // recorded entry vanished; start a window
+		}
+		e.Suppressed++
+		state[key] = e
+		n, closes = e.Suppressed, e.Last.Add(time.Duration(cooldownSeconds)*time.Second)
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "cooldown: %v\n", err)
+		return 1, time.Now()
+	}
+	return n, closes
 }
 
-func record(path, profile, action string) {
-	// Load existing state.
-	state := make(map[string]string)
-	if data, err := os.ReadFile(path); err == nil {
-		_ = json.Unmarshal(data, &state) // ignore corrupt; overwrite
+func flush(path, profile, action string) int {
+	n := 1
+	err := update(path, func(state map[string]entry) {
+		key := paths.CooldownKey(profile, action)
+		n = state[key].Suppressed
+		state[key] = entry{Last: time.Now()}
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "cooldown: %v\n", err)
+	}
+	return n
+}
+
+// load reads the state file. Entries that don't parse are dropped, so one
+// bad entry doesn't reset the others.
+func load(path string) (map[string]entry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	state := make(map[string]entry, len(raw))
+	for k, v := range raw {
+		var e entry
+		if err := json.Unmarshal(v, &e); err == nil {
+			state[k] = e
+		}
+	}
+	return state, nil
+}
+
+// update applies fn to the state under the state file lock and writes it
+// back. A missing or corrupt file is treated as empty.
+func update(path string, fn func(map[string]entry)) error {
+	unlock, err := paths.Lock(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	state, err := load(path)
+	if err != nil {
+		state = make(map[string]entry) // ignore missing or corrupt; overwrite
 	}
 
 	// Prune entries older than 24 hours to prevent unbounded growth of the
 	// state file. No real cooldown should last longer than a day.
-	for k, v := range state {
-		t, err := time.Parse(time.RFC3339, v)
-		if err != nil || time.Since(t) > 24*time.Hour {
+	for k, e := range state {
+		if time.Since(e.Last) > 24*time.Hour {
 			delete(state, k)
 		}
 	}
 
-	key := paths.CooldownKey(profile, action)
-	state[key] = time.Now().Format(time.RFC3339)
+	fn(state)
 
 	data, err := json.MarshalIndent(state, "", "  ")
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "cooldown: marshal: %v\n", err)
-		return
+		return fmt.Errorf("marshal: %w", err)
 	}
 	if err := paths.AtomicWrite(path, data); err != nil {
-		fmt.Fprintf(os.Stderr, "cooldown: write: %v\n", err)
+		return fmt.Errorf("write: %w", err)
 	}
+	return nil
 }
 
 func statePath() string {
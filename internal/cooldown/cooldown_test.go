@@ -177,3 +177,76 @@ func TestRecordCreatesDirectory(t *testing.T) {
 		t.Errorf("timestamp too old: %v", parsed)
 	}
 }
+
+func TestSuppressCountsRepeats(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cooldown.json")
+	record(path, "test", "ready")
+
+	n, closes := suppress(path, "test", "ready", 30)
+	if n != 1 {
+		t.Errorf("first suppress = %d, want 1", n)
+	}
+	if d := time.Until(closes); d < 25*time.Second || d > 30*time.Second {
+		t.Errorf("window closes in %v, want ~30s", d)
+	}
+	if n, _ := suppress(path, "test", "ready", 30); n != 2 {
+		t.Errorf("second suppress = %d, want 2", n)
+	}
+	if !check(path, "test", "ready", 30) {
+		t.Error("suppress should not reset the cooldown window")
+	}
+}
+
+func TestSuppressedCountPersisted(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cooldown.json")
+	record(path, "test", "ready")
+	suppress(path, "test", "ready", 30)
+	suppress(path, "test", "ready", 30)
+	suppress(path, "test", "ready", 30)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("state file not found: %v", err)
+	}
+	var got map[string]struct {
+		Last       time.Time `json:"last"`
+		Suppressed int       `json:"suppressed"`
+	}
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if got["test/ready"].Suppressed != 3 {
+		t.Errorf("suppressed = %d, want 3", got["test/ready"].Suppressed)
+	}
+}
+
+func TestFlushResetsCount(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cooldown.json")
+	past := time.Now().Add(-60 * time.Second).Format(time.RFC3339)
+	data, _ := json.Marshal(map[string]string{"test/ready": past})
+	os.WriteFile(path, data, 0644)
+
+	suppress(path, "test", "ready", 30)
+	suppress(path, "test", "ready", 30)
+
+	if n := flush(path, "test", "ready"); n != 2 {
+		t.Errorf("flush = %d, want 2", n)
+	}
+	if !check(path, "test", "ready", 30) {
+		t.Error("flush should start a new cooldown window")
+	}
+	if n, _ := suppress(path, "test", "ready", 30); n != 1 {
+		t.Errorf("suppress after flush = %d, want 1", n)
+	}
+}
+
+func TestRecordResetsCount(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cooldown.json")
+	record(path, "test", "ready")
+	suppress(path, "test", "ready", 30)
+
+	record(path, "test", "ready")
+	if n := flush(path, "test", "ready"); n != 0 {
+		t.Errorf("flush after record = %d, want 0", n)
+	}
+}
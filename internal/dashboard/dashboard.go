@@ -10,19 +10,20 @@ import (
 	"net/http"
 	"os"
 	"os/exec"
-	"strings"
 	"os/signal"
 	"path/filepath"
 	"runtime"
 	"sort"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/Mavwarf/notify/internal/config"
-	"github.com/Mavwarf/notify/internal/paths"
 	"github.com/Mavwarf/notify/internal/cooldown"
 	"github.com/Mavwarf/notify/internal/eventlog"
+	"github.com/Mavwarf/notify/internal/holiday"
 	"github.com/Mavwarf/notify/internal/idle"
+	"github.com/Mavwarf/notify/internal/paths"
 	"github.com/Mavwarf/notify/internal/runner"
 	"github.com/Mavwarf/notify/internal/silent"
 	"github.com/Mavwarf/notify/internal/tmpl"
@@ -42,23 +43,53 @@ var BuildDate = "unknown"
 // JSON response types used by API handlers.
 
 type jsonEntry struct {
-	Time          string `json:"time"`
-	Profile       string `json:"profile"`
-	Action        string `json:"action"`
-	Kind          string `json:"kind"`
-	ClaudeHook    string `json:"claude_hook,omitempty"`
-	ClaudeMessage string `json:"claude_message,omitempty"`
+	Time          string           `json:"time"`
+	Profile       string           `json:"profile"`
+	Action        string           `json:"action"`
+	Kind          string           `json:"kind"`
+	ClaudeHook    string           `json:"claude_hook,omitempty"`
+	ClaudeMessage string           `json:"claude_message,omitempty"`
+	Repeat        int              `json:"repeat,omitempty"`
+	Steps         []jsonStepResult `json:"steps,omitempty"`
+}
+
+type jsonStepResult struct {
+	Type      string `json:"type"`
+	Status    string `json:"status,omitempty"`
+	Error     string `json:"error,omitempty"`
+	LatencyMs int64  `json:"latency_ms"`
+	Attempts  int    `json:"attempts"`
 }
 
 func entryToJSON(e eventlog.Entry) jsonEntry {
-	return jsonEntry{
+	out := jsonEntry{
 		Time:          e.Time.Format(time.RFC3339),
 		Profile:       e.Profile,
 		Action:        e.Action,
 		Kind:          eventlog.KindString(e.Kind),
 		ClaudeHook:    e.ClaudeHook,
 		ClaudeMessage: e.ClaudeMessage,
+		Repeat:        e.Repeat,
 	}
+	for _, r := range e.Steps {
+		out.Steps = append(out.Steps, jsonStepResult{
+			Type:      r.Type,
+			Status:    r.Status,
+			Error:     r.Error,
+			LatencyMs: r.Latency.Milliseconds(),
+			Attempts:  r.Attempts,
+		})
+	}
+	return out
+}
+
+// logResults converts runner step results into their event log form.
+func logResults(results []runner.StepResult) []eventlog.StepResult {
+	out := make([]eventlog.StepResult, len(results))
+	for i, r := range results {
+		out[i] = eventlog.StepResult{Type: r.Type, Status: r.Status, Error: r.Error, Latency: r.Latency, Attempts: r.Attempts}
+	}
+	return out
 }
 
 type jsonSummary struct {
@@ -74,10 +105,12 @@ type jsonGroup struct {
 }
 
 type stepResult struct {
-	Index    int    `json:"index"`
-	Type     string `json:"type"`
-	Detail   string `json:"detail"`
-	WouldRun bool   `json:"would_run"`
+	Index    int          `json:"index"`
+	Type     string       `json:"type"`
+	Detail   string       `json:"detail"`
+	WouldRun bool         `json:"would_run"`
+	Reason   string       `json:"reason,omitempty"` // failing when sub-clauses, for skipped steps
+	Fallback []stepResult `json:"fallback,omitempty"`
 }
 
 type actionResult struct {
@@ -142,6 +175,7 @@ func Serve(cfg config.Config, configPath string, port int, open bool, showFn, mi
 	mux.HandleFunc("/api/voice/play/", handleVoicePlay)
 	mux.HandleFunc("/api/voice/generate", handleVoiceGenerate(configPath, cfg))
 	mux.HandleFunc("/api/silent", handleSilent)
+	mux.HandleFunc("/api/escalations", handleEscalations)
 	mux.HandleFunc("/api/trigger", handleTrigger(configPath, cfg))
 	mux.HandleFunc("/api/preferences", handlePreferences(configPath))
 	mux.HandleFunc("/api/edit-config", handleEditConfig(configPath))
@@ -221,6 +255,8 @@ func Serve(cfg config.Config, configPath string, port int, open bool, showFn, mi
 	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
 	defer stop()
 
+	go retryOutboxLoop(ctx, configPath, cfg)
+
 	go func() {
 		<-ctx.Done()
 		shutCtx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
@@ -244,6 +280,26 @@ func Serve(cfg config.Config, configPath string, port int, open bool, showFn, mi
 	return nil
 }
 
+// outboxRetryInterval is how often the dashboard process checks the
+// outbox for failed remote steps whose backoff has elapsed.
+const outboxRetryInterval = time.Minute
+
+// retryOutboxLoop periodically re-sends queued outbox entries so failed
+// notifications are delivered once the network is back, even when no new
+// notify invocation happens.
+func retryOutboxLoop(ctx context.Context, configPath string, fallback config.Config) {
+	ticker := time.NewTicker(outboxRetryInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			runner.RetryOutbox(loadCfg(configPath, fallback), false, "")
+		}
+	}
+}
+
 // openBrowser tries to open the URL in a chromeless browser window (app mode).
 // It tries Edge, then Chrome, then falls back to the OS default browser.
 func openBrowser(url string) {
@@ -578,6 +634,7 @@ func handleTest(configPath string, fallback config.Config) http.HandlerFunc {
 			Hostname: host,
 		}
 
+		conds := runner.Conditions{Holidays: holiday.Load(cfg.Options.HolidayFile)}
 		var results []actionResult
 		for _, aName := range actions {
 			_, act, err := config.Resolve(cfg, req.Profile, aName)
@@ -585,17 +642,26 @@ func handleTest(configPath string, fallback config.Config) http.HandlerFunc {
 				continue
 			}
 
-			wouldRun := runner.FilteredIndices(act.Steps, false, false, 0)
-			steps := make([]stepResult, len(act.Steps))
+			severity := act.EffectiveSeverity()
+			conds.Route = cfg.Options.RouteFor(severity, false)
+			actSteps := cfg.Options.RoutedSteps(act.Steps, severity)
+			wouldRun := runner.FilteredIndices(actSteps, conds)
+			steps := make([]stepResult, len(actSteps))
 			run, skip := 0, 0
-			for i, s := range act.Steps {
+			for i, s := range actSteps {
 				detail := eventlog.StepSummary(s, &vars)
 				wr := wouldRun[i]
+				reason := runner.ExplainWhen(s, conds)
+				if !runner.Routed(s, conds) {
+					reason = append(reason, "not routed for "+severity)
+				}
 				steps[i] = stepResult{
 					Index:    i + 1,
 					Type:     s.Type,
 					Detail:   detail,
 					WouldRun: wr,
+					Reason:   strings.Join(reason, ", "),
+					Fallback: fallbackResults(s.Fallback, &vars),
 				}
 				if wr {
 					run++
@@ -623,6 +689,24 @@ func handleTest(configPath string, fallback config.Config) http.HandlerFunc {
 	}
 }
 
+// fallbackResults describes a step's fallback chain for the dry-run. The
+// steps only run if their parent fails, so WouldRun is left false.
+func fallbackResults(steps []config.Step, vars *tmpl.Vars) []stepResult {
+	if len(steps) == 0 {
+		return nil
+	}
+	out := make([]stepResult, len(steps))
+	for i, s := range steps {
+		out[i] = stepResult{
+			Index:    i + 1,
+			Type:     s.Type,
+			Detail:   eventlog.StepSummary(s, vars),
+			Fallback: fallbackResults(s.Fallback, vars),
+		}
+	}
+	return out
+}
+
 // computeRange returns the start and end dates (inclusive) for a given range type
 // anchored to a specific date.
 // handleWatch returns the Summary, Breakdown, and Time Spent tabs payload: summary counts, time breakdown, and
@@ -1011,7 +1095,47 @@ func handleSilent(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+type jsonEscalation struct {
+	ID      string `json:"id"`
+	Profile string `json:"profile"`
+	Action  string `json:"action"`
+	Opened  string `json:"opened"`
+}
 
+// handleEscalations lists open escalations (GET) and acknowledges one
+// (POST {"id": "..."}), the dashboard counterpart of "notify ack".
+func handleEscalations(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		var req struct {
+			ID string `json:"id"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.ID == "" {
+			http.Error(w, "provide id", http.StatusBadRequest)
+			return
+		}
+		if _, err := eventlog.Ack(req.ID); err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	open, err := eventlog.OpenEscalations()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	out := make([]jsonEscalation, len(open))
+	for i, e := range open {
+		out[i] = jsonEscalation{ID: e.ID, Profile: e.Profile, Action: e.Action, Opened: e.Opened.Format(time.RFC3339)}
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	json.NewEncoder(w).Encode(out)
+}
 
 type triggerRequest struct {
 	Profile string `json:"profile"`
@@ -1021,12 +1145,13 @@ type triggerRequest struct {
 }
 
 type triggerResponse struct {
-	OK        bool   `json:"ok"`
-	Profile   string `json:"profile,omitempty"`
-	Action    string `json:"action,omitempty"`
-	StepsRun  int    `json:"steps_run,omitempty"`
-	StepsTotal int   `json:"steps_total,omitempty"`
-	Error     string `json:"error,omitempty"`
+	OK         bool             `json:"ok"`
+	Profile    string           `json:"profile,omitempty"`
+	Action     string           `json:"action,omitempty"`
+	StepsRun   int              `json:"steps_run,omitempty"`
+	StepsTotal int              `json:"steps_total,omitempty"`
+	Error      string           `json:"error,omitempty"`
+	Results    []jsonStepResult `json:"results,omitempty"`
 }
 
 // handleTrigger executes a real notification pipeline from the web UI. It reloads
@@ -1138,9 +1263,15 @@ func handleTrigger(configPath string, fallback config.Config) http.HandlerFunc {
 
 		// Filter and execute steps.
 		desk := cfg.Profiles[resolved].Desktop
-		totalSteps := len(act.Steps)
-		filtered := runner.FilterSteps(act.Steps, afk, false, 0)
-		execErr := runner.Execute(filtered, vol, creds, vars, desk)
+		vars.Severity = act.EffectiveSeverity()
+		steps := cfg.Options.RoutedSteps(act.Steps, vars.Severity)
+		totalSteps := len(steps)
+		filtered := runner.FilterSteps(steps, runner.Conditions{
+			AFK:      afk,
+			Holidays: holiday.Load(cfg.Options.HolidayFile),
+			Route:    cfg.Options.RouteFor(vars.Severity, afk),
+		})
+		results, execErr := runner.Execute(filtered, vol, creds, vars, desk)
 
 		// Record cooldown.
 		if cdEnabled && cdSec > 0 {
@@ -1152,12 +1283,13 @@ func handleTrigger(configPath string, fallback config.Config) http.HandlerFunc {
 
 		// Log execution.
 		if req.Log == nil || *req.Log {
-			eventlog.Log(req.Action, filtered, afk, vars, desk)
+			eventlog.Log(req.Action, filtered, logResults(results), afk, vars, desk)
 		}
 
+		stepResults := entryToJSON(eventlog.Entry{Steps: logResults(results)}).Steps
 		if execErr != nil {
 			w.WriteHeader(http.StatusInternalServerError)
-			json.NewEncoder(w).Encode(triggerResponse{Error: execErr.Error()})
+			json.NewEncoder(w).Encode(triggerResponse{Error: execErr.Error(), Results: stepResults})
 			return
 		}
 
@@ -1167,6 +1299,7 @@ func handleTrigger(configPath string, fallback config.Config) http.HandlerFunc {
 			Action:     req.Action,
 			StepsRun:   len(filtered),
 			StepsTotal: totalSteps,
+			Results:    stepResults,
 		})
 	}
 }
@@ -1175,13 +1308,13 @@ func handleTrigger(configPath string, fallback config.Config) http.HandlerFunc {
 // credential values replaced by "***".
 func redactConfig(cfg config.Config) config.Config {
 	out := cfg
-	out.Options.Credentials = redactCreds(cfg.Options.Credentials)
+	out.Options.Credentials = cfg.Options.Credentials.Redacted()
 
 	out.Profiles = make(map[string]config.Profile, len(cfg.Profiles))
 	for name, p := range cfg.Profiles {
 		cp := p
 		if p.Credentials != nil {
-			redacted := redactCreds(*p.Credentials)
+			redacted := p.Credentials.Redacted()
 			cp.Credentials = &redacted
 		}
 		out.Profiles[name] = cp
@@ -1189,35 +1322,6 @@ func redactConfig(cfg config.Config) config.Config {
 	return out
 }
 
-func redactCreds(c config.Credentials) config.Credentials {
-	if c.DiscordWebhook != "" {
-		c.DiscordWebhook = "***"
-	}
-	if c.SlackWebhook != "" {
-		c.SlackWebhook = "***"
-	}
-	if c.TelegramToken != "" {
-		c.TelegramToken = "***"
-	}
-	if c.TelegramChatID != "" {
-		c.TelegramChatID = "***"
-	}
-	if c.OpenAIAPIKey != "" {
-		c.OpenAIAPIKey = "***"
-	}
-	return c
-}
-
-// credentialRequirements maps step types to the credential fields they need.
-var credentialRequirements = map[string][]string{
-	"discord":        {"discord_webhook"},
-	"discord_voice":  {"discord_webhook"},
-	"slack":          {"slack_webhook"},
-	"telegram":       {"telegram_token", "telegram_chat_id"},
-	"telegram_audio": {"telegram_token", "telegram_chat_id"},
-	"telegram_voice": {"telegram_token", "telegram_chat_id"},
-}
-
 // handleCredentials reports which credentials each profile needs and whether
 // they are configured ("ok") or "missing", without revealing actual values.
 func handleCredentials(configPath string, fallback config.Config) http.HandlerFunc {
@@ -1237,11 +1341,10 @@ func handleCredentials(configPath string, fallback config.Config) http.HandlerFu
 			// Collect unique credential types needed by steps in this profile.
 			needed := map[string]bool{}
 			for _, action := range p.Actions {
-				for _, step := range action.Steps {
-					if reqs, ok := credentialRequirements[step.Type]; ok {
-						for _, req := range reqs {
-							needed[req] = true
-						}
+				steps := cfg.Options.RoutedSteps(action.Steps, action.EffectiveSeverity())
+				for _, step := range config.Flatten(steps) {
+					for _, req := range config.StepCredentials(step) {
+						needed[req] = true
 					}
 				}
 			}
@@ -1260,23 +1363,8 @@ func handleCredentials(configPath string, fallback config.Config) http.HandlerFu
 			var creds []credStatus
 			for _, ct := range credTypes {
 				status := "missing"
-				switch ct {
-				case "discord_webhook":
-					if merged.DiscordWebhook != "" {
-						status = "ok"
-					}
-				case "slack_webhook":
-					if merged.SlackWebhook != "" {
-						status = "ok"
-					}
-				case "telegram_token":
-					if merged.TelegramToken != "" {
-						status = "ok"
-					}
-				case "telegram_chat_id":
-					if merged.TelegramChatID != "" {
-						status = "ok"
-					}
+				if merged.Configured(ct) {
+					status = "ok"
 				}
 				creds = append(creds, credStatus{Type: ct, Status: status})
 			}
@@ -1291,5 +1379,3 @@ func handleCredentials(configPath string, fallback config.Config) http.HandlerFu
 		json.NewEncoder(w).Encode(result)
 	}
 }
-
-
@@ -185,6 +185,42 @@ func TestHandleHistory(t *testing.T) {
 	}
 }
 
+func TestHandleHistoryStepResults(t *testing.T) {
+	dir := t.TempDir()
+	logFile := filepath.Join(dir, "notify.log")
+	ts := time.Now().Format(time.RFC3339)
+
+	content := fmt.Sprintf(`%s  profile=notify  action=ready  steps=sound,telegram  afk=true
+%s    step[1] sound  sound=success  status=ok  attempts=1  latency=4ms
+%s    step[2] telegram  text="Ready!"  status=queued  attempts=2  latency=2.5s  error="telegram: timeout"
+
+`, ts, ts, ts)
+	if err := os.WriteFile(logFile, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	origDefault := eventlog.Default
+	eventlog.Default = eventlog.NewFileStore(logFile)
+	defer func() { eventlog.Default = origDefault }()
+
+	req := httptest.NewRequest("GET", "/api/history?days=1", nil)
+	w := httptest.NewRecorder()
+	handleHistory(w, req)
+
+	var entries []jsonEntry
+	if err := json.Unmarshal(w.Body.Bytes(), &entries); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if len(entries) != 1 || len(entries[0].Steps) != 2 {
+		t.Fatalf("entries = %+v, want 1 entry with 2 step results", entries)
+	}
+	got := entries[0].Steps[1]
+	want := jsonStepResult{Type: "telegram", Status: "queued", Error: "telegram: timeout", LatencyMs: 2500, Attempts: 2}
+	if got != want {
+		t.Errorf("Steps[1] = %+v, want %+v", got, want)
+	}
+}
+
 func TestHandleTestEndpoint(t *testing.T) {
 	cfg := testConfig()
 	handler := handleTest("", cfg)
@@ -1117,3 +1153,46 @@ func TestProfileMarshalJSON(t *testing.T) {
 		t.Fatal("should not have 'extends' key when empty")
 	}
 }
+
+func TestHandleEscalations(t *testing.T) {
+	origDefault := eventlog.Default
+	eventlog.Default = eventlog.NewFileStore(filepath.Join(t.TempDir(), "notify.log"))
+	defer func() { eventlog.Default = origDefault }()
+
+	eventlog.LogEscalation("ab12cd34", "boss", "ready", eventlog.EscalationOpen)
+
+	req := httptest.NewRequest("GET", "/api/escalations", nil)
+	w := httptest.NewRecorder()
+	handleEscalations(w, req)
+	if w.Code != 200 {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	var open []jsonEscalation
+	if err := json.Unmarshal(w.Body.Bytes(), &open); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if len(open) != 1 || open[0].ID != "ab12cd34" || open[0].Action != "ready" {
+		t.Fatalf("unexpected escalations: %+v", open)
+	}
+
+	// Acknowledge it; the response lists the remaining open escalations.
+	req = httptest.NewRequest("POST", "/api/escalations", strings.NewReader(`{"id":"ab12cd34"}`))
+	w = httptest.NewRecorder()
+	handleEscalations(w, req)
+	if w.Code != 200 {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	open = nil
+	json.Unmarshal(w.Body.Bytes(), &open)
+	if len(open) != 0 {
+		t.Errorf("expected no open escalations after ack, got %+v", open)
+	}
+
+	// Acknowledging again fails.
+	req = httptest.NewRequest("POST", "/api/escalations", strings.NewReader(`{"id":"ab12cd34"}`))
+	w = httptest.NewRecorder()
+	handleEscalations(w, req)
+	if w.Code != 404 {
+		t.Errorf("expected 404 for repeated ack, got %d", w.Code)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"net/http"
 
 	"github.com/Mavwarf/notify/internal/httputil"
 )
@@ -16,7 +17,9 @@ func Send(webhookURL, message string) error {
 		return fmt.Errorf("discord: marshal: %w", err)
 	}
 
-	resp, err := httputil.Post(webhookURL, "application/json", bytes.NewReader(body))
+	resp, err := httputil.Limited(webhookURL, func() (*http.Response, error) {
+		return httputil.Post(webhookURL, "application/json", bytes.NewReader(body))
+	})
 	if err != nil {
 		return fmt.Errorf("discord: post: %w", err)
 	}
@@ -35,10 +38,12 @@ func SendVoice(webhookURL, wavPath, caption string) error {
 
 	// Discord requires the JSON payload in a multipart field named "payload_json"
 	// (not a regular JSON body) when files are attached.
-	resp, err := httputil.PostMultipart(webhookURL, httputil.FileUpload{
-		FieldName: "file",
-		FilePath:  wavPath,
-	}, [][2]string{{"payload_json", string(payload)}})
+	resp, err := httputil.Limited(webhookURL, func() (*http.Response, error) {
+		return httputil.PostMultipart(webhookURL, httputil.FileUpload{
+			FieldName: "file",
+			FilePath:  wavPath,
+		}, [][2]string{{"payload_json", string(payload)}})
+	})
 	if err != nil {
 		return fmt.Errorf("discord: post voice: %w", err)
 	}
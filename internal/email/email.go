@@ -0,0 +1,206 @@
+// Package email sends notification emails over SMTP.
+package email
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+// ImplicitTLSPort is the SMTP submission port that expects TLS from the
+// first byte (SMTPS). Other ports upgrade with STARTTLS.
+const ImplicitTLSPort = "465"
+
+// DefaultPort is used when the server address has no port.
+const DefaultPort = "587"
+
+// timeout bounds the whole SMTP conversation.
+const timeout = 15 * time.Second
+
+// rootCAs verifies server certificates; nil uses the system roots.
+// Replaced in tests.
+var rootCAs *x509.CertPool
+
+// isLocal reports whether host is this machine, where a server without
+// STARTTLS is accepted. Replaced in tests.
+var isLocal = func(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// Server identifies the SMTP server and the optional login.
+type Server struct {
+	Addr        string // host:port
+	Username    string // empty = no AUTH
+	Password    string
+	ImplicitTLS bool // connect with TLS instead of upgrading via STARTTLS
+	NoTLS       bool // send in the clear, without STARTTLS (smtp_tls "none")
+}
+
+// Attachment is a file attached to a message.
+type Attachment struct {
+	Name string
+	Data []byte
+}
+
+// Message is one plain-text email. From and To are RFC 5322 addresses
+// and may carry display names ("Notify <notify@example.com>").
+type Message struct {
+	From        string
+	To          []string
+	Subject     string
+	Body        string
+	Attachments []Attachment
+}
+
+// Send delivers m through srv. With ImplicitTLS the connection is TLS
+// from the start; otherwise it is upgraded with STARTTLS, which a remote
+// server must offer: a server that doesn't (or a network that strips the
+// offer) fails rather than getting the message in plaintext. Only
+// localhost, or NoTLS, sends unencrypted. Credentials are only sent over
+// TLS, or in the clear to localhost (net/smtp's PlainAuth rule).
+func Send(srv Server, m Message) error {
+	host, _, err := net.SplitHostPort(srv.Addr)
+	if err != nil {
+		return fmt.Errorf("email: server address %q: %w", srv.Addr, err)
+	}
+	tlsCfg := &tls.Config{ServerName: host, RootCAs: rootCAs}
+
+	dialer := &net.Dialer{Timeout: timeout}
+	var conn net.Conn
+	if srv.ImplicitTLS {
+		conn, err = tls.DialWithDialer(dialer, "tcp", srv.Addr, tlsCfg)
+	} else {
+		conn, err = dialer.Dial("tcp", srv.Addr)
+	}
+	if err != nil {
+		return fmt.Errorf("email: connect: %w", err)
+	}
+	conn.SetDeadline(time.Now().Add(timeout))
+
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("email: %w", err)
+	}
+	defer c.Close()
+
+	if !srv.ImplicitTLS && !srv.NoTLS {
+		if ok, _ := c.Extension("STARTTLS"); ok {
+			if err := c.StartTLS(tlsCfg); err != nil {
+				return fmt.Errorf("email: starttls: %w", err)
+			}
+		} else if !isLocal(host) {
+			return fmt.Errorf("email: %s does not offer STARTTLS (set credentials.smtp_tls to \"none\" to send unencrypted)", host)
+		}
+	}
+	if srv.Username != "" {
+		if ok, _ := c.Extension("AUTH"); !ok {
+			return fmt.Errorf("email: server does not support AUTH")
+		}
+		if err := c.Auth(smtp.PlainAuth("", srv.Username, srv.Password, host)); err != nil {
+			return fmt.Errorf("email: auth: %w", err)
+		}
+	}
+
+	from, err := mail.ParseAddress(m.From)
+	if err != nil {
+		return fmt.Errorf("email: from: %w", err)
+	}
+	if err := c.Mail(from.Address); err != nil {
+		return fmt.Errorf("email: mail from: %w", err)
+	}
+	for _, to := range m.To {
+		rcpt, err := mail.ParseAddress(to)
+		if err != nil {
+			return fmt.Errorf("email: to: %w", err)
+		}
+		if err := c.Rcpt(rcpt.Address); err != nil {
+			return fmt.Errorf("email: rcpt %s: %w", rcpt.Address, err)
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return fmt.Errorf("email: data: %w", err)
+	}
+	if _, err := w.Write(build(m, time.Now())); err != nil {
+		return fmt.Errorf("email: write: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("email: data: %w", err)
+	}
+	return c.Quit()
+}
+
+// build renders m as an RFC 5322 message: a quoted-printable text body,
+// wrapped in multipart/mixed when there are attachments.
+func build(m Message, now time.Time) []byte {
+	var buf bytes.Buffer
+	header := func(k, v string) { fmt.Fprintf(&buf, "%s: %s\r\n", k, v) }
+	header("From", m.From)
+	header("To", strings.Join(m.To, ", "))
+	header("Subject", mime.QEncoding.Encode("utf-8", m.Subject))
+	header("Date", now.Format(time.RFC1123Z))
+	header("MIME-Version", "1.0")
+
+	if len(m.Attachments) == 0 {
+		header("Content-Type", "text/plain; charset=utf-8")
+		header("Content-Transfer-Encoding", "quoted-printable")
+		buf.WriteString("\r\n")
+		writeQP(&buf, m.Body)
+		return buf.Bytes()
+	}
+
+	mw := multipart.NewWriter(&buf)
+	header("Content-Type", mime.FormatMediaType("multipart/mixed", map[string]string{"boundary": mw.Boundary()}))
+	buf.WriteString("\r\n")
+
+	part, _ := mw.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {"text/plain; charset=utf-8"},
+		"Content-Transfer-Encoding": {"quoted-printable"},
+	})
+	writeQP(part, m.Body)
+
+	for _, a := range m.Attachments {
+		part, _ := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {mime.FormatMediaType("text/plain", map[string]string{"charset": "utf-8", "name": a.Name})},
+			"Content-Transfer-Encoding": {"base64"},
+			"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": a.Name})},
+		})
+		writeBase64(part, a.Data)
+	}
+	mw.Close()
+	return buf.Bytes()
+}
+
+// writeQP writes s quoted-printable encoded, with CRLF line breaks.
+func writeQP(w io.Writer, s string) {
+	qp := quotedprintable.NewWriter(w)
+	qp.Write([]byte(s))
+	qp.Close()
+}
+
+// writeBase64 writes data base64-encoded in 76-character lines.
+func writeBase64(w io.Writer, data []byte) {
+	enc := base64.StdEncoding.EncodeToString(data)
+	for len(enc) > 76 {
+		fmt.Fprintf(w, "%s\r\n", enc[:76])
+		enc = enc[76:]
+	}
+	fmt.Fprintf(w, "%s\r\n", enc)
+}
@@ -0,0 +1,349 @@
+package email
+
+import (
+	"bufio"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"io"
+	"math/big"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeServer is a minimal SMTP server that records one message per
+// connection.
+type fakeServer struct {
+	ln       net.Listener
+	tlsCfg   *tls.Config // non-nil: offer STARTTLS (or serve TLS when implicit)
+	implicit bool
+
+	mu       sync.Mutex
+	from     string
+	rcpts    []string
+	data     string
+	auth     string // decoded AUTH PLAIN response
+	startTLS bool
+}
+
+func newFakeServer(t *testing.T, tlsCfg *tls.Config, implicit bool) *fakeServer {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if implicit {
+		ln = tls.NewListener(ln, tlsCfg)
+	}
+	s := &fakeServer{ln: ln, tlsCfg: tlsCfg, implicit: implicit}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *fakeServer) addr() string { return s.ln.Addr().String() }
+
+func (s *fakeServer) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(line string) { io.WriteString(conn, line+"\r\n") }
+	reply("220 fake ESMTP")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		cmd := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+		switch cmd {
+		case "EHLO", "HELO":
+			_, isTLS := conn.(*tls.Conn)
+			reply("250-fake")
+			if s.tlsCfg != nil && !isTLS {
+				reply("250-STARTTLS")
+			}
+			reply("250 AUTH PLAIN")
+		case "STARTTLS":
+			reply("220 go ahead")
+			tc := tls.Server(conn, s.tlsCfg)
+			if tc.Handshake() != nil {
+				return
+			}
+			conn, r = tc, bufio.NewReader(tc)
+			s.mu.Lock()
+			s.startTLS = true
+			s.mu.Unlock()
+		case "AUTH":
+			parts := strings.Fields(line)
+			dec, _ := base64.StdEncoding.DecodeString(parts[len(parts)-1])
+			s.mu.Lock()
+			s.auth = string(dec)
+			s.mu.Unlock()
+			reply("235 ok")
+		case "MAIL":
+			s.mu.Lock()
+			s.from = strings.Trim(strings.TrimPrefix(line, "MAIL FROM:"), "<>")
+			s.mu.Unlock()
+			reply("250 ok")
+		case "RCPT":
+			to := strings.Trim(strings.TrimPrefix(line, "RCPT TO:"), "<>")
+			if strings.HasPrefix(to, "reject") {
+				reply("550 no such user")
+				continue
+			}
+			s.mu.Lock()
+			s.rcpts = append(s.rcpts, to)
+			s.mu.Unlock()
+			reply("250 ok")
+		case "DATA":
+			reply("354 end with .")
+			var b strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+				b.WriteString(l)
+			}
+			s.mu.Lock()
+			s.data = b.String()
+			s.mu.Unlock()
+			reply("250 queued")
+		case "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("250 ok")
+		}
+	}
+}
+
+// testTLS returns a server config with a self-signed certificate for
+// 127.0.0.1 and trusts it for the duration of the test.
+func testTLS(t *testing.T) *tls.Config {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "127.0.0.1"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+
+	orig := rootCAs
+	rootCAs = pool
+	t.Cleanup(func() { rootCAs = orig })
+	return &tls.Config{Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}}}
+}
+
+func testMessage() Message {
+	return Message{
+		From:    "notify@example.com",
+		To:      []string{"me@example.com", "ops@example.com"},
+		Subject: "webapp failed",
+		Body:    "Build failed\nexit 2",
+	}
+}
+
+func TestSendPlain(t *testing.T) {
+	srv := newFakeServer(t, nil, false)
+
+	if err := Send(Server{Addr: srv.addr()}, testMessage()); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	if srv.from != "notify@example.com" {
+		t.Errorf("from = %q", srv.from)
+	}
+	if strings.Join(srv.rcpts, ",") != "me@example.com,ops@example.com" {
+		t.Errorf("rcpts = %v", srv.rcpts)
+	}
+	msg, err := mail.ReadMessage(strings.NewReader(srv.data))
+	if err != nil {
+		t.Fatalf("parse message: %v", err)
+	}
+	if got := msg.Header.Get("Subject"); got != "webapp failed" {
+		t.Errorf("Subject = %q", got)
+	}
+	body, _ := io.ReadAll(msg.Body)
+	// The DATA terminator adds a final line break.
+	if got := strings.ReplaceAll(string(body), "\r\n", "\n"); got != "Build failed\nexit 2\n" {
+		t.Errorf("body = %q", got)
+	}
+}
+
+func TestSendStartTLSWithAuth(t *testing.T) {
+	srv := newFakeServer(t, testTLS(t), false)
+
+	err := Send(Server{Addr: srv.addr(), Username: "bot", Password: "s3cret"}, testMessage())
+	if err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	if !srv.startTLS {
+		t.Error("expected STARTTLS upgrade")
+	}
+	if srv.auth != "\x00bot\x00s3cret" {
+		t.Errorf("auth = %q", srv.auth)
+	}
+}
+
+func TestSendImplicitTLS(t *testing.T) {
+	srv := newFakeServer(t, testTLS(t), true)
+
+	err := Send(Server{Addr: srv.addr(), Username: "bot", Password: "pw", ImplicitTLS: true}, testMessage())
+	if err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	if srv.startTLS {
+		t.Error("implicit TLS should not use STARTTLS")
+	}
+	if srv.data == "" {
+		t.Error("no message received")
+	}
+}
+
+func TestSendUntrustedCertificate(t *testing.T) {
+	cfg := testTLS(t)
+	rootCAs = x509.NewCertPool() // trust nothing
+	srv := newFakeServer(t, cfg, false)
+
+	if err := Send(Server{Addr: srv.addr()}, testMessage()); err == nil || !strings.Contains(err.Error(), "starttls") {
+		t.Errorf("err = %v, want starttls failure", err)
+	}
+}
+
+// remote makes the test server count as a remote host.
+func remote(t *testing.T) {
+	orig := isLocal
+	isLocal = func(string) bool { return false }
+	t.Cleanup(func() { isLocal = orig })
+}
+
+func TestSendRequiresStartTLS(t *testing.T) {
+	remote(t)
+	srv := newFakeServer(t, nil, false)
+
+	err := Send(Server{Addr: srv.addr()}, testMessage())
+	if err == nil || !strings.Contains(err.Error(), "does not offer STARTTLS") {
+		t.Fatalf("err = %v, want STARTTLS required", err)
+	}
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	if srv.data != "" {
+		t.Error("message sent in plaintext")
+	}
+}
+
+func TestSendNoTLS(t *testing.T) {
+	remote(t)
+	srv := newFakeServer(t, testTLS(t), false)
+
+	if err := Send(Server{Addr: srv.addr(), NoTLS: true}, testMessage()); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	if srv.startTLS || srv.data == "" {
+		t.Errorf("startTLS = %v, data = %q; want a plaintext delivery", srv.startTLS, srv.data)
+	}
+}
+
+func TestSendRejectedRecipient(t *testing.T) {
+	srv := newFakeServer(t, nil, false)
+	m := testMessage()
+	m.To = []string{"reject@example.com"}
+
+	if err := Send(Server{Addr: srv.addr()}, m); err == nil || !strings.Contains(err.Error(), "rcpt reject@example.com") {
+		t.Errorf("err = %v, want rcpt error", err)
+	}
+}
+
+func TestSendBadAddress(t *testing.T) {
+	if err := Send(Server{Addr: "no-port"}, testMessage()); err == nil {
+		t.Fatal("expected error for address without port")
+	}
+	if err := Send(Server{Addr: "127.0.0.1:1"}, testMessage()); err == nil {
+		t.Fatal("expected error for unreachable server")
+	}
+}
+
+func TestBuildWithAttachment(t *testing.T) {
+	m := testMessage()
+	m.Subject = "Grüße"
+	m.Attachments = []Attachment{{Name: "output.txt", Data: []byte("line 1\nline 2\n")}}
+
+	msg, err := mail.ReadMessage(strings.NewReader(string(build(m, time.Now()))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dec := new(mime.WordDecoder)
+	if subj, _ := dec.DecodeHeader(msg.Header.Get("Subject")); subj != "Grüße" {
+		t.Errorf("Subject = %q", subj)
+	}
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/mixed" {
+		t.Fatalf("Content-Type = %q (%v)", mediaType, err)
+	}
+
+	mr := multipart.NewReader(msg.Body, params["boundary"])
+	body, err := mr.NextPart()
+	if err != nil {
+		t.Fatal(err)
+	}
+	text, _ := io.ReadAll(body) // multipart decodes quoted-printable
+	if got := strings.ReplaceAll(string(text), "\r\n", "\n"); got != "Build failed\nexit 2" {
+		t.Errorf("body = %q", got)
+	}
+
+	att, err := mr.NextPart()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if att.FileName() != "output.txt" {
+		t.Errorf("filename = %q", att.FileName())
+	}
+	raw, _ := io.ReadAll(att)
+	data, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(string(raw), "\r\n", ""))
+	if err != nil || string(data) != "line 1\nline 2\n" {
+		t.Errorf("attachment = %q (%v)", data, err)
+	}
+}
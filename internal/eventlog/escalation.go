@@ -0,0 +1,155 @@
+package eventlog
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// Escalation states. An escalation is opened when an action with an
+// "escalate" block fires, and stays open until it is acknowledged or its
+// re-fire limit is reached. Each transition is one event log record.
+const (
+	EscalationOpen  = "open"
+	EscalationAcked = "acked"
+	EscalationEnded = "ended"
+)
+
+// openEscalationAge is how far back OpenEscalations looks. An escalation
+// whose process was killed is never closed in the log; after this long it
+// is no longer listed.
+const openEscalationAge = 24 * time.Hour
+
+// Escalation is the current state of one escalation, folded from its
+// event log records.
+type Escalation struct {
+	ID      string
+	Profile string
+	Action  string
+	State   string    // EscalationOpen, EscalationAcked, or EscalationEnded
+	Opened  time.Time // time of the "open" record
+	Updated time.Time // time of the latest record
+}
+
+// escalationRecord is one escalation state change read from the log.
+type escalationRecord struct {
+	time            time.Time
+	id, state       string
+	profile, action string
+}
+
+// escalationExtra is the events.extra value (and flat-file suffix) of an
+// escalation record.
+func escalationExtra(id, state string) string {
+	return fmt.Sprintf("escalation=%s  state=%s", id, state)
+}
+
+// parseEscalationLine parses a summary line written by LogEscalation.
+func parseEscalationLine(line string) (escalationRecord, bool) {
+	id := extractField(line, "escalation")
+	if id == "" || strings.Contains(line, "step[") {
+		return escalationRecord{}, false
+	}
+	ts, ok := ExtractTimestamp(line)
+	if !ok {
+		return escalationRecord{}, false
+	}
+	return escalationRecord{
+		time:    ts,
+		id:      id,
+		state:   extractField(line, "state"),
+		profile: extractField(line, "profile"),
+		action:  extractField(line, "action"),
+	}, true
+}
+
+// foldEscalations reduces records (oldest first) to one Escalation per ID,
+// in the order the escalations were opened.
+func foldEscalations(recs []escalationRecord) []Escalation {
+	var out []Escalation
+	idx := map[string]int{}
+	for _, r := range recs {
+		i, ok := idx[r.id]
+		if !ok {
+			i = len(out)
+			idx[r.id] = i
+			out = append(out, Escalation{ID: r.id, Profile: r.profile, Action: r.action, Opened: r.time})
+		}
+		e := &out[i]
+		if r.state == EscalationOpen {
+			e.Opened = r.time
+		}
+		// A later "ended" doesn't undo an acknowledgement.
+		if !(e.State == EscalationAcked && r.state == EscalationEnded) {
+			e.State = r.state
+		}
+		e.Updated = r.time
+	}
+	return out
+}
+
+// LogEscalation records an escalation state change. Unlike the other Log
+// functions this is not gated on the "log" option: the event log is where
+// acknowledgements are looked up. Errors are printed to stderr.
+func LogEscalation(id, profile, action, state string) {
+	if err := Default.LogEscalation(id, profile, action, state); err != nil {
+		fmt.Fprintf(os.Stderr, "eventlog: %v\n", err)
+	}
+}
+
+// Escalations returns the escalations with records at or after since.
+func Escalations(since time.Time) ([]Escalation, error) {
+	return Default.Escalations(since)
+}
+
+// OpenEscalations returns the recently opened escalations that are still
+// waiting for acknowledgement, oldest first.
+func OpenEscalations() ([]Escalation, error) {
+	all, err := Default.Escalations(time.Now().Add(-openEscalationAge))
+	if err != nil {
+		return nil, err
+	}
+	var open []Escalation
+	for _, e := range all {
+		if e.State == EscalationOpen {
+			open = append(open, e)
+		}
+	}
+	return open, nil
+}
+
+// FindEscalation returns the escalation with the given ID, folded from
+// its records at or after since. A zero since scans the whole log.
+func FindEscalation(id string, since time.Time) (Escalation, bool, error) {
+	all, err := Default.Escalations(since)
+	if err != nil {
+		return Escalation{}, false, err
+	}
+	for _, e := range all {
+		if e.ID == id {
+			return e, true, nil
+		}
+	}
+	return Escalation{}, false, nil
+}
+
+// Ack acknowledges the escalation with the given ID, which stops its
+// re-fires at the next interval.
+func Ack(id string) (Escalation, error) {
+	e, ok, err := FindEscalation(id, time.Time{})
+	if err != nil {
+		return Escalation{}, err
+	}
+	if !ok {
+		return Escalation{}, fmt.Errorf("no escalation %q", id)
+	}
+	if e.State == EscalationAcked {
+		return e, fmt.Errorf("escalation %s already acknowledged", id)
+	}
+	if err := Default.LogEscalation(id, e.Profile, e.Action, EscalationAcked); err != nil {
+		return e, err
+	}
+	e.State = EscalationAcked
+	return e, nil
+}
@@ -0,0 +1,145 @@
+package eventlog
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFileStoreLogEscalation(t *testing.T) {
+	s := tempStore(t)
+	if err := s.LogEscalation("ab12cd34", "boss", "ready", EscalationOpen); err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := s.ReadContent()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(content, "profile=boss  action=ready  escalation=ab12cd34  state=open") {
+		t.Errorf("unexpected content: %q", content)
+	}
+
+	got, err := s.Escalations(time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].ID != "ab12cd34" || got[0].Profile != "boss" ||
+		got[0].Action != "ready" || got[0].State != EscalationOpen {
+		t.Errorf("Escalations() = %+v", got)
+	}
+}
+
+func TestSQLiteStoreLogEscalation(t *testing.T) {
+	s := tempSQLiteStore(t)
+	s.LogEscalation("ab12cd34", "boss", "ready", EscalationOpen)
+	s.LogEscalation("ab12cd34", "boss", "ready", EscalationAcked)
+
+	got, err := s.Escalations(time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].State != EscalationAcked || got[0].Profile != "boss" {
+		t.Errorf("Escalations() = %+v", got)
+	}
+
+	content, err := s.ReadContent()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(content, "escalation=ab12cd34  state=acked") {
+		t.Errorf("ReadContent missing escalation record: %q", content)
+	}
+}
+
+func TestEscalationsSince(t *testing.T) {
+	s := tempStore(t)
+	s.LogEscalation("old", "boss", "ready", EscalationOpen)
+
+	got, err := s.Escalations(time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 0 {
+		t.Errorf("Escalations(future) = %+v, want none", got)
+	}
+}
+
+func TestFoldEscalations(t *testing.T) {
+	t0 := time.Date(2026, 10, 17, 9, 0, 0, 0, time.Local)
+	recs := []escalationRecord{
+		{time: t0, id: "a", state: EscalationOpen, profile: "p", action: "x"},
+		{time: t0.Add(time.Minute), id: "b", state: EscalationOpen, profile: "p", action: "y"},
+		{time: t0.Add(2 * time.Minute), id: "a", state: EscalationAcked, profile: "p", action: "x"},
+		{time: t0.Add(3 * time.Minute), id: "a", state: EscalationEnded, profile: "p", action: "x"},
+		{time: t0.Add(4 * time.Minute), id: "b", state: EscalationEnded, profile: "p", action: "y"},
+	}
+	got := foldEscalations(recs)
+	if len(got) != 2 {
+		t.Fatalf("len = %d, want 2", len(got))
+	}
+	if got[0].ID != "a" || got[0].State != EscalationAcked {
+		t.Errorf("a = %+v, want acked (ended must not undo an ack)", got[0])
+	}
+	if !got[0].Opened.Equal(t0) || !got[0].Updated.Equal(t0.Add(3*time.Minute)) {
+		t.Errorf("a times = %v..%v", got[0].Opened, got[0].Updated)
+	}
+	if got[1].ID != "b" || got[1].State != EscalationEnded {
+		t.Errorf("b = %+v, want ended", got[1])
+	}
+}
+
+func TestAck(t *testing.T) {
+	orig := Default
+	Default = tempStore(t)
+	defer func() { Default = orig }()
+
+	LogEscalation("ab12cd34", "boss", "ready", EscalationOpen)
+	LogEscalation("ffff0000", "boss", "error", EscalationOpen)
+
+	open, err := OpenEscalations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(open) != 2 {
+		t.Fatalf("OpenEscalations() = %+v, want 2", open)
+	}
+
+	e, err := Ack("ab12cd34")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.State != EscalationAcked || e.Action != "ready" {
+		t.Errorf("Ack() = %+v", e)
+	}
+	if _, err := Ack("ab12cd34"); err == nil || !strings.Contains(err.Error(), "already acknowledged") {
+		t.Errorf("second Ack err = %v", err)
+	}
+	if _, err := Ack("nope"); err == nil || !strings.Contains(err.Error(), "no escalation") {
+		t.Errorf("unknown Ack err = %v", err)
+	}
+
+	open, _ = OpenEscalations()
+	if len(open) != 1 || open[0].ID != "ffff0000" {
+		t.Errorf("OpenEscalations() after ack = %+v", open)
+	}
+	if _, ok, _ := FindEscalation("ab12cd34", time.Now().Add(time.Hour)); ok {
+		t.Error("FindEscalation(future since) found records written before since")
+	}
+}
+
+func TestEscalationRecordsNotEntries(t *testing.T) {
+	s := tempStore(t)
+	s.LogEscalation("ab12cd34", "boss", "ready", EscalationOpen)
+	s.LogCooldown("boss", "ready", 30)
+
+	entries, err := s.Entries(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		if e.Kind == KindExecution {
+			t.Errorf("escalation record parsed as execution: %+v", e)
+		}
+	}
+}
@@ -63,10 +63,11 @@ func Close() {
 // --- Write wrappers (best-effort, matching existing behavior) ---
 
 // Log appends to the log file a summary line followed by one detail line
-// per step. Errors are printed to stderr but never returned — logging is
-// best-effort.
-func Log(action string, steps []config.Step, afk bool, vars tmpl.Vars, desktop *int) {
-	if err := Default.Log(action, steps, afk, vars, desktop); err != nil {
+// per step. results holds the delivery outcome for each step (same order
+// as steps); it may be nil when outcomes are unknown. Errors are printed
+// to stderr but never returned — logging is best-effort.
+func Log(action string, steps []config.Step, results []StepResult, afk bool, vars tmpl.Vars, desktop *int) {
+	if err := Default.Log(action, steps, results, afk, vars, desktop); err != nil {
 		fmt.Fprintf(os.Stderr, "eventlog: %v\n", err)
 	}
 	autoClean()
@@ -90,6 +91,26 @@ func LogCooldownRecord(profile, action string, cooldownSeconds int) {
 	autoClean()
 }
 
+// LogDedup appends a single line noting that an invocation was counted as
+// the count-th repeat within the cooldown window (dedup mode) instead of
+// notifying. Best-effort, same as Log.
+func LogDedup(profile, action string, cooldownSeconds, count int) {
+	if err := Default.LogDedup(profile, action, cooldownSeconds, count); err != nil {
+		fmt.Fprintf(os.Stderr, "eventlog: %v\n", err)
+	}
+	autoClean()
+}
+
+// LogBatched appends a single line noting that an invocation joined a
+// batch window as its count-th item, to be fired by the process that
+// opened it. Best-effort, same as Log.
+func LogBatched(profile, action string, count int) {
+	if err := Default.LogBatched(profile, action, count); err != nil {
+		fmt.Fprintf(os.Stderr, "eventlog: %v\n", err)
+	}
+	autoClean()
+}
+
 // LogSilent appends a single line noting that an invocation was skipped
 // due to silent mode. Best-effort, same as Log.
 func LogSilent(profile, action string) {
@@ -136,51 +157,14 @@ var LogPath = func() string {
 	return filepath.Join(paths.DataDir(), paths.LogFileName)
 }
 
-// StepSummary returns a human-readable description of what a step does.
-// When vars is non-nil, template variables are expanded (logging mode).
+// StepSummary returns a human-readable description of what a step does,
+// built by the step type's handler. When vars is non-nil, template variables are expanded (logging mode).
 // When vars is nil, raw values are shown (dry-run mode).
 // When/volume suffixes are always appended if present.
 func StepSummary(s config.Step, vars *tmpl.Vars) string {
-	// expand returns the expanded string if vars is set, otherwise the raw value.
-	expand := func(text string) string {
-		if vars != nil {
-			return tmpl.Expand(text, *vars)
-		}
-		return text
-	}
-
 	var parts []string
-	switch s.Type {
-	case "sound":
-		parts = append(parts, fmt.Sprintf("sound=%s", s.Sound))
-	case "say":
-		parts = append(parts, fmt.Sprintf("text=%q", expand(s.Text)))
-	case "toast":
-		title := s.Title
-		if title == "" && vars != nil {
-			title = vars.Profile
-		}
-		if title != "" {
-			parts = append(parts, fmt.Sprintf("title=%q", expand(title)))
-		}
-		parts = append(parts, fmt.Sprintf("message=%q", expand(s.Message)))
-	case "discord", "discord_voice", "slack", "telegram", "telegram_audio", "telegram_voice":
-		parts = append(parts, fmt.Sprintf("text=%q", expand(s.Text)))
-	case "webhook":
-		parts = append(parts, fmt.Sprintf("url=%s", s.URL))
-		parts = append(parts, fmt.Sprintf("text=%q", expand(s.Text)))
-	case "plugin":
-		parts = append(parts, fmt.Sprintf("command=%q", s.Command))
-		if s.Text != "" {
-			parts = append(parts, fmt.Sprintf("text=%q", expand(s.Text)))
-		}
-		if s.Timeout != nil {
-			parts = append(parts, fmt.Sprintf("timeout=%d", *s.Timeout))
-		}
-	case "mqtt":
-		parts = append(parts, fmt.Sprintf("broker=%s", s.Broker))
-		parts = append(parts, fmt.Sprintf("topic=%s", s.Topic))
-		parts = append(parts, fmt.Sprintf("text=%q", expand(s.Text)))
+	if h, ok := config.LookupStep(s.Type); ok {
+		parts = h.Summary(s, vars)
 	}
 	if s.When != "" {
 		parts = append(parts, fmt.Sprintf("when=%s", s.When))
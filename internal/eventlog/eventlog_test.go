@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/Mavwarf/notify/internal/config"
+	_ "github.com/Mavwarf/notify/internal/steps" // register built-in step handlers
 	"github.com/Mavwarf/notify/internal/tmpl"
 )
 
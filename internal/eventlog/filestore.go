@@ -48,8 +48,9 @@ func (f *FileStore) writeLog(fn func(file *os.File, ts string)) error {
 
 // Log appends an execution event to the log file: a summary line with
 // profile, action, step types, and metadata, followed by one detail line
-// per step, terminated by a blank line to separate blocks.
-func (f *FileStore) Log(action string, steps []config.Step, afk bool, vars tmpl.Vars, desktop *int) error {
+// per step (with its delivery result, if known), terminated by a blank
+// line to separate blocks.
+func (f *FileStore) Log(action string, steps []config.Step, results []StepResult, afk bool, vars tmpl.Vars, desktop *int) error {
 	return f.writeLog(func(file *os.File, ts string) {
 		types := make([]string, len(steps))
 		for i, s := range steps {
@@ -61,6 +62,9 @@ func (f *FileStore) Log(action string, steps []config.Step, afk bool, vars tmpl.
 		if desktop != nil {
 			summary += fmt.Sprintf("  desktop=%d", *desktop)
 		}
+		if vars.RepeatCount != "" {
+			summary += fmt.Sprintf("  repeat=%s", vars.RepeatCount)
+		}
 		if vars.ClaudeHook != "" {
 			summary += fmt.Sprintf("  claude_hook=%s", vars.ClaudeHook)
 		}
@@ -71,6 +75,9 @@ func (f *FileStore) Log(action string, steps []config.Step, afk bool, vars tmpl.
 
 		for i, s := range steps {
 			detail := StepSummary(s, &vars)
+			if i < len(results) {
+				detail += resultSuffix(results[i])
+			}
 			fmt.Fprintf(file, "%s    step[%d] %s  %s\n", ts, i+1, s.Type, detail)
 		}
 
@@ -100,6 +107,32 @@ func (f *FileStore) LogCooldownRecord(profile, action string, seconds int) error
 	})
 }
 
+// LogDedup records that an invocation was counted as a repeat within the
+// cooldown window. Forms its own block, like LogCooldown.
+func (f *FileStore) LogDedup(profile, action string, seconds, count int) error {
+	return f.writeLog(func(file *os.File, ts string) {
+		fmt.Fprintf(file, "%s  profile=%s  action=%s  cooldown=deduped (%ds)  repeat=%d\n\n",
+			ts, profile, action, seconds, count)
+	})
+}
+
+// LogBatched records that an invocation joined another process's batch
+// window. Forms its own block, like LogCooldown.
+func (f *FileStore) LogBatched(profile, action string, count int) error {
+	return f.writeLog(func(file *os.File, ts string) {
+		fmt.Fprintf(file, "%s  profile=%s  action=%s  batch=joined (%d so far)\n\n",
+			ts, profile, action, count)
+	})
+}
+
+// LogEscalation records an escalation state change (open, acked, ended).
+func (f *FileStore) LogEscalation(id, profile, action, state string) error {
+	return f.writeLog(func(file *os.File, ts string) {
+		fmt.Fprintf(file, "%s  profile=%s  action=%s  %s\n\n",
+			ts, profile, action, escalationExtra(id, state))
+	})
+}
+
 // LogSilent records that an invocation was suppressed by silent mode.
 func (f *FileStore) LogSilent(profile, action string) error {
 	return f.writeLog(func(file *os.File, ts string) {
@@ -166,6 +199,25 @@ func (f *FileStore) EntriesSince(cutoff time.Time) ([]Entry, error) {
 	return filtered, nil
 }
 
+// Escalations scans the log for escalation records at or after since and
+// folds them into escalation states.
+func (f *FileStore) Escalations(since time.Time) ([]Escalation, error) {
+	data, err := os.ReadFile(f.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var recs []escalationRecord
+	for _, line := range strings.Split(string(data), "\n") {
+		if r, ok := parseEscalationLine(line); ok && !r.time.Before(since) {
+			recs = append(recs, r)
+		}
+	}
+	return foldEscalations(recs), nil
+}
+
 // VoiceLines scans the log for TTS step detail lines and returns unique
 // texts with their usage counts. Pass days=0 for all history.
 func (f *FileStore) VoiceLines(days int) ([]VoiceLine, error) {
@@ -24,7 +24,7 @@ func TestFileStoreLogAndEntries(t *testing.T) {
 	vars := tmpl.Vars{Profile: "test"}
 	steps := []config.Step{{Type: "sound", Sound: "blip"}, {Type: "say", Text: "hello"}}
 
-	if err := s.Log("ready", steps, false, vars, nil); err != nil {
+	if err := s.Log("ready", steps, nil, false, vars, nil); err != nil {
 		t.Fatal(err)
 	}
 
@@ -59,6 +59,38 @@ func TestFileStoreLogCooldown(t *testing.T) {
 	}
 }
 
+func TestFileStoreLogDedup(t *testing.T) {
+	s := tempStore(t)
+
+	if err := s.LogDedup("p", "a", 30, 3); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, _ := s.Entries(0)
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 entry, got %d", len(entries))
+	}
+	if entries[0].Kind != KindDeduped || entries[0].Repeat != 3 {
+		t.Fatalf("got kind %d repeat %d, want KindDeduped repeat 3", entries[0].Kind, entries[0].Repeat)
+	}
+}
+
+func TestFileStoreLogBatched(t *testing.T) {
+	s := tempStore(t)
+
+	if err := s.LogBatched("p", "a", 2); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, _ := s.Entries(0)
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 entry, got %d", len(entries))
+	}
+	if entries[0].Kind != KindBatched || KindString(entries[0].Kind) != "batched" {
+		t.Fatalf("expected KindBatched, got %d", entries[0].Kind)
+	}
+}
+
 func TestFileStoreLogSilent(t *testing.T) {
 	s := tempStore(t)
 
@@ -103,7 +135,7 @@ func TestFileStoreLogDesktop(t *testing.T) {
 	vars := tmpl.Vars{Profile: "test"}
 	steps := []config.Step{{Type: "sound", Sound: "blip"}}
 
-	if err := s.Log("ready", steps, false, vars, &d); err != nil {
+	if err := s.Log("ready", steps, nil, false, vars, &d); err != nil {
 		t.Fatal(err)
 	}
 
@@ -423,3 +455,35 @@ func TestFileStoreRemoveProfileClearsFile(t *testing.T) {
 		t.Fatal("expected file to be removed")
 	}
 }
+
+func TestFileStoreLogStepResults(t *testing.T) {
+	s := tempStore(t)
+	vars := tmpl.Vars{Profile: "test"}
+	steps := []config.Step{{Type: "sound", Sound: "blip"}, {Type: "telegram", Text: "status=fake"}}
+	results := []StepResult{
+		{Status: "ok", Attempts: 1, Latency: 5 * time.Millisecond},
+		{Status: "queued", Attempts: 2, Latency: 2100 * time.Millisecond, Error: "telegram: timeout"},
+	}
+
+	if err := s.Log("ready", steps, results, false, vars, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	content, _ := s.ReadContent()
+	if !strings.Contains(content, `status=queued  attempts=2  latency=2.1s  error="telegram: timeout"`) {
+		t.Errorf("content missing result suffix:\n%s", content)
+	}
+
+	entries, _ := s.Entries(0)
+	if len(entries) != 1 || len(entries[0].Steps) != 2 {
+		t.Fatalf("entries = %+v, want 1 entry with 2 steps", entries)
+	}
+	got := entries[0].Steps[1]
+	want := StepResult{Type: "telegram", Status: "queued", Error: "telegram: timeout", Latency: 2100 * time.Millisecond, Attempts: 2}
+	if got != want {
+		t.Errorf("Steps[1] = %+v, want %+v", got, want)
+	}
+	if failed := entries[0].Failed(); len(failed) != 1 || failed[0].Type != "telegram" {
+		t.Errorf("Failed() = %+v, want the telegram step", failed)
+	}
+}
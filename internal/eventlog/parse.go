@@ -1,24 +1,31 @@
 package eventlog
 
 import (
+	"fmt"
 	"sort"
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/Mavwarf/notify/internal/config"
 )
 
 // EntryKind classifies a log entry.
 type EntryKind int
 
-// Entry classification constants. KindExecution and KindCooldown/KindSilent
-// represent "real" events that appear in history summaries. KindOther covers
-// informational records (cooldown=recorded, silent=enabled/disabled) that are
-// stored for audit but excluded from aggregation counts.
+// Entry classification constants. KindExecution and
+// KindCooldown/KindSilent/KindDeduped/KindBatched represent "real" events that appear in
+// history summaries. KindOther covers informational records
+// (cooldown=recorded, silent=enabled/disabled) that are stored for audit but
+// excluded from aggregation counts. The values are stored in SQLite, so new
+// kinds are appended.
 const (
 	KindExecution EntryKind = iota // A notification pipeline was fully executed
 	KindCooldown                   // Invocation was skipped due to cooldown
 	KindSilent                     // Invocation was suppressed by silent mode
 	KindOther                      // Informational record (not counted in summaries)
+	KindDeduped                    // Invocation was counted toward a repeat follow-up (dedup mode)
+	KindBatched                    // Invocation joined a batch window another process fires
 )
 
 // Entry is a single parsed log entry.
@@ -29,6 +36,31 @@ type Entry struct {
 	Kind           EntryKind
 	ClaudeHook     string // from claude_hook= field (optional)
 	ClaudeMessage  string // from claude_message= field (optional)
+	Repeat         int    // KindDeduped: repeats so far in the window; KindExecution: repeats a follow-up reported
+	Steps          []StepResult // per-step delivery results (executions only)
+}
+
+// StepResult records the delivery outcome of one executed step. Status is
+// "ok", "failed", "queued" (written to the outbox), "skipped", or
+// "fallback" (failed, but its fallback steps delivered); it is "" for
+// entries logged before results were recorded.
+type StepResult struct {
+	Type     string
+	Status   string
+	Error    string
+	Latency  time.Duration
+	Attempts int
+}
+
+// Failed returns the step results that were not delivered.
+func (e Entry) Failed() []StepResult {
+	var out []StepResult
+	for _, r := range e.Steps {
+		if r.Status == "failed" || r.Status == "queued" {
+			out = append(out, r)
+		}
+	}
+	return out
 }
 
 // DaySummary holds execution and skip counts for one profile/action pair.
@@ -48,8 +80,9 @@ type DayGroup struct {
 // ParseEntries splits log content on blank lines and parses summary lines
 // into entries. Each block may contain multiple summary lines (e.g. a
 // cooldown=recorded line followed by an execution line) plus step detail
-// lines (indented with "step["). Step detail lines are skipped. Each
-// summary line produces one Entry. Malformed lines are silently skipped.
+// lines (indented with "step["). Step detail lines are attached as
+// StepResults to the execution entry they follow. Each summary line
+// produces one Entry. Malformed lines are silently skipped.
 func ParseEntries(content string) []Entry {
 	content = strings.TrimRight(content, "\n\r ")
 	if content == "" {
@@ -58,9 +91,19 @@ func ParseEntries(content string) []Entry {
 
 	var entries []Entry
 	for _, block := range SplitBlocks(content) {
+		lastExec := -1 // index into entries of this block's execution entry
 		for _, line := range strings.Split(block, "\n") {
-			// Skip step detail lines (indented, contain "step[").
+			// Step detail lines (indented, contain "step[") belong to the
+			// preceding execution entry.
 			if strings.Contains(line, "step[") {
+				if lastExec >= 0 {
+					_, stepType, detail, _ := parseStepLine(line)
+					if stepType != "" {
+						_, r := splitResult(detail)
+						r.Type = stepType
+						entries[lastExec].Steps = append(entries[lastExec].Steps, r)
+					}
+				}
 				continue
 			}
 
@@ -80,6 +123,8 @@ func ParseEntries(content string) []Entry {
 			// Classify by looking for distinguishing fields in the summary line:
 			//   steps=...           -> KindExecution (notification was sent)
 			//   cooldown=skipped    -> KindCooldown  (blocked by cooldown timer)
+			//   cooldown=deduped    -> KindDeduped   (counted toward a repeat follow-up)
+			//   batch=joined        -> KindBatched   (part of another process's batch)
 			//   silent=skipped      -> KindSilent    (blocked by silent mode)
 			//   anything else       -> KindOther     (cooldown=recorded, etc.)
 			kind := KindOther
@@ -87,10 +132,17 @@ func ParseEntries(content string) []Entry {
 				kind = KindExecution
 			} else if extractField(line, "cooldown") == "skipped" {
 				kind = KindCooldown
+			} else if extractField(line, "cooldown") == "deduped" {
+				kind = KindDeduped
+			} else if extractField(line, "batch") == "joined" {
+				kind = KindBatched
 			} else if extractField(line, "silent") == "skipped" {
 				kind = KindSilent
 			}
 
+			if kind == KindExecution {
+				lastExec = len(entries)
+			}
 			entries = append(entries, Entry{
 				Time:          ts,
 				Profile:       profile,
@@ -98,6 +150,7 @@ func ParseEntries(content string) []Entry {
 				Kind:          kind,
 				ClaudeHook:    extractField(line, "claude_hook"),
 				ClaudeMessage: extractQuotedField(line, "claude_message"),
+				Repeat:        atoiField(line, "repeat"),
 			})
 		}
 	}
@@ -228,6 +281,38 @@ func extractQuotedField(line, key string) string {
 	return extractQuoted(line[idx+len(prefix):])
 }
 
+// resultSuffix formats r as the trailing fields of a step detail line.
+// Returns "" when no result was recorded.
+func resultSuffix(r StepResult) string {
+	if r.Status == "" {
+		return ""
+	}
+	s := fmt.Sprintf("  status=%s  attempts=%d  latency=%s",
+		r.Status, r.Attempts, r.Latency.Round(time.Millisecond))
+	if r.Error != "" {
+		s += fmt.Sprintf("  error=%q", r.Error)
+	}
+	return s
+}
+
+// splitResult separates the fields appended by resultSuffix from a step
+// detail string. The last "  status=" occurrence is used so quoted step
+// text containing the same characters can't be mistaken for the suffix.
+func splitResult(detail string) (string, StepResult) {
+	idx := strings.LastIndex(detail, "  status=")
+	if idx < 0 {
+		return detail, StepResult{}
+	}
+	tail := detail[idx:]
+	r := StepResult{
+		Status: extractField(tail, "status"),
+		Error:  extractQuotedField(tail, "error"),
+	}
+	r.Attempts, _ = strconv.Atoi(extractField(tail, "attempts"))
+	r.Latency, _ = time.ParseDuration(extractField(tail, "latency"))
+	return detail[:idx], r
+}
+
 // KindString returns a human-readable string for an EntryKind.
 func KindString(k EntryKind) string {
 	switch k {
@@ -237,11 +322,21 @@ func KindString(k EntryKind) string {
 		return "cooldown"
 	case KindSilent:
 		return "silent"
+	case KindDeduped:
+		return "deduped"
+	case KindBatched:
+		return "batched"
 	default:
 		return "other"
 	}
 }
 
+// atoiField returns the integer value of key in the line, or 0.
+func atoiField(line, key string) int {
+	n, _ := strconv.Atoi(extractField(line, key))
+	return n
+}
+
 // hasField returns true if "key=" appears in the line.
 func hasField(line, key string) bool {
 	return extractField(line, key) != ""
@@ -277,16 +372,31 @@ type VoiceLine struct {
 	Count int
 }
 
-// voiceStepSuffixes lists the log patterns for step types that use TTS.
-// Each is the string after "step[N]" and before the text= field.
-// These must match the format strings used in StepSummary (eventlog.go) and
-// the Log methods that write step detail lines -- if the log format changes,
-// these suffixes must be updated in lockstep.
-var voiceStepSuffixes = []string{
-	"] say  text=",
-	"] discord_voice  text=",
-	"] telegram_audio  text=",
-	"] telegram_voice  text=",
+// voiceStep reports whether a step type carries TTS voice text.
+func voiceStep(typ string) bool {
+	h, ok := config.LookupStep(typ)
+	return ok && h.UsesVoice()
+}
+
+// voiceStepSuffix returns the log pattern for a step type that uses TTS:
+// the string after "step[N]" and before the text= field. It must match
+// the format used by the Log methods that write step detail lines and the
+// handler summaries behind StepSummary -- if the log format changes, this
+// must be updated in lockstep.
+func voiceStepSuffix(typ string) string {
+	return "] " + typ + "  text="
+}
+
+// voiceStepSuffixes returns voiceStepSuffix for every registered step
+// type that uses TTS.
+func voiceStepSuffixes() []string {
+	var out []string
+	for _, typ := range config.StepTypes() {
+		if voiceStep(typ) {
+			out = append(out, voiceStepSuffix(typ))
+		}
+	}
+	return out
 }
 
 // ParseVoiceLines scans log content for TTS step detail lines and returns
@@ -297,12 +407,13 @@ func ParseVoiceLines(content string) []VoiceLine {
 		return nil
 	}
 
+	suffixes := voiceStepSuffixes()
 	counts := map[string]int{}
 	for _, line := range strings.Split(content, "\n") {
 		// Match step detail lines for any voice-capable step type.
 		var raw string
 		matched := false
-		for _, suffix := range voiceStepSuffixes {
+		for _, suffix := range suffixes {
 			idx := strings.Index(line, suffix)
 			if idx >= 0 && strings.Contains(line[:idx], "step[") {
 				raw = line[idx+len(suffix):]
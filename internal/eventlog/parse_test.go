@@ -37,6 +37,22 @@ func TestParseEntries_CooldownSkip(t *testing.T) {
 	}
 }
 
+func TestParseEntries_Deduped(t *testing.T) {
+	content := "2026-02-22T10:05:00+01:00  profile=default  action=ready  cooldown=deduped (30s)  repeat=2\n\n" +
+		"2026-02-22T10:05:30+01:00  profile=default  action=ready  steps=toast  afk=false  repeat=2\n"
+
+	entries := ParseEntries(content)
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+	if entries[0].Kind != KindDeduped || entries[0].Repeat != 2 {
+		t.Errorf("entry 0 = kind %d repeat %d, want KindDeduped repeat 2", entries[0].Kind, entries[0].Repeat)
+	}
+	if entries[1].Kind != KindExecution || entries[1].Repeat != 2 {
+		t.Errorf("entry 1 = kind %d repeat %d, want KindExecution repeat 2", entries[1].Kind, entries[1].Repeat)
+	}
+}
+
 func TestParseEntries_SilentSkip(t *testing.T) {
 	content := "2026-02-22T10:10:00+01:00  profile=default  action=ready  silent=skipped\n"
 
@@ -386,3 +402,19 @@ func TestExtractField(t *testing.T) {
 		}
 	}
 }
+
+func TestSplitResult(t *testing.T) {
+	detail, r := splitResult(`text="a  status=x"  status=failed  attempts=2  latency=30ms  error="boom"`)
+	if detail != `text="a  status=x"` {
+		t.Errorf("detail = %q", detail)
+	}
+	want := StepResult{Status: "failed", Error: "boom", Latency: 30 * time.Millisecond, Attempts: 2}
+	if r != want {
+		t.Errorf("result = %+v, want %+v", r, want)
+	}
+
+	detail, r = splitResult(`sound=blip`)
+	if detail != "sound=blip" || r != (StepResult{}) {
+		t.Errorf("splitResult(no suffix) = %q, %+v", detail, r)
+	}
+}
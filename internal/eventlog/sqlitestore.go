@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
@@ -62,7 +63,8 @@ CREATE TABLE IF NOT EXISTS events (
     claude_hook     TEXT    NOT NULL DEFAULT '',
     claude_message  TEXT    NOT NULL DEFAULT '',
     steps_csv       TEXT    NOT NULL DEFAULT '',
-    extra           TEXT    NOT NULL DEFAULT ''
+    extra           TEXT    NOT NULL DEFAULT '',
+    repeat          INTEGER NOT NULL DEFAULT 0
 );
 
 CREATE TABLE IF NOT EXISTS step_details (
@@ -71,7 +73,11 @@ CREATE TABLE IF NOT EXISTS step_details (
     step_num   INTEGER NOT NULL,
     step_type  TEXT    NOT NULL,
     detail     TEXT    NOT NULL,
-    voice_text TEXT
+    voice_text TEXT,
+    status     TEXT    NOT NULL DEFAULT '',
+    error      TEXT    NOT NULL DEFAULT '',
+    latency_ms INTEGER NOT NULL DEFAULT 0,
+    attempts   INTEGER NOT NULL DEFAULT 0
 );
 
 CREATE INDEX IF NOT EXISTS idx_events_timestamp ON events(timestamp DESC);
@@ -84,6 +90,15 @@ CREATE INDEX IF NOT EXISTS idx_step_voice       ON step_details(voice_text)
 		return nil, fmt.Errorf("sqlite schema: %w", err)
 	}
 
+	if err := addColumns(db, "step_details", stepResultColumns); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("sqlite schema: %w", err)
+	}
+	if err := addColumns(db, "events", eventColumns); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("sqlite schema: %w", err)
+	}
+
 	s := &SQLiteStore{db: db, path: path}
 
 	// One-time migration from flat file.
@@ -97,21 +112,68 @@ CREATE INDEX IF NOT EXISTS idx_step_voice       ON step_details(voice_text)
 	return s, nil
 }
 
+// column is a column added to a table after its first release.
+type column struct{ name, def string }
+
+// stepResultColumns hold per-step delivery results.
+var stepResultColumns = []column{
+	{"status", "TEXT NOT NULL DEFAULT ''"},
+	{"error", "TEXT NOT NULL DEFAULT ''"},
+	{"latency_ms", "INTEGER NOT NULL DEFAULT 0"},
+	{"attempts", "INTEGER NOT NULL DEFAULT 0"},
+}
+
+// eventColumns hold the dedup repeat count.
+var eventColumns = []column{
+	{"repeat", "INTEGER NOT NULL DEFAULT 0"},
+}
+
+// addColumns upgrades tables created before the given columns existed.
+// CREATE TABLE IF NOT EXISTS leaves existing tables untouched, so missing
+// columns are added here.
+func addColumns(db *sql.DB, table string, cols []column) error {
+	rows, err := db.Query(`PRAGMA table_info(` + table + `)`)
+	if err != nil {
+		return err
+	}
+	have := map[string]bool{}
+	for rows.Next() {
+		var cid, notNull, pk int
+		var name, typ string
+		var dflt any
+		if err := rows.Scan(&cid, &name, &typ, &notNull, &dflt, &pk); err != nil {
+			rows.Close()
+			return err
+		}
+		have[name] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, col := range cols {
+		if have[col.name] {
+			continue
+		}
+		if _, err := db.Exec(`ALTER TABLE ` + table + ` ADD COLUMN ` + col.name + ` ` + col.def); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // Close closes the database connection.
 func (s *SQLiteStore) Close() error {
 	return s.db.Close()
 }
 
-// voiceTextTypes lists step types that carry TTS voice text.
-var voiceTextTypes = map[string]bool{
-	"say": true, "discord_voice": true, "telegram_audio": true, "telegram_voice": true,
-}
-
 // Log inserts an execution event into the events table, then inserts one
-// step_details row per step in the same transaction. For voice-capable step
-// types (say, discord_voice, telegram_audio, telegram_voice), the expanded
-// text is stored in voice_text for later frequency queries.
-func (s *SQLiteStore) Log(action string, steps []config.Step, afk bool, vars tmpl.Vars, desktop *int) error {
+// step_details row per step in the same transaction, including the step's
+// delivery result when known. For voice-capable step types (say,
+// discord_voice, telegram_audio, telegram_voice, ...), the expanded text is
+// stored in voice_text for later frequency queries.
+func (s *SQLiteStore) Log(action string, steps []config.Step, results []StepResult, afk bool, vars tmpl.Vars, desktop *int) error {
 	ts := time.Now().Format(time.RFC3339)
 
 	types := make([]string, len(steps))
@@ -134,12 +196,13 @@ func (s *SQLiteStore) Log(action string, steps []config.Step, afk bool, vars tmp
 	if desktop != nil {
 		desktopVal = *desktop
 	}
+	repeat, _ := strconv.Atoi(vars.RepeatCount)
 
 	res, err := tx.Exec(
-		`INSERT INTO events (timestamp, profile, action, kind, afk, desktop, claude_hook, claude_message, steps_csv)
-		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		`INSERT INTO events (timestamp, profile, action, kind, afk, desktop, claude_hook, claude_message, steps_csv, repeat)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
 		ts, vars.Profile, action, int(KindExecution), afkInt, desktopVal,
-		vars.ClaudeHook, vars.ClaudeMessage, strings.Join(types, ","),
+		vars.ClaudeHook, vars.ClaudeMessage, strings.Join(types, ","), repeat,
 	)
 	if err != nil {
 		return err
@@ -153,14 +216,19 @@ func (s *SQLiteStore) Log(action string, steps []config.Step, afk bool, vars tmp
 	for i, st := range steps {
 		detail := StepSummary(st, &vars)
 		var voiceText *string
-		if voiceTextTypes[st.Type] {
+		if voiceStep(st.Type) {
 			expanded := tmpl.Expand(st.Text, vars)
 			voiceText = &expanded
 		}
+		var r StepResult
+		if i < len(results) {
+			r = results[i]
+		}
 		if _, err := tx.Exec(
-			`INSERT INTO step_details (event_id, step_num, step_type, detail, voice_text)
-			 VALUES (?, ?, ?, ?, ?)`,
+			`INSERT INTO step_details (event_id, step_num, step_type, detail, voice_text, status, error, latency_ms, attempts)
+			 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
 			eventID, i+1, st.Type, detail, voiceText,
+			r.Status, r.Error, r.Latency.Milliseconds(), r.Attempts,
 		); err != nil {
 			return err
 		}
@@ -197,6 +265,76 @@ func (s *SQLiteStore) LogCooldownRecord(profile, action string, seconds int) err
 	return err
 }
 
+// LogDedup records that an invocation was counted as a repeat within the
+// cooldown window. Uses KindDeduped so it counts as skipped in summaries.
+func (s *SQLiteStore) LogDedup(profile, action string, seconds, count int) error {
+	ts := time.Now().Format(time.RFC3339)
+	_, err := s.db.Exec(
+		`INSERT INTO events (timestamp, profile, action, kind, extra, repeat) VALUES (?, ?, ?, ?, ?, ?)`,
+		ts, profile, action, int(KindDeduped), fmt.Sprintf("deduped (%ds)", seconds), count,
+	)
+	return err
+}
+
+// LogBatched records that an invocation joined another process's batch
+// window. Uses KindBatched so it counts as skipped in summaries.
+func (s *SQLiteStore) LogBatched(profile, action string, count int) error {
+	ts := time.Now().Format(time.RFC3339)
+	_, err := s.db.Exec(
+		`INSERT INTO events (timestamp, profile, action, kind, extra) VALUES (?, ?, ?, ?, ?)`,
+		ts, profile, action, int(KindBatched), fmt.Sprintf("joined (%d so far)", count),
+	)
+	return err
+}
+
+// LogEscalation records an escalation state change as a KindOther event
+// (not counted in summaries) with the ID and state in extra.
+func (s *SQLiteStore) LogEscalation(id, profile, action, state string) error {
+	ts := time.Now().Format(time.RFC3339)
+	_, err := s.db.Exec(
+		`INSERT INTO events (timestamp, profile, action, kind, extra) VALUES (?, ?, ?, ?, ?)`,
+		ts, profile, action, int(KindOther), escalationExtra(id, state),
+	)
+	return err
+}
+
+// Escalations returns the escalation states folded from escalation
+// records at or after since.
+func (s *SQLiteStore) Escalations(since time.Time) ([]Escalation, error) {
+	rows, err := s.db.Query(
+		`SELECT timestamp, profile, action, extra FROM events
+		 WHERE kind = ? AND extra LIKE 'escalation=%' AND timestamp >= ?
+		 ORDER BY id`,
+		int(KindOther), since.Format(time.RFC3339))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var recs []escalationRecord
+	for rows.Next() {
+		var ts, profile, action, extra string
+		if err := rows.Scan(&ts, &profile, &action, &extra); err != nil {
+			return nil, err
+		}
+		t, err := time.Parse(time.RFC3339, ts)
+		if err != nil {
+			continue
+		}
+		recs = append(recs, escalationRecord{
+			time:    t,
+			id:      extractField(extra, "escalation"),
+			state:   extractField(extra, "state"),
+			profile: profile,
+			action:  action,
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return foldEscalations(recs), nil
+}
+
 // LogSilent records that an invocation was suppressed by silent mode.
 func (s *SQLiteStore) LogSilent(profile, action string) error {
 	ts := time.Now().Format(time.RFC3339)
@@ -234,7 +372,7 @@ func (s *SQLiteStore) Entries(days int) ([]Entry, error) {
 	// WHERE profile != '' AND action != '' mirrors the flat-file ParseEntries
 	// logic, which skips lines without both fields (e.g. silent=enabled/disabled
 	// system events). This keeps both backends returning the same result set.
-	query := `SELECT timestamp, profile, action, kind, claude_hook, claude_message
+	query := `SELECT id, timestamp, profile, action, kind, claude_hook, claude_message, repeat
 		FROM events WHERE profile != '' AND action != ''`
 	var args []any
 	if days > 0 {
@@ -250,13 +388,14 @@ func (s *SQLiteStore) Entries(days int) ([]Entry, error) {
 // EntriesSince returns entries with timestamps at or after cutoff.
 // Same profile/action filter as Entries for consistency.
 func (s *SQLiteStore) EntriesSince(cutoff time.Time) ([]Entry, error) {
-	query := `SELECT timestamp, profile, action, kind, claude_hook, claude_message
+	query := `SELECT id, timestamp, profile, action, kind, claude_hook, claude_message, repeat
 		FROM events WHERE timestamp >= ? AND profile != '' AND action != ''
 		ORDER BY id`
 	return s.queryEntries(query, cutoff.Format(time.RFC3339))
 }
 
-// queryEntries executes a SELECT query and scans rows into Entry structs.
+// queryEntries executes a SELECT query (whose first column is the event id)
+// and scans rows into Entry structs, attaching step results to executions.
 func (s *SQLiteStore) queryEntries(query string, args ...any) ([]Entry, error) {
 	rows, err := s.db.Query(query, args...)
 	if err != nil {
@@ -265,16 +404,25 @@ func (s *SQLiteStore) queryEntries(query string, args ...any) ([]Entry, error) {
 	defer rows.Close()
 
 	var entries []Entry
+	execIdx := map[int64]int{} // event id → index in entries
+	var minID int64 = -1
 	for rows.Next() {
+		var id int64
 		var tsStr, profile, action, claudeHook, claudeMessage string
-		var kind int
-		if err := rows.Scan(&tsStr, &profile, &action, &kind, &claudeHook, &claudeMessage); err != nil {
+		var kind, repeat int
+		if err := rows.Scan(&id, &tsStr, &profile, &action, &kind, &claudeHook, &claudeMessage, &repeat); err != nil {
 			return nil, err
 		}
 		ts, err := time.Parse(time.RFC3339, tsStr)
 		if err != nil {
 			continue
 		}
+		if EntryKind(kind) == KindExecution {
+			execIdx[id] = len(entries)
+			if minID < 0 || id < minID {
+				minID = id
+			}
+		}
 		entries = append(entries, Entry{
 			Time:          ts,
 			Profile:       profile,
@@ -282,9 +430,38 @@ func (s *SQLiteStore) queryEntries(query string, args ...any) ([]Entry, error) {
 			Kind:          EntryKind(kind),
 			ClaudeHook:    claudeHook,
 			ClaudeMessage: claudeMessage,
+			Repeat:        repeat,
 		})
 	}
-	return entries, rows.Err()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	if len(execIdx) == 0 {
+		return entries, nil
+	}
+	sdRows, err := s.db.Query(
+		`SELECT event_id, step_type, status, error, latency_ms, attempts
+		 FROM step_details WHERE event_id >= ? ORDER BY event_id, step_num`, minID)
+	if err != nil {
+		return nil, err
+	}
+	defer sdRows.Close()
+	for sdRows.Next() {
+		var eventID, latencyMs int64
+		var r StepResult
+		if err := sdRows.Scan(&eventID, &r.Type, &r.Status, &r.Error, &latencyMs, &r.Attempts); err != nil {
+			return nil, err
+		}
+		i, ok := execIdx[eventID]
+		if !ok {
+			continue
+		}
+		r.Latency = time.Duration(latencyMs) * time.Millisecond
+		entries[i].Steps = append(entries[i].Steps, r)
+	}
+	return entries, sdRows.Err()
 }
 
 // VoiceLines queries the step_details table for distinct TTS texts and their
@@ -328,7 +505,7 @@ func (s *SQLiteStore) VoiceLines(days int) ([]VoiceLine, error) {
 func (s *SQLiteStore) ReadContent() (string, error) {
 	rows, err := s.db.Query(
 		`SELECT e.id, e.timestamp, e.profile, e.action, e.kind, e.afk,
-		        e.desktop, e.claude_hook, e.claude_message, e.steps_csv, e.extra
+		        e.desktop, e.claude_hook, e.claude_message, e.steps_csv, e.extra, e.repeat
 		 FROM events e ORDER BY e.id`)
 	if err != nil {
 		return "", err
@@ -347,13 +524,14 @@ func (s *SQLiteStore) ReadContent() (string, error) {
 		claudeMessage string
 		stepsCSV      string
 		extra         string
+		repeat        int
 	}
 
 	var events []eventRow
 	for rows.Next() {
 		var ev eventRow
 		if err := rows.Scan(&ev.id, &ev.ts, &ev.profile, &ev.action, &ev.kind, &ev.afk,
-			&ev.desktop, &ev.claudeHook, &ev.claudeMessage, &ev.stepsCSV, &ev.extra); err != nil {
+			&ev.desktop, &ev.claudeHook, &ev.claudeMessage, &ev.stepsCSV, &ev.extra, &ev.repeat); err != nil {
 			return "", err
 		}
 		events = append(events, ev)
@@ -372,11 +550,12 @@ func (s *SQLiteStore) ReadContent() (string, error) {
 		stepType  string
 		detail    string
 		voiceText *string
+		result    StepResult
 	}
 	stepsByEvent := map[int64][]stepRow{}
 
 	sdRows, err := s.db.Query(
-		`SELECT event_id, step_num, step_type, detail, voice_text
+		`SELECT event_id, step_num, step_type, detail, voice_text, status, error, latency_ms, attempts
 		 FROM step_details ORDER BY event_id, step_num`)
 	if err != nil {
 		return "", err
@@ -384,11 +563,13 @@ func (s *SQLiteStore) ReadContent() (string, error) {
 	defer sdRows.Close()
 
 	for sdRows.Next() {
-		var eventID int64
+		var eventID, latencyMs int64
 		var sr stepRow
-		if err := sdRows.Scan(&eventID, &sr.stepNum, &sr.stepType, &sr.detail, &sr.voiceText); err != nil {
+		if err := sdRows.Scan(&eventID, &sr.stepNum, &sr.stepType, &sr.detail, &sr.voiceText,
+			&sr.result.Status, &sr.result.Error, &latencyMs, &sr.result.Attempts); err != nil {
 			return "", err
 		}
+		sr.result.Latency = time.Duration(latencyMs) * time.Millisecond
 		stepsByEvent[eventID] = append(stepsByEvent[eventID], sr)
 	}
 	if err := sdRows.Err(); err != nil {
@@ -405,6 +586,9 @@ func (s *SQLiteStore) ReadContent() (string, error) {
 			if ev.desktop != nil {
 				summary += fmt.Sprintf("  desktop=%d", *ev.desktop)
 			}
+			if ev.repeat > 0 {
+				summary += fmt.Sprintf("  repeat=%d", ev.repeat)
+			}
 			if ev.claudeHook != "" {
 				summary += fmt.Sprintf("  claude_hook=%s", ev.claudeHook)
 			}
@@ -414,7 +598,7 @@ func (s *SQLiteStore) ReadContent() (string, error) {
 			b.WriteString(summary)
 			b.WriteByte('\n')
 			for _, sr := range stepsByEvent[ev.id] {
-				fmt.Fprintf(&b, "%s    step[%d] %s  %s\n", ev.ts, sr.stepNum, sr.stepType, sr.detail)
+				fmt.Fprintf(&b, "%s    step[%d] %s  %s%s\n", ev.ts, sr.stepNum, sr.stepType, sr.detail, resultSuffix(sr.result))
 			}
 			b.WriteByte('\n')
 
@@ -422,12 +606,23 @@ func (s *SQLiteStore) ReadContent() (string, error) {
 			fmt.Fprintf(&b, "%s  profile=%s  action=%s  cooldown=%s\n\n",
 				ev.ts, ev.profile, ev.action, ev.extra)
 
+		case KindDeduped:
+			fmt.Fprintf(&b, "%s  profile=%s  action=%s  cooldown=%s  repeat=%d\n\n",
+				ev.ts, ev.profile, ev.action, ev.extra, ev.repeat)
+
+		case KindBatched:
+			fmt.Fprintf(&b, "%s  profile=%s  action=%s  batch=%s\n\n",
+				ev.ts, ev.profile, ev.action, ev.extra)
+
 		case KindSilent:
 			fmt.Fprintf(&b, "%s  profile=%s  action=%s  silent=skipped\n\n",
 				ev.ts, ev.profile, ev.action)
 
 		case KindOther:
-			if ev.profile != "" || ev.action != "" {
+			if strings.HasPrefix(ev.extra, "escalation=") {
+				fmt.Fprintf(&b, "%s  profile=%s  action=%s  %s\n\n",
+					ev.ts, ev.profile, ev.action, ev.extra)
+			} else if ev.profile != "" || ev.action != "" {
 				// cooldown=recorded
 				fmt.Fprintf(&b, "%s  profile=%s  action=%s  cooldown=%s\n",
 					ev.ts, ev.profile, ev.action, ev.extra)
@@ -520,10 +715,12 @@ func (s *SQLiteStore) migrateFromFile(logPath string) error {
 				if lastExecID > 0 {
 					stepNum, stepType, detail, voiceText := parseStepLine(line)
 					if stepType != "" {
+						detail, r := splitResult(detail)
 						if _, err := tx.Exec(
-							`INSERT INTO step_details (event_id, step_num, step_type, detail, voice_text)
-							 VALUES (?, ?, ?, ?, ?)`,
+							`INSERT INTO step_details (event_id, step_num, step_type, detail, voice_text, status, error, latency_ms, attempts)
+							 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
 							lastExecID, stepNum, stepType, detail, voiceText,
+							r.Status, r.Error, r.Latency.Milliseconds(), r.Attempts,
 						); err != nil {
 							return fmt.Errorf("migrate step: %w", err)
 						}
@@ -549,6 +746,7 @@ func (s *SQLiteStore) migrateFromFile(logPath string) error {
 			var desktop *int
 			claudeHook := ""
 			claudeMessage := ""
+			repeat := atoiField(line, "repeat")
 
 			if hasField(line, "steps") {
 				kind = KindExecution
@@ -565,8 +763,15 @@ func (s *SQLiteStore) migrateFromFile(logPath string) error {
 			} else if cooldownVal := extractField(line, "cooldown"); cooldownVal != "" {
 				if strings.HasPrefix(cooldownVal, "skipped") {
 					kind = KindCooldown
+				} else if strings.HasPrefix(cooldownVal, "deduped") {
+					kind = KindDeduped
 				}
 				extra = cooldownVal
+			} else if batchVal := extractField(line, "batch"); batchVal == "joined" {
+				kind = KindBatched
+				extra = batchVal
+			} else if id := extractField(line, "escalation"); id != "" {
+				extra = escalationExtra(id, extractField(line, "state"))
 			} else if silentVal := extractField(line, "silent"); silentVal != "" {
 				if silentVal == "skipped" {
 					kind = KindSilent
@@ -586,10 +791,10 @@ func (s *SQLiteStore) migrateFromFile(logPath string) error {
 			}
 
 			res, err := tx.Exec(
-				`INSERT INTO events (timestamp, profile, action, kind, afk, desktop, claude_hook, claude_message, steps_csv, extra)
-				 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+				`INSERT INTO events (timestamp, profile, action, kind, afk, desktop, claude_hook, claude_message, steps_csv, extra, repeat)
+				 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
 				tsStr, profile, action, int(kind), afkInt, desktopVal,
-				claudeHook, claudeMessage, stepsCSV, extra,
+				claudeHook, claudeMessage, stepsCSV, extra, repeat,
 			)
 			if err != nil {
 				return fmt.Errorf("migrate event: %w", err)
@@ -647,16 +852,13 @@ func parseStepLine(line string) (num int, stepType, detail string, voiceText *st
 	detail = strings.TrimLeft(rest[spaceIdx:], " ")
 
 	// Extract voice text for TTS step types.
-	if voiceTextTypes[stepType] {
-		for _, suffix := range voiceStepSuffixes {
-			sIdx := strings.Index(line, suffix)
-			if sIdx >= 0 {
-				raw := line[sIdx+len(suffix):]
-				text := extractQuoted(raw)
-				if text != "" {
-					voiceText = &text
-				}
-				break
+	if voiceStep(stepType) {
+		suffix := voiceStepSuffix(stepType)
+		if sIdx := strings.Index(line, suffix); sIdx >= 0 {
+			raw := line[sIdx+len(suffix):]
+			text := extractQuoted(raw)
+			if text != "" {
+				voiceText = &text
 			}
 		}
 	}
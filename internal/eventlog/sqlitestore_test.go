@@ -1,6 +1,7 @@
 package eventlog
 
 import (
+	"database/sql"
 	"os"
 	"path/filepath"
 	"strings"
@@ -30,7 +31,7 @@ func TestSQLiteStoreLogAndEntries(t *testing.T) {
 	vars := tmpl.Vars{Profile: "test"}
 	steps := []config.Step{{Type: "sound", Sound: "blip"}, {Type: "say", Text: "hello"}}
 
-	if err := s.Log("ready", steps, false, vars, nil); err != nil {
+	if err := s.Log("ready", steps, nil, false, vars, nil); err != nil {
 		t.Fatal(err)
 	}
 
@@ -65,6 +66,81 @@ func TestSQLiteStoreLogCooldown(t *testing.T) {
 	}
 }
 
+func TestSQLiteStoreLogBatched(t *testing.T) {
+	s := tempSQLiteStore(t)
+
+	if err := s.LogBatched("p", "a", 2); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, _ := s.Entries(0)
+	if len(entries) != 1 || entries[0].Kind != KindBatched {
+		t.Fatalf("entries = %+v, want one KindBatched", entries)
+	}
+	// ReadContent round-trips through the flat-file parser.
+	content, _ := s.ReadContent()
+	if parsed := ParseEntries(content); len(parsed) != 1 || parsed[0].Kind != KindBatched {
+		t.Errorf("ReadContent lost the batched kind:\n%s", content)
+	}
+}
+
+func TestSQLiteStoreLogDedup(t *testing.T) {
+	s := tempSQLiteStore(t)
+
+	if err := s.LogDedup("p", "a", 30, 3); err != nil {
+		t.Fatal(err)
+	}
+	vars := tmpl.Vars{Profile: "p", RepeatCount: "3"}
+	if err := s.Log("a", []config.Step{{Type: "toast", Message: "x"}}, nil, false, vars, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, _ := s.Entries(0)
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+	if entries[0].Kind != KindDeduped || entries[0].Repeat != 3 {
+		t.Errorf("entry 0 = kind %d repeat %d, want KindDeduped repeat 3", entries[0].Kind, entries[0].Repeat)
+	}
+	if entries[1].Kind != KindExecution || entries[1].Repeat != 3 {
+		t.Errorf("entry 1 = kind %d repeat %d, want KindExecution repeat 3", entries[1].Kind, entries[1].Repeat)
+	}
+
+	// ReadContent round-trips through the flat-file parser.
+	content, _ := s.ReadContent()
+	parsed := ParseEntries(content)
+	if len(parsed) != 2 || parsed[0].Repeat != 3 || parsed[1].Repeat != 3 {
+		t.Errorf("ReadContent lost repeat counts:\n%s", content)
+	}
+}
+
+func TestSQLiteStoreAddsRepeatColumn(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notify.db")
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// events table as created before dedup.
+	if _, err := db.Exec(`CREATE TABLE events (
+		id INTEGER PRIMARY KEY AUTOINCREMENT, timestamp TEXT NOT NULL,
+		profile TEXT NOT NULL DEFAULT '', action TEXT NOT NULL DEFAULT '',
+		kind INTEGER NOT NULL, afk INTEGER NOT NULL DEFAULT 0, desktop INTEGER,
+		claude_hook TEXT NOT NULL DEFAULT '', claude_message TEXT NOT NULL DEFAULT '',
+		steps_csv TEXT NOT NULL DEFAULT '', extra TEXT NOT NULL DEFAULT '')`); err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	s, err := NewSQLiteStore(path)
+	if err != nil {
+		t.Fatalf("NewSQLiteStore: %v", err)
+	}
+	defer s.Close()
+	if err := s.LogDedup("p", "a", 30, 2); err != nil {
+		t.Fatalf("LogDedup after upgrade: %v", err)
+	}
+}
+
 func TestSQLiteStoreLogSilent(t *testing.T) {
 	s := tempSQLiteStore(t)
 
@@ -109,7 +185,7 @@ func TestSQLiteStoreLogDesktop(t *testing.T) {
 	vars := tmpl.Vars{Profile: "test"}
 	steps := []config.Step{{Type: "sound", Sound: "blip"}}
 
-	if err := s.Log("ready", steps, false, vars, &d); err != nil {
+	if err := s.Log("ready", steps, nil, false, vars, &d); err != nil {
 		t.Fatal(err)
 	}
 
@@ -178,10 +254,10 @@ func TestSQLiteStoreVoiceLines(t *testing.T) {
 	steps := []config.Step{{Type: "say", Text: "hello world"}}
 
 	// Log three times: 2x "hello world", 1x "goodbye"
-	s.Log("a", steps, false, vars, nil)
-	s.Log("a", steps, false, vars, nil)
+	s.Log("a", steps, nil, false, vars, nil)
+	s.Log("a", steps, nil, false, vars, nil)
 	steps2 := []config.Step{{Type: "say", Text: "goodbye"}}
-	s.Log("a", steps2, false, vars, nil)
+	s.Log("a", steps2, nil, false, vars, nil)
 
 	lines, err := s.VoiceLines(0)
 	if err != nil {
@@ -398,7 +474,7 @@ func TestSQLiteStoreCascadeDelete(t *testing.T) {
 
 	vars := tmpl.Vars{Profile: "p"}
 	steps := []config.Step{{Type: "say", Text: "hello"}}
-	s.Log("a", steps, false, vars, nil)
+	s.Log("a", steps, nil, false, vars, nil)
 
 	// Verify step_details exist.
 	var count int
@@ -553,7 +629,7 @@ func TestSQLiteStoreReadContentExecution(t *testing.T) {
 	vars := tmpl.Vars{Profile: "test"}
 	steps := []config.Step{{Type: "sound", Sound: "blip"}, {Type: "say", Text: "hello"}}
 
-	s.Log("ready", steps, true, vars, nil)
+	s.Log("ready", steps, nil, true, vars, nil)
 
 	content, err := s.ReadContent()
 	if err != nil {
@@ -582,7 +658,7 @@ func TestSQLiteStoreLogClaudeFields(t *testing.T) {
 	vars := tmpl.Vars{Profile: "test", ClaudeHook: "post_tool_use", ClaudeMessage: "task done"}
 	steps := []config.Step{{Type: "sound", Sound: "blip"}}
 
-	s.Log("ready", steps, false, vars, nil)
+	s.Log("ready", steps, nil, false, vars, nil)
 
 	entries, _ := s.Entries(0)
 	if len(entries) != 1 {
@@ -603,3 +679,60 @@ func TestSQLiteStoreLogClaudeFields(t *testing.T) {
 		t.Fatal("expected claude_message in content")
 	}
 }
+
+func TestSQLiteStoreLogStepResults(t *testing.T) {
+	s := tempSQLiteStore(t)
+	vars := tmpl.Vars{Profile: "test"}
+	steps := []config.Step{{Type: "sound", Sound: "blip"}, {Type: "slack", Text: "hi"}}
+	results := []StepResult{
+		{Status: "ok", Attempts: 1, Latency: 5 * time.Millisecond},
+		{Status: "failed", Attempts: 2, Latency: 1500 * time.Millisecond, Error: "slack: webhook returned 500"},
+	}
+
+	if err := s.Log("ready", steps, results, false, vars, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := s.Entries(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || len(entries[0].Steps) != 2 {
+		t.Fatalf("entries = %+v, want 1 entry with 2 steps", entries)
+	}
+	got := entries[0].Steps[1]
+	want := StepResult{Type: "slack", Status: "failed", Error: "slack: webhook returned 500", Latency: 1500 * time.Millisecond, Attempts: 2}
+	if got != want {
+		t.Errorf("Steps[1] = %+v, want %+v", got, want)
+	}
+
+	content, _ := s.ReadContent()
+	if !strings.Contains(content, `step[2] slack  text="hi"  status=failed  attempts=2  latency=1.5s  error="slack: webhook returned 500"`) {
+		t.Errorf("ReadContent missing result suffix:\n%s", content)
+	}
+}
+
+func TestSQLiteStoreUpgradesStepDetails(t *testing.T) {
+	// A database created before step results existed lacks the new columns.
+	path := filepath.Join(t.TempDir(), "notify.db")
+	old, err := NewSQLiteStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, col := range []string{"status", "error", "latency_ms", "attempts"} {
+		if _, err := old.db.Exec(`ALTER TABLE step_details DROP COLUMN ` + col); err != nil {
+			t.Fatal(err)
+		}
+	}
+	old.Close()
+
+	s, err := NewSQLiteStore(path)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer s.Close()
+	steps := []config.Step{{Type: "sound", Sound: "blip"}}
+	if err := s.Log("ready", steps, []StepResult{{Status: "ok", Attempts: 1}}, false, tmpl.Vars{Profile: "p"}, nil); err != nil {
+		t.Fatalf("Log after upgrade: %v", err)
+	}
+}
@@ -11,18 +11,22 @@ import (
 // and SQLiteStore (indexed SQL queries). Selected via config "storage" option.
 type Store interface {
 	// Write — returns error for correctness; FileStore prints to stderr (best-effort).
-	Log(action string, steps []config.Step, afk bool, vars tmpl.Vars, desktop *int) error
+	Log(action string, steps []config.Step, results []StepResult, afk bool, vars tmpl.Vars, desktop *int) error
 	LogCooldown(profile, action string, seconds int) error
 	LogCooldownRecord(profile, action string, seconds int) error
+	LogDedup(profile, action string, seconds, count int) error
+	LogBatched(profile, action string, count int) error
 	LogSilent(profile, action string) error
 	LogSilentEnable(d time.Duration) error
 	LogSilentDisable() error
+	LogEscalation(id, profile, action, state string) error
 
 	// Read
-	Entries(days int) ([]Entry, error)             // parsed entries, 0 = all
-	EntriesSince(cutoff time.Time) ([]Entry, error) // entries after cutoff
-	VoiceLines(days int) ([]VoiceLine, error)       // TTS text frequency
-	ReadContent() (string, error)                   // raw log text
+	Entries(days int) ([]Entry, error)                 // parsed entries, 0 = all
+	EntriesSince(cutoff time.Time) ([]Entry, error)    // entries after cutoff
+	VoiceLines(days int) ([]VoiceLine, error)          // TTS text frequency
+	ReadContent() (string, error)                      // raw log text
+	Escalations(since time.Time) ([]Escalation, error) // escalation states, in opening order
 
 	// Maintenance
 	Clean(days int) (int, error)            // remove old entries, return removed count
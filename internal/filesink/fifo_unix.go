@@ -0,0 +1,29 @@
+//go:build unix
+
+package filesink
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// writeFIFO writes data to a named pipe without blocking. Opening a pipe
+// with no reader fails with ENXIO, which means nobody is listening and the
+// line is dropped. A full pipe (a reader that stopped reading) is an error
+// rather than a hang.
+func writeFIFO(path string, data []byte) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|syscall.O_NONBLOCK, 0)
+	if errors.Is(err, syscall.ENXIO) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("file: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(data); err != nil {
+		return fmt.Errorf("file: write: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+//go:build unix
+
+package filesink
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+func TestAppendFIFO(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notify.fifo")
+	if err := syscall.Mkfifo(path, 0600); err != nil {
+		t.Skipf("mkfifo: %v", err)
+	}
+
+	// No reader: the line is dropped instead of blocking.
+	if err := Append(path, Line{Profile: "p", Text: "lost"}, Rotation{}); err != nil {
+		t.Fatalf("Append without reader: %v", err)
+	}
+
+	r, err := os.OpenFile(path, os.O_RDONLY|syscall.O_NONBLOCK, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	if err := Append(path, Line{Profile: "p", Text: "seen"}, Rotation{MaxSize: 1}); err != nil {
+		t.Fatalf("Append with reader: %v", err)
+	}
+	line, err := bufio.NewReader(r).ReadBytes('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	var l Line
+	if err := json.Unmarshal(line, &l); err != nil || l.Text != "seen" {
+		t.Errorf("read %q (%v), want the second line", line, err)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode()&os.ModeNamedPipe == 0 {
+		t.Error("pipe was replaced")
+	}
+}
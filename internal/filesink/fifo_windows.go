@@ -0,0 +1,20 @@
+package filesink
+
+import (
+	"fmt"
+	"os"
+)
+
+// writeFIFO writes data to a named pipe (\\.\pipe\name), connecting to
+// the process that created it.
+func writeFIFO(path string, data []byte) error {
+	f, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		return fmt.Errorf("file: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(data); err != nil {
+		return fmt.Errorf("file: write: %w", err)
+	}
+	return nil
+}
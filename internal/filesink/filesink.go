@@ -0,0 +1,124 @@
+// Package filesink appends notifications as JSON lines to a file or named
+// pipe, so editors, status bars, and scripts can follow them without a
+// plugin process per event. Regular files can be rotated by size.
+package filesink
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Mavwarf/notify/internal/paths"
+)
+
+// DefaultKeep is how many rotated files are kept when a size limit is set.
+const DefaultKeep = 3
+
+// Line is one notification as written to the sink.
+type Line struct {
+	Time     time.Time         `json:"time"`
+	Profile  string            `json:"profile"`
+	Action   string            `json:"action,omitempty"`
+	Severity string            `json:"severity,omitempty"`
+	Text     string            `json:"text"`
+	Vars     map[string]string `json:"vars"`
+}
+
+// Rotation limits the size of a regular file. MaxSize 0 never rotates.
+type Rotation struct {
+	MaxSize int64 // bytes
+	Keep    int   // rotated files kept as path.1 ... path.Keep
+}
+
+// ParseSize parses a size such as "10MB", "512KB", "1GB", or a plain
+// byte count. Units are powers of 1024.
+func ParseSize(s string) (int64, error) {
+	num, mult := strings.ToUpper(strings.TrimSpace(s)), int64(1)
+	for _, u := range []struct {
+		suffix string
+		mult   int64
+	}{{"KB", 1 << 10}, {"MB", 1 << 20}, {"GB", 1 << 30}, {"B", 1}} {
+		if strings.HasSuffix(num, u.suffix) {
+			num, mult = strings.TrimSpace(strings.TrimSuffix(num, u.suffix)), u.mult
+			break
+		}
+	}
+	n, err := strconv.ParseInt(num, 10, 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("size %q must be a positive byte count or use KB, MB, or GB", s)
+	}
+	return n * mult, nil
+}
+
+// Append writes l as one JSON line to path. A named pipe receives the line
+// only while a reader has it open; without one the line is dropped. A
+// regular file is created if needed and rotated first when the line would
+// take it past r.MaxSize.
+func Append(path string, l Line, r Rotation) error {
+	data, err := json.Marshal(l)
+	if err != nil {
+		return fmt.Errorf("file: marshal: %w", err)
+	}
+	data = append(data, '\n')
+
+	if info, err := os.Stat(path); err == nil && info.Mode()&os.ModeNamedPipe != 0 {
+		return writeFIFO(path, data)
+	}
+
+	if r.MaxSize > 0 {
+		unlock, err := paths.Lock(path)
+		if err != nil {
+			return fmt.Errorf("file: lock: %w", err)
+		}
+		defer unlock()
+		if err := rotate(path, int64(len(data)), r); err != nil {
+			return fmt.Errorf("file: rotate: %w", err)
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), paths.DirPerm); err != nil {
+		return fmt.Errorf("file: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, paths.FilePerm)
+	if err != nil {
+		return fmt.Errorf("file: %w", err)
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return fmt.Errorf("file: write: %w", err)
+	}
+	return f.Close()
+}
+
+// rotate renames path.1 to path.2 and so on, then path to path.1, when
+// adding n bytes would take path past r.MaxSize; the oldest file is
+// removed. An empty file is never rotated, so an oversized line still lands.
+func rotate(path string, n int64, r Rotation) error {
+	info, err := os.Stat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if info.Size() == 0 || info.Size()+n <= r.MaxSize {
+		return nil
+	}
+	keep := r.Keep
+	if keep <= 0 {
+		keep = DefaultKeep
+	}
+	_ = os.Remove(fmt.Sprintf("%s.%d", path, keep))
+	for i := keep - 1; i >= 1; i-- {
+		old := fmt.Sprintf("%s.%d", path, i)
+		if err := os.Rename(old, fmt.Sprintf("%s.%d", path, i+1)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return os.Rename(path, path+".1")
+}
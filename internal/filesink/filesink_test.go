@@ -0,0 +1,124 @@
+package filesink
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func readLines(t *testing.T, path string) []Line {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var lines []Line
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		var l Line
+		if err := json.Unmarshal(sc.Bytes(), &l); err != nil {
+			t.Fatalf("line %q: %v", sc.Text(), err)
+		}
+		lines = append(lines, l)
+	}
+	return lines
+}
+
+func TestAppend(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sub", "notify.jsonl")
+	l := Line{
+		Time:    time.Date(2026, 10, 17, 9, 30, 0, 0, time.UTC),
+		Profile: "webapp",
+		Action:  "ready",
+		Text:    "build done",
+		Vars:    map[string]string{"profile": "webapp", "exit_code": "0"},
+	}
+	for range 2 {
+		if err := Append(path, l, Rotation{}); err != nil {
+			t.Fatalf("Append: %v", err)
+		}
+	}
+	lines := readLines(t, path)
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2", len(lines))
+	}
+	if got := lines[1]; got.Profile != "webapp" || got.Text != "build done" || got.Vars["exit_code"] != "0" || !got.Time.Equal(l.Time) {
+		t.Errorf("line = %+v", got)
+	}
+}
+
+func TestAppendRotates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notify.jsonl")
+	l := Line{Profile: "p", Text: strings.Repeat("x", 100)}
+	r := Rotation{MaxSize: 300, Keep: 2}
+	for range 10 {
+		if err := Append(path, l, r); err != nil {
+			t.Fatalf("Append: %v", err)
+		}
+	}
+	for _, p := range []string{path, path + ".1", path + ".2"} {
+		info, err := os.Stat(p)
+		if err != nil {
+			t.Fatalf("%s: %v", p, err)
+		}
+		if info.Size() > r.MaxSize {
+			t.Errorf("%s is %d bytes, limit %d", p, info.Size(), r.MaxSize)
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Errorf("%s.3 exists, want only 2 rotated files", path)
+	}
+	if _, err := os.Stat(path + ".lock"); !os.IsNotExist(err) {
+		t.Error("lock file left behind")
+	}
+}
+
+func TestAppendOversizedLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notify.jsonl")
+	l := Line{Profile: "p", Text: strings.Repeat("x", 500)}
+	if err := Append(path, l, Rotation{MaxSize: 100}); err != nil {
+		t.Fatal(err)
+	}
+	if got := readLines(t, path); len(got) != 1 {
+		t.Errorf("got %d lines, want the oversized line written", len(got))
+	}
+	if _, err := os.Stat(path + ".1"); !os.IsNotExist(err) {
+		t.Error("empty file was rotated")
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int64
+	}{
+		{"1024", 1024},
+		{"512KB", 512 << 10},
+		{"10MB", 10 << 20},
+		{"10 mb", 10 << 20},
+		{"1GB", 1 << 30},
+		{"100B", 100},
+		{"", 0},
+		{"0", 0},
+		{"-5MB", 0},
+		{"ten", 0},
+		{"5TB", 0},
+	}
+	for _, tt := range tests {
+		got, err := ParseSize(tt.in)
+		if tt.want == 0 {
+			if err == nil {
+				t.Errorf("ParseSize(%q) = %d, want error", tt.in, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("ParseSize(%q) = %d, %v; want %d", tt.in, got, err, tt.want)
+		}
+	}
+}
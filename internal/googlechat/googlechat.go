@@ -0,0 +1,96 @@
+// Package googlechat posts cards (v2) to Google Chat spaces via incoming
+// webhooks, optionally grouping messages into threads.
+package googlechat
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/Mavwarf/notify/internal/httputil"
+)
+
+// Message is one card message. Text is the plain-text fallback shown in
+// notifications; the card carries the same text plus the facts.
+type Message struct {
+	Title     string
+	Subtitle  string
+	Text      string
+	Facts     []Fact
+	ThreadKey string // messages with the same key are grouped in one thread
+}
+
+// Fact is a labelled value rendered as a decoratedText widget.
+type Fact struct {
+	Label string
+	Value string
+}
+
+// payload builds the cardsV2 request body.
+func payload(m Message) map[string]any {
+	widgets := []any{
+		map[string]any{"textParagraph": map[string]any{"text": m.Text}},
+	}
+	for _, f := range m.Facts {
+		widgets = append(widgets, map[string]any{
+			"decoratedText": map[string]any{"topLabel": f.Label, "text": f.Value},
+		})
+	}
+	header := map[string]any{"title": m.Title}
+	if m.Subtitle != "" {
+		header["subtitle"] = m.Subtitle
+	}
+	p := map[string]any{
+		"text": m.Text,
+		"cardsV2": []any{map[string]any{
+			"cardId": "notify",
+			"card": map[string]any{
+				"header":   header,
+				"sections": []any{map[string]any{"widgets": widgets}},
+			},
+		}},
+	}
+	if m.ThreadKey != "" {
+		p["thread"] = map[string]any{"threadKey": m.ThreadKey}
+	}
+	return p
+}
+
+// threadURL adds the reply option that makes Google Chat honor threadKey,
+// starting a new thread when no message with the key exists yet.
+func threadURL(webhookURL string) (string, error) {
+	u, err := url.Parse(webhookURL)
+	if err != nil {
+		return "", err
+	}
+	q := u.Query()
+	q.Set("messageReplyOption", "REPLY_MESSAGE_FALLBACK_TO_NEW_THREAD")
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+// Send posts m to the space's webhook URL.
+func Send(webhookURL string, m Message) error {
+	body, err := json.Marshal(payload(m))
+	if err != nil {
+		return fmt.Errorf("googlechat: marshal: %w", err)
+	}
+	endpoint := webhookURL
+	if m.ThreadKey != "" {
+		if endpoint, err = threadURL(webhookURL); err != nil {
+			return fmt.Errorf("googlechat: webhook URL: %w", err)
+		}
+	}
+
+	resp, err := httputil.Limited(webhookURL, func() (*http.Response, error) {
+		return httputil.Post(endpoint, "application/json; charset=UTF-8", bytes.NewReader(body))
+	})
+	if err != nil {
+		return fmt.Errorf("googlechat: post: %w", err)
+	}
+	defer resp.Body.Close()
+
+	return httputil.CheckStatus(resp, "googlechat: webhook")
+}
@@ -0,0 +1,91 @@
+package googlechat
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSendThreadedCard(t *testing.T) {
+	var raw map[string]any
+	var gotQuery map[string][]string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotQuery = r.URL.Query()
+		data, _ := io.ReadAll(r.Body)
+		json.Unmarshal(data, &raw)
+	}))
+	defer srv.Close()
+
+	m := Message{
+		Title:     "Webapp",
+		Subtitle:  "buildbox",
+		Text:      "build failed",
+		Facts:     []Fact{{"Command", "make"}},
+		ThreadKey: "notify-webapp",
+	}
+	if err := Send(srv.URL+"/v1/spaces/AAA/messages?key=k&token=t", m); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if gotQuery["key"][0] != "k" || gotQuery["token"][0] != "t" {
+		t.Errorf("webhook credentials lost from query: %v", gotQuery)
+	}
+	if gotQuery["messageReplyOption"][0] != "REPLY_MESSAGE_FALLBACK_TO_NEW_THREAD" {
+		t.Errorf("messageReplyOption = %v", gotQuery["messageReplyOption"])
+	}
+	if thread, _ := raw["thread"].(map[string]any); thread["threadKey"] != "notify-webapp" {
+		t.Errorf("thread = %v", raw["thread"])
+	}
+	if raw["text"] != "build failed" {
+		t.Errorf("text = %v", raw["text"])
+	}
+	cards, _ := raw["cardsV2"].([]any)
+	if len(cards) != 1 {
+		t.Fatalf("cardsV2 = %v", raw["cardsV2"])
+	}
+	card := cards[0].(map[string]any)["card"].(map[string]any)
+	if header := card["header"].(map[string]any); header["title"] != "Webapp" || header["subtitle"] != "buildbox" {
+		t.Errorf("header = %v", header)
+	}
+	widgets := card["sections"].([]any)[0].(map[string]any)["widgets"].([]any)
+	if len(widgets) != 2 {
+		t.Fatalf("widgets = %v, want text and one fact", widgets)
+	}
+	fact := widgets[1].(map[string]any)["decoratedText"].(map[string]any)
+	if fact["topLabel"] != "Command" || fact["text"] != "make" {
+		t.Errorf("fact = %v", fact)
+	}
+}
+
+func TestSendUnthreaded(t *testing.T) {
+	var raw map[string]any
+	var gotQuery string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotQuery = r.URL.RawQuery
+		data, _ := io.ReadAll(r.Body)
+		json.Unmarshal(data, &raw)
+	}))
+	defer srv.Close()
+
+	if err := Send(srv.URL, Message{Title: "x", Text: "hi"}); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if gotQuery != "" {
+		t.Errorf("query = %q, want none", gotQuery)
+	}
+	if _, ok := raw["thread"]; ok {
+		t.Error("thread sent without a key")
+	}
+}
+
+func TestSendError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	if err := Send(srv.URL, Message{Text: "hi"}); err == nil {
+		t.Fatal("expected error for 400 response")
+	}
+}
@@ -0,0 +1,88 @@
+// Package gotify posts messages to a self-hosted Gotify server using its
+// REST API and an application token.
+package gotify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Mavwarf/notify/internal/httputil"
+)
+
+// MaxPriority is the highest priority Gotify clients distinguish.
+const MaxPriority = 10
+
+// ParsePriority accepts a level from 0 to MaxPriority.
+func ParsePriority(s string) (int, error) {
+	p, err := strconv.Atoi(s)
+	if err != nil || p < 0 || p > MaxPriority {
+		return 0, fmt.Errorf("priority %q must be 0-%d", s, MaxPriority)
+	}
+	return p, nil
+}
+
+// Message is one Gotify message. Priority 0 leaves the application's
+// default priority; clients typically pop up from 4 and sound from 8.
+type Message struct {
+	Title    string
+	Message  string
+	Priority int
+	Markdown bool   // render the message as markdown in clients
+	Click    string // URL opened when the notification is tapped
+}
+
+// payload is the JSON body of POST /message.
+type payload struct {
+	Title    string         `json:"title,omitempty"`
+	Message  string         `json:"message"`
+	Priority int            `json:"priority,omitempty"`
+	Extras   map[string]any `json:"extras,omitempty"`
+}
+
+// body builds the request payload, mapping Markdown and Click onto
+// Gotify's client extras.
+func body(m Message) payload {
+	p := payload{Title: m.Title, Message: m.Message, Priority: m.Priority}
+	extras := map[string]any{}
+	if m.Markdown {
+		extras["client::display"] = map[string]string{"contentType": "text/markdown"}
+	}
+	if m.Click != "" {
+		extras["client::notification"] = map[string]any{
+			"click": map[string]string{"url": m.Click},
+		}
+	}
+	if len(extras) > 0 {
+		p.Extras = extras
+	}
+	return p
+}
+
+// Send posts m to the server at baseURL with the application token.
+func Send(baseURL, token string, m Message) error {
+	endpoint := strings.TrimRight(baseURL, "/") + "/message"
+	data, err := json.Marshal(body(m))
+	if err != nil {
+		return fmt.Errorf("gotify: marshal: %w", err)
+	}
+	req, err := http.NewRequest("POST", endpoint, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("gotify: new request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Gotify-Key", token)
+
+	resp, err := httputil.Limited(endpoint, func() (*http.Response, error) {
+		return httputil.Client.Do(req)
+	})
+	if err != nil {
+		return fmt.Errorf("gotify: post: %w", err)
+	}
+	defer resp.Body.Close()
+
+	return httputil.CheckStatus(resp, "gotify")
+}
@@ -0,0 +1,96 @@
+package gotify
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSendSuccess(t *testing.T) {
+	var raw map[string]any
+	var gotKey, gotPath string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotKey = r.Header.Get("X-Gotify-Key")
+		gotPath = r.URL.Path
+		data, _ := io.ReadAll(r.Body)
+		json.Unmarshal(data, &raw)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	m := Message{Title: "Webapp", Message: "**failed**", Priority: 8, Markdown: true, Click: "https://ci.example.com/1"}
+	if err := Send(srv.URL+"/", "app_tok", m); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if gotPath != "/message" {
+		t.Errorf("path = %q, want /message", gotPath)
+	}
+	if gotKey != "app_tok" {
+		t.Errorf("X-Gotify-Key = %q", gotKey)
+	}
+	if raw["title"] != "Webapp" || raw["message"] != "**failed**" || raw["priority"] != float64(8) {
+		t.Errorf("message = %v", raw)
+	}
+	extras, _ := raw["extras"].(map[string]any)
+	display, _ := extras["client::display"].(map[string]any)
+	if display["contentType"] != "text/markdown" {
+		t.Errorf("client::display = %v", extras["client::display"])
+	}
+	notif, _ := extras["client::notification"].(map[string]any)
+	click, _ := notif["click"].(map[string]any)
+	if click["url"] != m.Click {
+		t.Errorf("client::notification = %v", extras["client::notification"])
+	}
+}
+
+func TestSendPlain(t *testing.T) {
+	var raw map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		json.Unmarshal(data, &raw)
+	}))
+	defer srv.Close()
+
+	if err := Send(srv.URL, "t", Message{Message: "hi"}); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	for _, k := range []string{"title", "priority", "extras"} {
+		if _, ok := raw[k]; ok {
+			t.Errorf("unset field %q sent", k)
+		}
+	}
+}
+
+func TestSendError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	if err := Send(srv.URL, "bad", Message{Message: "hi"}); err == nil {
+		t.Fatal("expected error for 401 response")
+	}
+}
+
+func TestParsePriority(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int
+		ok   bool
+	}{
+		{"0", 0, true},
+		{"8", 8, true},
+		{"10", 10, true},
+		{"11", 0, false},
+		{"-1", 0, false},
+		{"high", 0, false},
+	}
+	for _, tt := range tests {
+		got, err := ParsePriority(tt.in)
+		if (err == nil) != tt.ok || got != tt.want {
+			t.Errorf("ParsePriority(%q) = %d, %v; want %d, ok=%v", tt.in, got, err, tt.want, tt.ok)
+		}
+	}
+}
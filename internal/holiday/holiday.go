@@ -0,0 +1,199 @@
+// Package holiday loads the holiday calendar used by the "holiday" step
+// condition. Two file formats are accepted: an iCalendar (.ics) export
+// from a calendar app, or a plain list with one date (YYYY-MM-DD) or date
+// range (YYYY-MM-DD..YYYY-MM-DD) per line.
+package holiday
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+const dateLayout = "2006-01-02"
+
+// Set is a collection of holiday dates. Yearly holidays from recurring
+// calendar events match on month and day in every year. A nil Set
+// contains no dates.
+type Set struct {
+	dates  map[string]bool // "2006-01-02"
+	yearly map[string]bool // "01-02"
+}
+
+// Contains reports whether t's local calendar date is a holiday.
+func (s *Set) Contains(t time.Time) bool {
+	if s == nil {
+		return false
+	}
+	d := t.Format(dateLayout)
+	return s.dates[d] || s.yearly[d[5:]]
+}
+
+// Len returns the number of distinct dates and yearly dates in the set.
+func (s *Set) Len() int {
+	if s == nil {
+		return 0
+	}
+	return len(s.dates) + len(s.yearly)
+}
+
+// Load reads the holiday file at path. An empty path yields a nil Set.
+// Errors are printed to stderr and also yield a nil Set, so a missing or
+// broken file never blocks notifications (fail-open: no day is a holiday).
+func Load(path string) *Set {
+	if path == "" {
+		return nil
+	}
+	s, err := Parse(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "holiday: %v\n", err)
+		return nil
+	}
+	return s
+}
+
+// Parse reads and parses the holiday file at path, detecting the format
+// from its content.
+func Parse(path string) (*Set, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	content := string(data)
+	if strings.Contains(content, "BEGIN:VCALENDAR") {
+		return parseICS(content)
+	}
+	s, err := parseList(content)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return s, nil
+}
+
+func newSet() *Set {
+	return &Set{dates: map[string]bool{}, yearly: map[string]bool{}}
+}
+
+// addRange adds every date from start through end (inclusive).
+func (s *Set) addRange(start, end time.Time, yearly bool) {
+	for d := start; !d.After(end); d = d.AddDate(0, 0, 1) {
+		key := d.Format(dateLayout)
+		if yearly {
+			s.yearly[key[5:]] = true
+		} else {
+			s.dates[key] = true
+		}
+	}
+}
+
+// parseList parses the plain format. Blank lines and lines starting with
+// "#" are ignored; anything after the date on a line is a free-form label:
+//
+//	2026-12-24..2027-01-02  office closed
+//	2027-04-05  # Easter Monday
+func parseList(content string) (*Set, error) {
+	s := newSet()
+	sc := bufio.NewScanner(strings.NewReader(content))
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		spec := strings.Fields(line)[0]
+		from, to, ok := strings.Cut(spec, "..")
+		if !ok {
+			to = from
+		}
+		start, err1 := time.Parse(dateLayout, from)
+		end, err2 := time.Parse(dateLayout, to)
+		if err1 != nil || err2 != nil || end.Before(start) {
+			return nil, fmt.Errorf("line %d: invalid date %q (expected YYYY-MM-DD or YYYY-MM-DD..YYYY-MM-DD)", n, spec)
+		}
+		s.addRange(start, end, false)
+	}
+	return s, sc.Err()
+}
+
+// parseICS extracts the dates of all VEVENTs. Each event covers its
+// DTSTART date through the day before an all-day DTEND (iCalendar end
+// dates are exclusive). Events with a yearly RRULE repeat every year;
+// other recurrence rules are ignored and only the first occurrence counts.
+func parseICS(content string) (*Set, error) {
+	s := newSet()
+	var inEvent, yearly bool
+	var start, end time.Time
+	for _, line := range unfoldICS(content) {
+		name, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		params := ""
+		if i := strings.Index(name, ";"); i >= 0 {
+			name, params = name[:i], name[i:]
+		}
+		switch strings.ToUpper(name) {
+		case "BEGIN":
+			if value == "VEVENT" {
+				inEvent, yearly = true, false
+				start, end = time.Time{}, time.Time{}
+			}
+		case "DTSTART":
+			if inEvent {
+				start = icsDate(value)
+			}
+		case "DTEND":
+			// Only all-day events have an exclusive end date; a timed
+			// event ending on the next day doesn't cover that day.
+			if inEvent && (strings.Contains(params, "VALUE=DATE") || len(value) == 8) {
+				if t := icsDate(value); !t.IsZero() {
+					end = t.AddDate(0, 0, -1)
+				}
+			}
+		case "RRULE":
+			if inEvent && strings.Contains(strings.ToUpper(value), "FREQ=YEARLY") {
+				yearly = true
+			}
+		case "END":
+			if value == "VEVENT" && inEvent {
+				inEvent = false
+				if start.IsZero() {
+					continue
+				}
+				if end.Before(start) {
+					end = start
+				}
+				s.addRange(start, end, yearly)
+			}
+		}
+	}
+	return s, nil
+}
+
+// icsDate parses the date part of an iCalendar DATE or DATE-TIME value
+// ("20261224" or "20261224T090000Z"). Returns zero time if invalid.
+func icsDate(value string) time.Time {
+	if len(value) < 8 {
+		return time.Time{}
+	}
+	t, err := time.Parse("20060102", value[:8])
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+// unfoldICS splits iCalendar content into logical lines, joining folded
+// continuation lines (which start with a space or tab).
+func unfoldICS(content string) []string {
+	var lines []string
+	for _, raw := range strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n") {
+		if (strings.HasPrefix(raw, " ") || strings.HasPrefix(raw, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += raw[1:]
+			continue
+		}
+		lines = append(lines, raw)
+	}
+	return lines
+}
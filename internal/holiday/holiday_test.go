@@ -0,0 +1,125 @@
+package holiday
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func day(y int, m time.Month, d int) time.Time {
+	return time.Date(y, m, d, 12, 0, 0, 0, time.Local)
+}
+
+func TestParseList(t *testing.T) {
+	path := writeFile(t, "holidays.txt", `# company holidays
+2026-12-24..2026-12-26  Christmas
+
+2027-04-05 Easter Monday
+`)
+	s, err := Parse(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, d := range []time.Time{day(2026, 12, 24), day(2026, 12, 25), day(2026, 12, 26), day(2027, 4, 5)} {
+		if !s.Contains(d) {
+			t.Errorf("Contains(%s) = false, want true", d.Format(dateLayout))
+		}
+	}
+	for _, d := range []time.Time{day(2026, 12, 23), day(2026, 12, 27), day(2028, 4, 5)} {
+		if s.Contains(d) {
+			t.Errorf("Contains(%s) = true, want false", d.Format(dateLayout))
+		}
+	}
+	if s.Len() != 4 {
+		t.Errorf("Len = %d, want 4", s.Len())
+	}
+}
+
+func TestParseListInvalid(t *testing.T) {
+	for _, content := range []string{"2026-12-24\nchristmas\n", "2026-12-26..2026-12-24\n", "2026-13-01\n"} {
+		_, err := Parse(writeFile(t, "holidays.txt", content))
+		if err == nil {
+			t.Errorf("Parse(%q): expected error", content)
+		} else if !strings.Contains(err.Error(), "invalid date") {
+			t.Errorf("Parse(%q): unexpected error: %v", content, err)
+		}
+	}
+}
+
+func TestParseICS(t *testing.T) {
+	ics := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"BEGIN:VEVENT",
+		"SUMMARY:Office closed",
+		"DTSTART;VALUE=DATE:20261224",
+		"DTEND;VALUE=DATE:20261227",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"SUMMARY:New Year's Day",
+		"DTSTART;VALUE=DATE:20250101",
+		"RRULE:FREQ=YEARLY",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"SUMMARY:Offsite with a very long description that the exporter",
+		"  folded onto a second line",
+		"DTSTART:20270310T090000Z",
+		"DTEND:20270311T170000Z",
+		"END:VEVENT",
+		"END:VCALENDAR",
+	}, "\r\n")
+	s, err := Parse(writeFile(t, "holidays.ics", ics))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		t    time.Time
+		want bool
+	}{
+		{day(2026, 12, 23), false},
+		{day(2026, 12, 24), true},
+		{day(2026, 12, 26), true},
+		{day(2026, 12, 27), false}, // all-day DTEND is exclusive
+		{day(2025, 1, 1), true},
+		{day(2031, 1, 1), true}, // yearly
+		{day(2031, 1, 2), false},
+		{day(2027, 3, 10), true},
+		{day(2027, 3, 11), false}, // timed event: only the start date
+	}
+	for _, tt := range tests {
+		if got := s.Contains(tt.t); got != tt.want {
+			t.Errorf("Contains(%s) = %v, want %v", tt.t.Format(dateLayout), got, tt.want)
+		}
+	}
+}
+
+func TestUnfoldICS(t *testing.T) {
+	got := unfoldICS("SUMMARY:Long\r\n  title\r\nDTSTART:20260101")
+	if len(got) != 2 || got[0] != "SUMMARY:Long title" {
+		t.Errorf("unfoldICS = %q", got)
+	}
+}
+
+func TestLoadFailOpen(t *testing.T) {
+	if s := Load(""); s != nil {
+		t.Errorf("Load(\"\") = %v, want nil", s)
+	}
+	if s := Load(filepath.Join(t.TempDir(), "missing.txt")); s != nil {
+		t.Errorf("Load(missing) = %v, want nil", s)
+	}
+	var s *Set
+	if s.Contains(day(2026, 12, 25)) || s.Len() != 0 {
+		t.Error("nil Set should be empty")
+	}
+}
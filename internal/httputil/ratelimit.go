@@ -0,0 +1,181 @@
+package httputil
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Mavwarf/notify/internal/paths"
+)
+
+// Rate limiting policy. Every request to a destination reserves the next
+// free send slot, spaced minInterval apart; a 429 (or a 503 with
+// Retry-After) pushes the next slot out by the server's Retry-After.
+// Slots are kept in ratelimit.json in the data directory, so concurrent
+// notify processes posting to the same webhook take turns instead of
+// bursting.
+const (
+	minInterval       = 1 * time.Second
+	maxWait           = 30 * time.Second // longer waits fail with RateLimitError
+	defaultRetryAfter = 5 * time.Second  // 429 without a Retry-After header
+	limitPrune        = time.Hour        // drop slots this far in the past
+)
+
+// sleep is time.Sleep, replaceable in tests.
+var sleep = time.Sleep
+
+// RateLimitError is returned by Limited when the destination is blocked
+// for longer than it is worth waiting. The step fails (and is queued in
+// the outbox) instead of holding up the invocation.
+type RateLimitError struct {
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("rate limited, retry after %s", e.RetryAfter.Round(time.Second))
+}
+
+// Limited runs send under the shared rate limiter for dest, a URL that
+// identifies the destination (webhook URL or bot API base URL). It waits
+// for dest's next send slot, then records any Retry-After in the
+// response. Loopback destinations are not limited. Limiter errors are
+// printed to stderr and never block delivery (fail-open).
+func Limited(dest string, send func() (*http.Response, error)) (*http.Response, error) {
+	return limited(limitPath(), dest, send)
+}
+
+func limited(path, dest string, send func() (*http.Response, error)) (*http.Response, error) {
+	if isLoopback(dest) {
+		return send()
+	}
+	key := limitKey(dest)
+	wait, err := reserve(path, key, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	sleep(wait)
+	resp, err := send()
+	if err != nil {
+		return resp, err
+	}
+	if d, ok := retryAfter(resp); ok {
+		block(path, key, time.Now().Add(d))
+	}
+	return resp, nil
+}
+
+// reserve claims key's next send slot and returns how long to wait for
+// it. Returns a RateLimitError if the slot is more than maxWait away.
+func reserve(path, key string, now time.Time) (time.Duration, error) {
+	var wait time.Duration
+	var limitErr error
+	updateLimits(path, func(slots map[string]time.Time) {
+		start := now
+		if next := slots[key]; next.After(now) {
+			start = next
+		}
+		wait = start.Sub(now)
+		if wait > maxWait {
+			limitErr = &RateLimitError{RetryAfter: wait}
+			return
+		}
+		slots[key] = start.Add(minInterval)
+	})
+	return wait, limitErr
+}
+
+// block moves key's next send slot to until, unless it is already later.
+func block(path, key string, until time.Time) {
+	updateLimits(path, func(slots map[string]time.Time) {
+		if until.After(slots[key]) {
+			slots[key] = until
+		}
+	})
+}
+
+// updateLimits applies fn to the slot map under the state file lock and
+// writes the result back. Stale slots are pruned on every write.
+func updateLimits(path string, fn func(map[string]time.Time)) {
+	unlock, err := paths.Lock(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "httputil: rate limit: %v\n", err)
+		fn(map[string]time.Time{}) // still compute the wait; just don't persist
+		return
+	}
+	defer unlock()
+
+	slots := map[string]time.Time{}
+	if data, err := os.ReadFile(path); err == nil {
+		_ = json.Unmarshal(data, &slots) // ignore corrupt; overwrite
+	}
+	fn(slots)
+	for k, t := range slots {
+		if time.Since(t) > limitPrune {
+			delete(slots, k)
+		}
+	}
+	data, err := json.MarshalIndent(slots, "", "  ")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "httputil: rate limit: marshal: %v\n", err)
+		return
+	}
+	if err := paths.AtomicWrite(path, data); err != nil {
+		fmt.Fprintf(os.Stderr, "httputil: rate limit: write: %v\n", err)
+	}
+}
+
+// retryAfter returns how long the server asked us to back off: the
+// Retry-After of a 429 or 503 response, or defaultRetryAfter for a 429
+// without one. Retry-After may be delay seconds or an HTTP date.
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode != http.StatusServiceUnavailable {
+		return 0, false
+	}
+	if v := strings.TrimSpace(resp.Header.Get("Retry-After")); v != "" {
+		if secs, err := strconv.ParseFloat(v, 64); err == nil && secs >= 0 {
+			return time.Duration(secs * float64(time.Second)), true
+		}
+		if t, err := http.ParseTime(v); err == nil {
+			return max(time.Until(t), 0), true
+		}
+	}
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return defaultRetryAfter, true
+	}
+	return 0, false
+}
+
+// limitKey hashes a destination for the state file: webhook URLs and bot
+// tokens are secrets and must not be written out in plain text.
+func limitKey(dest string) string {
+	sum := sha256.Sum256([]byte(dest))
+	return hex.EncodeToString(sum[:8])
+}
+
+// isLoopback reports whether dest points at this machine (local services
+// and tests), where rate limiting serves no purpose.
+func isLoopback(dest string) bool {
+	u, err := url.Parse(dest)
+	if err != nil {
+		return false
+	}
+	host := u.Hostname()
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+func limitPath() string {
+	return filepath.Join(paths.DataDir(), paths.RateLimitFileName)
+}
@@ -0,0 +1,147 @@
+package httputil
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestReserveSpacesSlots(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ratelimit.json")
+	now := time.Now()
+	for i := 0; i < 3; i++ {
+		wait, err := reserve(path, "k", now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := time.Duration(i) * minInterval; wait != want {
+			t.Errorf("reservation %d: wait = %v, want %v", i, wait, want)
+		}
+	}
+	// Other destinations are independent.
+	if wait, _ := reserve(path, "other", now); wait != 0 {
+		t.Errorf("other destination: wait = %v, want 0", wait)
+	}
+}
+
+func TestReserveAfterBlock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ratelimit.json")
+	now := time.Now()
+	block(path, "k", now.Add(10*time.Second))
+	wait, err := reserve(path, "k", now)
+	if err != nil || wait != 10*time.Second {
+		t.Errorf("wait = %v, %v; want 10s", wait, err)
+	}
+
+	block(path, "k", now.Add(time.Hour))
+	_, err = reserve(path, "k", now)
+	var rl *RateLimitError
+	if !errors.As(err, &rl) || rl.RetryAfter != time.Hour {
+		t.Errorf("err = %v, want RateLimitError with 1h", err)
+	}
+}
+
+func TestBlockNeverShortens(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ratelimit.json")
+	now := time.Now()
+	block(path, "k", now.Add(20*time.Second))
+	block(path, "k", now.Add(5*time.Second))
+	if wait, _ := reserve(path, "k", now); wait != 20*time.Second {
+		t.Errorf("wait = %v, want 20s", wait)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	future := time.Now().Add(time.Minute).UTC().Format(http.TimeFormat)
+	tests := []struct {
+		status int
+		header string
+		want   time.Duration
+		ok     bool
+	}{
+		{200, "", 0, false},
+		{429, "3", 3 * time.Second, true},
+		{429, "0.5", 500 * time.Millisecond, true},
+		{429, "", defaultRetryAfter, true},
+		{503, "7", 7 * time.Second, true},
+		{503, "", 0, false},
+		{500, "7", 0, false},
+	}
+	for _, tt := range tests {
+		resp := &http.Response{StatusCode: tt.status, Header: http.Header{}}
+		if tt.header != "" {
+			resp.Header.Set("Retry-After", tt.header)
+		}
+		got, ok := retryAfter(resp)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("retryAfter(%d, %q) = %v, %v; want %v, %v", tt.status, tt.header, got, ok, tt.want, tt.ok)
+		}
+	}
+	resp := &http.Response{StatusCode: 429, Header: http.Header{"Retry-After": {future}}}
+	if got, ok := retryAfter(resp); !ok || got < 58*time.Second || got > time.Minute {
+		t.Errorf("HTTP-date Retry-After = %v, %v; want about 1m", got, ok)
+	}
+}
+
+func TestLimitedRecordsRetryAfter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ratelimit.json")
+	var slept []time.Duration
+	sleep = func(d time.Duration) { slept = append(slept, d) }
+	defer func() { sleep = time.Sleep }()
+
+	dest := "https://hooks.example.com/services/T/B/secret"
+	send := func() (*http.Response, error) {
+		return &http.Response{StatusCode: 429, Header: http.Header{"Retry-After": {"12"}}}, nil
+	}
+	if _, err := limited(path, dest, send); err != nil {
+		t.Fatal(err)
+	}
+	wait, err := reserve(path, limitKey(dest), time.Now())
+	if err != nil || wait < 11*time.Second || wait > 12*time.Second {
+		t.Errorf("after 429: wait = %v, %v; want about 12s", wait, err)
+	}
+	if len(slept) != 1 || slept[0] != 0 {
+		t.Errorf("slept %v, want one zero wait for the first request", slept)
+	}
+}
+
+func TestLimitedSkipsLoopback(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+	path := filepath.Join(t.TempDir(), "ratelimit.json")
+	for i := 0; i < 2; i++ {
+		resp, err := limited(path, srv.URL, func() (*http.Response, error) { return http.Get(srv.URL) })
+		if err != nil {
+			t.Fatalf("request %d: %v", i, err)
+		}
+		resp.Body.Close()
+	}
+	if wait, _ := reserve(path, limitKey(srv.URL), time.Now()); wait != 0 {
+		t.Errorf("loopback destination was limited: wait = %v", wait)
+	}
+}
+
+func TestCheckStatusRateLimited(t *testing.T) {
+	resp := &http.Response{
+		StatusCode: 429,
+		Header:     http.Header{"Retry-After": {"4"}},
+		Body:       http.NoBody,
+	}
+	err := CheckStatus(resp, "slack: webhook")
+	if err == nil || err.Error() != "slack: webhook returned 429 (rate limited, retry after 4s): (empty body)" {
+		t.Errorf("CheckStatus = %v", err)
+	}
+}
+
+func TestLimitKeyHidesSecret(t *testing.T) {
+	dest := "https://discord.com/api/webhooks/1/TOKEN"
+	if k := limitKey(dest); k == dest || len(k) != 16 || k != limitKey(dest) {
+		t.Errorf("limitKey = %q, want a stable 16-char hash", k)
+	}
+}
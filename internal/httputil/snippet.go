@@ -1,4 +1,5 @@
-// Package httputil provides helpers for constructing multipart HTTP requests.
+// Package httputil provides the shared HTTP client, helpers for constructing
+// multipart requests and error messages, and per-destination rate limiting.
 package httputil
 
 import (
@@ -80,8 +81,13 @@ func PostMultipart(url string, upload FileUpload, fields [][2]string) (*http.Res
 }
 
 // CheckStatus returns an error if the response status code is not 2xx.
+// A 429 error includes the server's Retry-After.
 // The prefix is included in the error message for context (e.g. "discord: webhook").
 func CheckStatus(resp *http.Response, prefix string) error {
+	if resp.StatusCode == http.StatusTooManyRequests {
+		d, _ := retryAfter(resp)
+		return fmt.Errorf("%s returned %d (rate limited, retry after %s): %s", prefix, resp.StatusCode, d.Round(time.Second), ReadSnippet(resp.Body))
+	}
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		return fmt.Errorf("%s returned %d: %s", prefix, resp.StatusCode, ReadSnippet(resp.Body))
 	}
@@ -0,0 +1,145 @@
+// Package incident tracks which incidents notify has opened with an
+// incident service (PagerDuty, Opsgenie), so a later success can resolve
+// exactly the incidents a failure opened.
+//
+// State lives in incidents.json in the data directory: one entry per
+// service and dedup key, holding when the incident was opened, and one
+// per trigger that failed and is waiting in the outbox.
+package incident
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Mavwarf/notify/internal/paths"
+)
+
+// maxAge bounds how long an incident is remembered. Incidents nobody
+// resolved through notify in that time were handled elsewhere.
+const maxAge = 30 * 24 * time.Hour
+
+// Open records that an incident with key was opened on service.
+// Errors are printed to stderr but never fatal (best-effort).
+func Open(service, key string) {
+	if err := open(statePath(), service, key); err != nil {
+		fmt.Fprintf(os.Stderr, "incident: %v\n", err)
+	}
+}
+
+// IsOpen reports whether an incident with key is open on service. A
+// missing or unreadable state file means none are.
+func IsOpen(service, key string) bool {
+	return isOpen(statePath(), service, key)
+}
+
+// Queue records that the trigger for key on service failed and was
+// queued in the outbox. The incident counts as open from now on, so its
+// resolve is not skipped, and IsQueued holds until Open is called when
+// the outbox delivers the trigger.
+// Errors are printed to stderr but never fatal (best-effort).
+func Queue(service, key string) {
+	if err := queue(statePath(), service, key); err != nil {
+		fmt.Fprintf(os.Stderr, "incident: %v\n", err)
+	}
+}
+
+// IsQueued reports whether the trigger for key on service is still
+// waiting in the outbox.
+func IsQueued(service, key string) bool {
+	return isOpen(statePath(), queuedPrefix+service, key)
+}
+
+// Close forgets the incident with key on service after it was resolved.
+// Errors are printed to stderr but never fatal (best-effort).
+func Close(service, key string) {
+	if err := closeIncident(statePath(), service, key); err != nil {
+		fmt.Fprintf(os.Stderr, "incident: %v\n", err)
+	}
+}
+
+// queuedPrefix marks the state entries of queued triggers.
+const queuedPrefix = "queued:"
+
+func open(path, service, key string) error {
+	return update(path, func(state map[string]time.Time) {
+		state[stateKey(service, key)] = time.Now()
+		delete(state, stateKey(queuedPrefix+service, key))
+	})
+}
+
+func queue(path, service, key string) error {
+	return update(path, func(state map[string]time.Time) {
+		state[stateKey(service, key)] = time.Now()
+		state[stateKey(queuedPrefix+service, key)] = time.Now()
+	})
+}
+
+func isOpen(path, service, key string) bool {
+	state, err := load(path)
+	if err != nil {
+		return false
+	}
+	_, ok := state[stateKey(service, key)]
+	return ok
+}
+
+func closeIncident(path, service, key string) error {
+	return update(path, func(state map[string]time.Time) {
+		delete(state, stateKey(service, key))
+		delete(state, stateKey(queuedPrefix+service, key))
+	})
+}
+
+func stateKey(service, key string) string {
+	return service + "/" + key
+}
+
+func load(path string) (map[string]time.Time, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var state map[string]time.Time
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+// update applies fn to the state under the state file lock and writes it
+// back. A missing or corrupt file is treated as empty.
+func update(path string, fn func(map[string]time.Time)) error {
+	unlock, err := paths.Lock(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	state, err := load(path)
+	if err != nil {
+		state = make(map[string]time.Time)
+	}
+	for k, opened := range state {
+		if time.Since(opened) > maxAge {
+			delete(state, k)
+		}
+	}
+
+	fn(state)
+
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal: %w", err)
+	}
+	if err := paths.AtomicWrite(path, data); err != nil {
+		return fmt.Errorf("write: %w", err)
+	}
+	return nil
+}
+
+func statePath() string {
+	return filepath.Join(paths.DataDir(), paths.IncidentFileName)
+}
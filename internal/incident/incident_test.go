@@ -0,0 +1,81 @@
+package incident
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestOpenClose(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "incidents.json")
+
+	if isOpen(path, "pagerduty", "webapp:make") {
+		t.Fatal("open before open")
+	}
+	if err := open(path, "pagerduty", "webapp:make"); err != nil {
+		t.Fatal(err)
+	}
+	if !isOpen(path, "pagerduty", "webapp:make") {
+		t.Fatal("not open after open")
+	}
+	if isOpen(path, "opsgenie", "webapp:make") {
+		t.Error("services share keys")
+	}
+	if err := closeIncident(path, "pagerduty", "webapp:make"); err != nil {
+		t.Fatal(err)
+	}
+	if isOpen(path, "pagerduty", "webapp:make") {
+		t.Error("still open after close")
+	}
+}
+
+func TestPrunesOldIncidents(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "incidents.json")
+	old := map[string]time.Time{"pagerduty/stale": time.Now().Add(-maxAge - time.Hour)}
+	data, _ := json.Marshal(old)
+	os.WriteFile(path, data, 0644)
+
+	open(path, "pagerduty", "fresh")
+	if isOpen(path, "pagerduty", "stale") {
+		t.Error("stale incident not pruned")
+	}
+	if !isOpen(path, "pagerduty", "fresh") {
+		t.Error("fresh incident missing")
+	}
+}
+
+func TestCorruptStateIsEmpty(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "incidents.json")
+	os.WriteFile(path, []byte("{not json"), 0644)
+
+	if isOpen(path, "pagerduty", "x") {
+		t.Error("corrupt state reported an open incident")
+	}
+	open(path, "pagerduty", "x")
+	if !isOpen(path, "pagerduty", "x") {
+		t.Error("open did not recover from corrupt state")
+	}
+}
+
+func TestQueuedTrigger(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "incidents.json")
+	queued := func() bool { return isOpen(path, queuedPrefix+"pagerduty", "k") }
+
+	if err := queue(path, "pagerduty", "k"); err != nil {
+		t.Fatal(err)
+	}
+	if !isOpen(path, "pagerduty", "k") || !queued() {
+		t.Fatal("queued trigger should count as open and queued")
+	}
+	open(path, "pagerduty", "k")
+	if !isOpen(path, "pagerduty", "k") || queued() {
+		t.Error("delivered trigger should be open and no longer queued")
+	}
+	queue(path, "pagerduty", "k")
+	closeIncident(path, "pagerduty", "k")
+	if isOpen(path, "pagerduty", "k") || queued() {
+		t.Error("close left state behind")
+	}
+}
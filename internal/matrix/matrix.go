@@ -0,0 +1,98 @@
+// Package matrix sends m.room.message events to a Matrix room through the
+// client-server API.
+package matrix
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/Mavwarf/notify/internal/httputil"
+)
+
+// Message is the content of an m.text event. A non-empty HTML is sent as
+// the formatted body, with Body as the plain-text fallback.
+type Message struct {
+	Body string
+	HTML string
+}
+
+// content is the JSON body of an m.room.message event.
+type content struct {
+	MsgType       string `json:"msgtype"`
+	Body          string `json:"body"`
+	Format        string `json:"format,omitempty"`
+	FormattedBody string `json:"formatted_body,omitempty"`
+}
+
+// Send posts m to room on the homeserver with the access token. room is a
+// room ID ("!abc:example.org") or an alias ("#ops:example.org"), which is
+// resolved first. txnID identifies the send: sending it again with the
+// same ID makes the homeserver return the original event instead of
+// posting it twice.
+func Send(homeserver, token, room, txnID string, m Message) error {
+	base := strings.TrimRight(homeserver, "/") + "/_matrix/client/v3"
+	roomID := room
+	if strings.HasPrefix(room, "#") {
+		var err error
+		if roomID, err = resolveAlias(base, token, room); err != nil {
+			return err
+		}
+	}
+
+	c := content{MsgType: "m.text", Body: m.Body}
+	if m.HTML != "" {
+		c.Format = "org.matrix.custom.html"
+		c.FormattedBody = m.HTML
+	}
+	body, err := json.Marshal(c)
+	if err != nil {
+		return fmt.Errorf("matrix: marshal: %w", err)
+	}
+	endpoint := fmt.Sprintf("%s/rooms/%s/send/m.room.message/%s",
+		base, url.PathEscape(roomID), url.PathEscape(txnID))
+
+	resp, err := httputil.Limited(base+"/rooms/"+url.PathEscape(roomID), func() (*http.Response, error) {
+		req, err := http.NewRequest("PUT", endpoint, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		return httputil.Client.Do(req)
+	})
+	if err != nil {
+		return fmt.Errorf("matrix: send: %w", err)
+	}
+	defer resp.Body.Close()
+
+	return httputil.CheckStatus(resp, "matrix")
+}
+
+// resolveAlias looks up the room ID for a room alias.
+func resolveAlias(base, token, alias string) (string, error) {
+	req, err := http.NewRequest("GET", base+"/directory/room/"+url.PathEscape(alias), nil)
+	if err != nil {
+		return "", fmt.Errorf("matrix: new request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := httputil.Client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("matrix: resolve %s: %w", alias, err)
+	}
+	defer resp.Body.Close()
+	if err := httputil.CheckStatus(resp, "matrix: resolve "+alias); err != nil {
+		return "", err
+	}
+
+	var out struct {
+		RoomID string `json:"room_id"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil || out.RoomID == "" {
+		return "", fmt.Errorf("matrix: resolve %s: no room_id in response", alias)
+	}
+	return out.RoomID, nil
+}
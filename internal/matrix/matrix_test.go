@@ -0,0 +1,95 @@
+package matrix
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSendSuccess(t *testing.T) {
+	var got content
+	var gotMethod, gotPath, gotAuth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotMethod = r.Method
+		gotPath = r.URL.EscapedPath()
+		gotAuth = r.Header.Get("Authorization")
+		data, _ := io.ReadAll(r.Body)
+		json.Unmarshal(data, &got)
+		w.Write([]byte(`{"event_id":"$ev"}`))
+	}))
+	defer srv.Close()
+
+	m := Message{Body: "webapp failed", HTML: "<b>webapp</b> failed"}
+	if err := Send(srv.URL+"/", "syt_tok", "!room:example.org", "notify-1", m); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if gotMethod != "PUT" {
+		t.Errorf("method = %s, want PUT", gotMethod)
+	}
+	if want := "/_matrix/client/v3/rooms/%21room:example.org/send/m.room.message/notify-1"; gotPath != want {
+		t.Errorf("path = %q, want %q", gotPath, want)
+	}
+	if gotAuth != "Bearer syt_tok" {
+		t.Errorf("Authorization = %q", gotAuth)
+	}
+	want := content{MsgType: "m.text", Body: m.Body, Format: "org.matrix.custom.html", FormattedBody: m.HTML}
+	if got != want {
+		t.Errorf("content = %+v, want %+v", got, want)
+	}
+}
+
+func TestSendPlain(t *testing.T) {
+	var raw map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		json.Unmarshal(data, &raw)
+	}))
+	defer srv.Close()
+
+	if err := Send(srv.URL, "t", "!r:x", "notify-1", Message{Body: "hi"}); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	for _, k := range []string{"format", "formatted_body"} {
+		if _, ok := raw[k]; ok {
+			t.Errorf("unset field %q sent", k)
+		}
+	}
+}
+
+func TestSendResolvesAlias(t *testing.T) {
+	var sentPath string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "GET" {
+			if !strings.HasSuffix(r.URL.Path, "/directory/room/#ops:example.org") {
+				t.Errorf("resolve path = %q", r.URL.Path)
+			}
+			w.Write([]byte(`{"room_id":"!abc:example.org","servers":["example.org"]}`))
+			return
+		}
+		sentPath = r.URL.Path
+	}))
+	defer srv.Close()
+
+	if err := Send(srv.URL, "t", "#ops:example.org", "notify-1", Message{Body: "hi"}); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if !strings.Contains(sentPath, "/rooms/!abc:example.org/") {
+		t.Errorf("send path = %q, want the resolved room ID", sentPath)
+	}
+}
+
+func TestSendError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`{"errcode":"M_FORBIDDEN"}`))
+	}))
+	defer srv.Close()
+
+	err := Send(srv.URL, "t", "!r:x", "notify-1", Message{Body: "hi"})
+	if err == nil || !strings.Contains(err.Error(), "M_FORBIDDEN") {
+		t.Fatalf("err = %v, want M_FORBIDDEN", err)
+	}
+}
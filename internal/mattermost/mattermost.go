@@ -0,0 +1,53 @@
+// Package mattermost posts messages to Mattermost (or any server speaking
+// the same incoming webhook format) with optional sender and channel
+// overrides.
+package mattermost
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/Mavwarf/notify/internal/httputil"
+)
+
+// Message is one incoming webhook post. Empty overrides keep the
+// webhook's configured defaults; the server must allow overriding the
+// username and icon for those to take effect.
+type Message struct {
+	Text     string `json:"text"`
+	Username string `json:"username,omitempty"`
+	IconURL  string `json:"icon_url,omitempty"`
+	Emoji    string `json:"icon_emoji,omitempty"`
+	Channel  string `json:"channel,omitempty"`
+}
+
+// SetIcon sets IconURL for a URL and Emoji for anything else, so a single
+// "icon" setting accepts both "https://..." and ":robot:".
+func (m *Message) SetIcon(icon string) {
+	if strings.HasPrefix(icon, "http://") || strings.HasPrefix(icon, "https://") {
+		m.IconURL = icon
+	} else {
+		m.Emoji = icon
+	}
+}
+
+// Send posts m to the incoming webhook URL.
+func Send(webhookURL string, m Message) error {
+	body, err := json.Marshal(m)
+	if err != nil {
+		return fmt.Errorf("mattermost: marshal: %w", err)
+	}
+
+	resp, err := httputil.Limited(webhookURL, func() (*http.Response, error) {
+		return httputil.Post(webhookURL, "application/json", bytes.NewReader(body))
+	})
+	if err != nil {
+		return fmt.Errorf("mattermost: post: %w", err)
+	}
+	defer resp.Body.Close()
+
+	return httputil.CheckStatus(resp, "mattermost: webhook")
+}
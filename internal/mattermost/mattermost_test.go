@@ -0,0 +1,53 @@
+package mattermost
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSendSuccess(t *testing.T) {
+	var got map[string]string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		json.Unmarshal(data, &got)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	m := Message{Text: "webapp ready", Username: "notify", Channel: "builds"}
+	m.SetIcon(":robot:")
+	if err := Send(srv.URL, m); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	want := map[string]string{"text": "webapp ready", "username": "notify", "icon_emoji": ":robot:", "channel": "builds"}
+	if len(got) != len(want) {
+		t.Errorf("body = %v, want %v", got, want)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%s = %q, want %q", k, got[k], v)
+		}
+	}
+}
+
+func TestSetIcon(t *testing.T) {
+	var m Message
+	m.SetIcon("https://example.com/bot.png")
+	if m.IconURL != "https://example.com/bot.png" || m.Emoji != "" {
+		t.Errorf("URL icon: %+v", m)
+	}
+}
+
+func TestSendError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	if err := Send(srv.URL, Message{Text: "hi"}); err == nil {
+		t.Fatal("expected error for 400 response")
+	}
+}
@@ -0,0 +1,250 @@
+// Package nats publishes notifications to a NATS server over its text
+// protocol, optionally waiting for a JetStream acknowledgement. Each call
+// opens a fresh connection.
+package nats
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// timeout bounds the whole exchange, from dial to the last reply.
+const timeout = 5 * time.Second
+
+// Server is a parsed nats:// or tls:// URL plus credentials.
+type Server struct {
+	Addr     string // host:port
+	TLS      bool
+	Username string
+	Password string
+	Token    string
+}
+
+// ParseURL parses "nats://[user[:password]@]host[:port]". A user without
+// a password is taken as a token; tls:// requires TLS. The port defaults
+// to 4222.
+func ParseURL(raw string) (Server, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return Server{}, fmt.Errorf("url %q: %w", raw, err)
+	}
+	if u.Scheme != "nats" && u.Scheme != "tls" {
+		return Server{}, fmt.Errorf("url %q must start with nats:// or tls://", raw)
+	}
+	if u.Hostname() == "" {
+		return Server{}, fmt.Errorf("url %q has no host", raw)
+	}
+	s := Server{Addr: u.Host, TLS: u.Scheme == "tls"}
+	if u.Port() == "" {
+		s.Addr = net.JoinHostPort(u.Hostname(), "4222")
+	}
+	if u.User != nil {
+		if pw, ok := u.User.Password(); ok {
+			s.Username, s.Password = u.User.Username(), pw
+		} else {
+			s.Token = u.User.Username()
+		}
+	}
+	return s, nil
+}
+
+// Ack is a JetStream publish acknowledgement.
+type Ack struct {
+	Stream string `json:"stream"`
+	Seq    uint64 `json:"seq"`
+}
+
+// Publish sends payload to subject and waits until the server has
+// processed it (a PING round trip), so errors such as a permissions
+// violation are reported.
+func Publish(s Server, subject string, payload []byte) error {
+	c, err := connect(s)
+	if err != nil {
+		return err
+	}
+	defer c.conn.Close()
+	fmt.Fprintf(c.w, "PUB %s %d\r\n%s\r\nPING\r\n", subject, len(payload), payload)
+	if err := c.w.Flush(); err != nil {
+		return fmt.Errorf("nats: write: %w", err)
+	}
+	for {
+		line, err := c.readLine()
+		if err != nil {
+			return err
+		}
+		if line == "PONG" {
+			return nil
+		}
+	}
+}
+
+// PublishJetStream sends payload to subject and waits for the stream that
+// captures it to acknowledge it.
+func PublishJetStream(s Server, subject string, payload []byte) (Ack, error) {
+	c, err := connect(s)
+	if err != nil {
+		return Ack{}, err
+	}
+	defer c.conn.Close()
+	inbox := newInbox()
+	fmt.Fprintf(c.w, "SUB %s 1\r\nPUB %s %s %d\r\n%s\r\n", inbox, subject, inbox, len(payload), payload)
+	if err := c.w.Flush(); err != nil {
+		return Ack{}, fmt.Errorf("nats: write: %w", err)
+	}
+	for {
+		line, err := c.readLine()
+		if err != nil {
+			return Ack{}, err
+		}
+		fields := strings.Fields(line)
+		switch {
+		case len(fields) == 4 && fields[0] == "MSG":
+			body, err := c.readPayload(fields[3])
+			if err != nil {
+				return Ack{}, err
+			}
+			return parseAck(body)
+		case len(fields) == 5 && fields[0] == "HMSG":
+			// A header-only reply is a status, e.g. 503 when no stream
+			// listens on the subject.
+			msg, err := c.readPayload(fields[4])
+			if err != nil {
+				return Ack{}, err
+			}
+			status, _, _ := strings.Cut(string(msg), "\r\n")
+			if strings.HasPrefix(status, "NATS/1.0 503") {
+				return Ack{}, fmt.Errorf("nats: no JetStream stream for subject %q", subject)
+			}
+			return Ack{}, fmt.Errorf("nats: jetstream: %s", strings.TrimPrefix(status, "NATS/1.0 "))
+		}
+	}
+}
+
+// parseAck decodes a JetStream publish reply.
+func parseAck(body []byte) (Ack, error) {
+	var reply struct {
+		Ack
+		Error *struct {
+			Code        int    `json:"code"`
+			Description string `json:"description"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(body, &reply); err != nil {
+		return Ack{}, fmt.Errorf("nats: jetstream ack: %w", err)
+	}
+	if reply.Error != nil {
+		return Ack{}, fmt.Errorf("nats: jetstream: %s (%d)", reply.Error.Description, reply.Error.Code)
+	}
+	return reply.Ack, nil
+}
+
+// client is an open connection after the CONNECT handshake.
+type client struct {
+	conn net.Conn
+	r    *bufio.Reader
+	w    *bufio.Writer
+}
+
+// info holds the INFO fields the handshake needs.
+type info struct {
+	TLSRequired bool `json:"tls_required"`
+}
+
+// connect dials s, upgrades to TLS when asked or required by the server,
+// and sends CONNECT.
+func connect(s Server) (*client, error) {
+	conn, err := net.DialTimeout("tcp", s.Addr, timeout)
+	if err != nil {
+		return nil, fmt.Errorf("nats: dial: %w", err)
+	}
+	conn.SetDeadline(time.Now().Add(timeout))
+	c := &client{conn: conn, r: bufio.NewReader(conn)}
+
+	line, err := c.readLine()
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	var srv info
+	if data, ok := strings.CutPrefix(line, "INFO "); !ok || json.Unmarshal([]byte(data), &srv) != nil {
+		conn.Close()
+		return nil, fmt.Errorf("nats: unexpected greeting %q", line)
+	}
+	if s.TLS || srv.TLSRequired {
+		host, _, _ := net.SplitHostPort(s.Addr)
+		tc := tls.Client(conn, &tls.Config{ServerName: host})
+		if err := tc.Handshake(); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("nats: tls: %w", err)
+		}
+		c.conn, c.r = tc, bufio.NewReader(tc)
+	}
+	c.w = bufio.NewWriter(c.conn)
+
+	// Headers and no_responders make a JetStream publish to a subject no
+	// stream captures fail fast with a 503 instead of timing out.
+	opts := map[string]any{
+		"verbose": false, "pedantic": false, "name": "notify", "lang": "go",
+		"version": "notify", "protocol": 1, "headers": true, "no_responders": true,
+	}
+	for key, value := range map[string]string{"user": s.Username, "pass": s.Password, "auth_token": s.Token} {
+		if value != "" {
+			opts[key] = value
+		}
+	}
+	data, _ := json.Marshal(opts)
+	fmt.Fprintf(c.w, "CONNECT %s\r\n", data)
+	return c, nil
+}
+
+// readLine returns the next protocol line. It answers server PINGs, skips
+// INFO updates and +OK, and turns -ERR into an error.
+func (c *client) readLine() (string, error) {
+	for {
+		line, err := c.r.ReadString('\n')
+		if err != nil {
+			return "", fmt.Errorf("nats: read: %w", err)
+		}
+		line = strings.TrimRight(line, "\r\n")
+		switch {
+		case line == "PING":
+			io.WriteString(c.conn, "PONG\r\n")
+		case line == "+OK", strings.HasPrefix(line, "INFO ") && c.w != nil:
+		case strings.HasPrefix(line, "-ERR"):
+			return "", errors.New("nats: " + strings.Trim(strings.TrimSpace(line[4:]), "'"))
+		default:
+			return line, nil
+		}
+	}
+}
+
+// readPayload reads a message body of the given size and its CRLF.
+func (c *client) readPayload(size string) ([]byte, error) {
+	n, err := strconv.Atoi(size)
+	if err != nil {
+		return nil, fmt.Errorf("nats: bad message size %q", size)
+	}
+	buf := make([]byte, n+2)
+	if _, err := io.ReadFull(c.r, buf); err != nil {
+		return nil, fmt.Errorf("nats: read: %w", err)
+	}
+	return buf[:n], nil
+}
+
+// newInbox returns a unique reply subject.
+func newInbox() string {
+	b := make([]byte, 12)
+	rand.Read(b)
+	return "_INBOX." + hex.EncodeToString(b)
+}
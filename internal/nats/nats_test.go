@@ -0,0 +1,159 @@
+package nats
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"testing"
+)
+
+// published is what the fake server saw for one PUB.
+type published struct {
+	connect map[string]any
+	subject string
+	reply   string
+	payload string
+}
+
+// fakeServer speaks enough of the NATS protocol to accept one publish per
+// connection. jetstream answers a PUB with a reply subject: an ack when
+// the subject starts with "events.", a 503 no-responders status otherwise.
+// A token other than "" or "secret" is rejected like a real server would.
+func fakeServer(t *testing.T) (Server, <-chan published) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	got := make(chan published, 10)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go serve(conn, got)
+		}
+	}()
+	return Server{Addr: ln.Addr().String()}, got
+}
+
+func serve(conn net.Conn, got chan<- published) {
+	defer conn.Close()
+	io.WriteString(conn, `INFO {"server_id":"fake","headers":true,"jetstream":true}`+"\r\n")
+	r := bufio.NewReader(conn)
+	var p published
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		f := strings.Fields(line)
+		switch f[0] {
+		case "CONNECT":
+			json.Unmarshal([]byte(strings.TrimPrefix(strings.TrimSpace(line), "CONNECT ")), &p.connect)
+			if tok, _ := p.connect["auth_token"].(string); tok != "" && tok != "secret" {
+				io.WriteString(conn, "-ERR 'Authorization Violation'\r\n")
+				return
+			}
+			// Servers ping new clients; the client must answer.
+			io.WriteString(conn, "PING\r\n")
+		case "SUB", "PONG":
+		case "PUB":
+			p.subject = f[1]
+			if len(f) == 4 {
+				p.reply = f[2]
+			}
+			body, _ := r.ReadString('\n')
+			p.payload = strings.TrimSuffix(body, "\r\n")
+			got <- p
+			switch {
+			case p.reply == "":
+			case strings.HasPrefix(p.subject, "events."):
+				ack := `{"stream":"EVENTS","seq":42}`
+				fmt.Fprintf(conn, "MSG %s 1 %d\r\n%s\r\n", p.reply, len(ack), ack)
+			default:
+				hdr := "NATS/1.0 503\r\n\r\n"
+				fmt.Fprintf(conn, "HMSG %s 1 %d %d\r\n%s\r\n", p.reply, len(hdr), len(hdr), hdr)
+			}
+		case "PING":
+			io.WriteString(conn, "PONG\r\n")
+		}
+	}
+}
+
+func TestPublish(t *testing.T) {
+	s, got := fakeServer(t)
+	s.Token = "secret"
+	if err := Publish(s, "notify.webapp", []byte(`{"profile":"webapp"}`)); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	p := <-got
+	if p.subject != "notify.webapp" || p.reply != "" || p.payload != `{"profile":"webapp"}` {
+		t.Errorf("published %+v", p)
+	}
+	if p.connect["auth_token"] != "secret" || p.connect["headers"] != true {
+		t.Errorf("CONNECT = %v", p.connect)
+	}
+	if _, ok := p.connect["user"]; ok {
+		t.Error("empty user sent in CONNECT")
+	}
+}
+
+func TestPublishAuthError(t *testing.T) {
+	s, _ := fakeServer(t)
+	s.Token = "wrong"
+	err := Publish(s, "notify", []byte("{}"))
+	if err == nil || !strings.Contains(err.Error(), "Authorization Violation") {
+		t.Errorf("err = %v, want authorization error", err)
+	}
+}
+
+func TestPublishJetStream(t *testing.T) {
+	s, got := fakeServer(t)
+	ack, err := PublishJetStream(s, "events.notify", []byte(`{"text":"hi"}`))
+	if err != nil {
+		t.Fatalf("PublishJetStream: %v", err)
+	}
+	if ack != (Ack{Stream: "EVENTS", Seq: 42}) {
+		t.Errorf("ack = %+v", ack)
+	}
+	if p := <-got; !strings.HasPrefix(p.reply, "_INBOX.") {
+		t.Errorf("reply subject = %q", p.reply)
+	}
+
+	if _, err := PublishJetStream(s, "nostream.notify", []byte("{}")); err == nil || !strings.Contains(err.Error(), "no JetStream stream") {
+		t.Errorf("err = %v, want no-stream error", err)
+	}
+}
+
+func TestParseAckError(t *testing.T) {
+	_, err := parseAck([]byte(`{"error":{"code":503,"description":"insufficient resources"}}`))
+	if err == nil || !strings.Contains(err.Error(), "insufficient resources (503)") {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func TestParseURL(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Server
+		ok   bool
+	}{
+		{"nats://localhost", Server{Addr: "localhost:4222"}, true},
+		{"nats://s3cr3t@10.0.0.5:4223", Server{Addr: "10.0.0.5:4223", Token: "s3cr3t"}, true},
+		{"tls://notify:pw@nats.example.com", Server{Addr: "nats.example.com:4222", TLS: true, Username: "notify", Password: "pw"}, true},
+		{"redis://localhost", Server{}, false},
+		{"nats://", Server{}, false},
+	}
+	for _, tt := range tests {
+		got, err := ParseURL(tt.in)
+		if (err == nil) != tt.ok || got != tt.want {
+			t.Errorf("ParseURL(%q) = %+v, %v; want %+v, ok=%v", tt.in, got, err, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+// Package ntfy publishes notifications to an ntfy server (ntfy.sh or
+// self-hosted) using its JSON publishing API.
+package ntfy
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Mavwarf/notify/internal/httputil"
+)
+
+// DefaultServer is used when no server URL is configured.
+const DefaultServer = "https://ntfy.sh"
+
+// Message is one ntfy notification. Priority 0 leaves the server
+// default (3).
+type Message struct {
+	Topic    string   `json:"topic"`
+	Message  string   `json:"message"`
+	Title    string   `json:"title,omitempty"`
+	Priority int      `json:"priority,omitempty"`
+	Tags     []string `json:"tags,omitempty"`
+	Click    string   `json:"click,omitempty"`
+	Actions  []Action `json:"actions,omitempty"`
+}
+
+// Action is a notification action button.
+type Action struct {
+	Action string `json:"action"` // "view", "http", or "broadcast"
+	Label  string `json:"label"`
+	URL    string `json:"url,omitempty"`
+	Method string `json:"method,omitempty"` // http only (default POST)
+	Body   string `json:"body,omitempty"`   // http only
+	Clear  bool   `json:"clear,omitempty"`  // dismiss the notification when tapped
+}
+
+// priorities maps ntfy's priority names to their numeric levels.
+var priorities = map[string]int{
+	"min": 1, "low": 2, "default": 3, "high": 4, "urgent": 5, "max": 5,
+}
+
+// ParsePriority accepts a priority name ("high") or level ("4"). An empty
+// string returns 0 (server default).
+func ParsePriority(s string) (int, error) {
+	if s == "" {
+		return 0, nil
+	}
+	if p, ok := priorities[strings.ToLower(s)]; ok {
+		return p, nil
+	}
+	if p, err := strconv.Atoi(s); err == nil && p >= 1 && p <= 5 {
+		return p, nil
+	}
+	return 0, fmt.Errorf("priority %q must be min, low, default, high, urgent, or 1-5", s)
+}
+
+// Send publishes m to server (DefaultServer if empty). A non-empty token
+// is sent as a bearer access token.
+func Send(server, token string, m Message) error {
+	if server == "" {
+		server = DefaultServer
+	}
+	server = strings.TrimRight(server, "/")
+
+	body, err := json.Marshal(m)
+	if err != nil {
+		return fmt.Errorf("ntfy: marshal: %w", err)
+	}
+	req, err := http.NewRequest("POST", server, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("ntfy: new request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := httputil.Limited(server+"/"+m.Topic, func() (*http.Response, error) {
+		return httputil.Client.Do(req)
+	})
+	if err != nil {
+		return fmt.Errorf("ntfy: post: %w", err)
+	}
+	defer resp.Body.Close()
+
+	return httputil.CheckStatus(resp, "ntfy")
+}
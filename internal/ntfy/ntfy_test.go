@@ -0,0 +1,110 @@
+package ntfy
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSendSuccess(t *testing.T) {
+	var got Message
+	var gotAuth, gotContentType, gotPath string
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+		gotContentType = r.Header.Get("Content-Type")
+		gotPath = r.URL.Path
+		data, _ := io.ReadAll(r.Body)
+		json.Unmarshal(data, &got)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	m := Message{
+		Topic:    "builds",
+		Message:  "webapp failed",
+		Title:    "Webapp",
+		Priority: 4,
+		Tags:     []string{"warning", "skull"},
+		Click:    "https://ci.example.com/1",
+		Actions:  []Action{{Action: "view", Label: "Open CI", URL: "https://ci.example.com/1"}},
+	}
+	if err := Send(srv.URL+"/", "tk_secret", m); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if gotPath != "/" {
+		t.Errorf("path = %q, want / (JSON publishing posts to the server root)", gotPath)
+	}
+	if gotContentType != "application/json" {
+		t.Errorf("Content-Type = %q", gotContentType)
+	}
+	if gotAuth != "Bearer tk_secret" {
+		t.Errorf("Authorization = %q", gotAuth)
+	}
+	if got.Topic != "builds" || got.Message != "webapp failed" || got.Title != "Webapp" || got.Priority != 4 {
+		t.Errorf("message = %+v", got)
+	}
+	if len(got.Tags) != 2 || got.Click != m.Click || len(got.Actions) != 1 || got.Actions[0].Label != "Open CI" {
+		t.Errorf("extras = %+v", got)
+	}
+}
+
+func TestSendNoToken(t *testing.T) {
+	var gotAuth string
+	var raw map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+		data, _ := io.ReadAll(r.Body)
+		json.Unmarshal(data, &raw)
+	}))
+	defer srv.Close()
+
+	if err := Send(srv.URL, "", Message{Topic: "t", Message: "hi"}); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if gotAuth != "" {
+		t.Errorf("Authorization = %q, want none", gotAuth)
+	}
+	for _, k := range []string{"title", "priority", "tags", "click", "actions"} {
+		if _, ok := raw[k]; ok {
+			t.Errorf("unset field %q sent", k)
+		}
+	}
+}
+
+func TestSendError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer srv.Close()
+
+	if err := Send(srv.URL, "bad", Message{Topic: "t", Message: "hi"}); err == nil {
+		t.Fatal("expected error for 403 response")
+	}
+}
+
+func TestParsePriority(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int
+		ok   bool
+	}{
+		{"", 0, true},
+		{"min", 1, true},
+		{"High", 4, true},
+		{"urgent", 5, true},
+		{"max", 5, true},
+		{"3", 3, true},
+		{"0", 0, false},
+		{"6", 0, false},
+		{"loud", 0, false},
+	}
+	for _, tt := range tests {
+		got, err := ParsePriority(tt.in)
+		if (err == nil) != tt.ok || got != tt.want {
+			t.Errorf("ParsePriority(%q) = %d, %v; want %d, ok=%v", tt.in, got, err, tt.want, tt.ok)
+		}
+	}
+}
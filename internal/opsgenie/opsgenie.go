@@ -0,0 +1,74 @@
+// Package opsgenie creates and closes alerts through the Opsgenie Alerts
+// API, addressing them by alias so one failure maps to one alert.
+package opsgenie
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/Mavwarf/notify/internal/httputil"
+)
+
+// DefaultServer is the API host for US accounts; EU accounts use
+// https://api.eu.opsgenie.com.
+const DefaultServer = "https://api.opsgenie.com"
+
+// maxMessage is the longest alert message Opsgenie accepts.
+const maxMessage = 130
+
+// Alert is a new alert. Alias is the dedup key: creating an alert whose
+// alias is already open only bumps its count.
+type Alert struct {
+	Message     string            `json:"message"`
+	Alias       string            `json:"alias"`
+	Description string            `json:"description,omitempty"`
+	Priority    string            `json:"priority,omitempty"` // P1 (highest) to P5
+	Source      string            `json:"source,omitempty"`
+	Details     map[string]string `json:"details,omitempty"`
+}
+
+// Create opens an alert on server (DefaultServer if empty) with the API
+// integration key.
+func Create(server, apiKey string, a Alert) error {
+	if r := []rune(a.Message); len(r) > maxMessage {
+		a.Message = string(r[:maxMessage-1]) + "…"
+	}
+	return post(server, apiKey, "/v2/alerts", a)
+}
+
+// Close closes the open alert with the given alias.
+func Close(server, apiKey, alias, source string) error {
+	path := "/v2/alerts/" + url.PathEscape(alias) + "/close?identifierType=alias"
+	return post(server, apiKey, path, map[string]string{"source": source})
+}
+
+func post(server, apiKey, path string, v any) error {
+	if server == "" {
+		server = DefaultServer
+	}
+	server = strings.TrimRight(server, "/")
+	body, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("opsgenie: marshal: %w", err)
+	}
+	req, err := http.NewRequest("POST", server+path, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("opsgenie: new request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "GenieKey "+apiKey)
+
+	resp, err := httputil.Limited(server+"/v2/alerts", func() (*http.Response, error) {
+		return httputil.Client.Do(req)
+	})
+	if err != nil {
+		return fmt.Errorf("opsgenie: post: %w", err)
+	}
+	defer resp.Body.Close()
+
+	return httputil.CheckStatus(resp, "opsgenie")
+}
@@ -0,0 +1,74 @@
+package opsgenie
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCreate(t *testing.T) {
+	var got Alert
+	var gotAuth, gotPath string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+		gotPath = r.URL.Path
+		data, _ := io.ReadAll(r.Body)
+		json.Unmarshal(data, &got)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	a := Alert{Message: strings.Repeat("x", 200), Alias: "notify/webapp/make", Priority: "P1"}
+	if err := Create(srv.URL+"/", "k3y", a); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if gotPath != "/v2/alerts" {
+		t.Errorf("path = %q", gotPath)
+	}
+	if gotAuth != "GenieKey k3y" {
+		t.Errorf("Authorization = %q", gotAuth)
+	}
+	if got.Alias != a.Alias || got.Priority != "P1" {
+		t.Errorf("alert = %+v", got)
+	}
+	if n := len([]rune(got.Message)); n != maxMessage {
+		t.Errorf("message length = %d, want truncated to %d", n, maxMessage)
+	}
+}
+
+func TestClose(t *testing.T) {
+	var gotPath, gotQuery string
+	var got map[string]string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.EscapedPath()
+		gotQuery = r.URL.RawQuery
+		data, _ := io.ReadAll(r.Body)
+		json.Unmarshal(data, &got)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	if err := Close(srv.URL, "k3y", "notify/webapp/make", "buildbox"); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if gotPath != "/v2/alerts/notify%2Fwebapp%2Fmake/close" || gotQuery != "identifierType=alias" {
+		t.Errorf("request = %s?%s", gotPath, gotQuery)
+	}
+	if got["source"] != "buildbox" {
+		t.Errorf("body = %v", got)
+	}
+}
+
+func TestCreateError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	if err := Create(srv.URL, "bad", Alert{Message: "hi", Alias: "a"}); err == nil {
+		t.Fatal("expected error for 401 response")
+	}
+}
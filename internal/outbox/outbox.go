@@ -0,0 +1,307 @@
+// Package outbox persists remote notification steps that failed to deliver
+// so they can be retried later with exponential backoff.
+package outbox
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Mavwarf/notify/internal/config"
+	"github.com/Mavwarf/notify/internal/paths"
+	"github.com/Mavwarf/notify/internal/tmpl"
+)
+
+// Backoff schedule: the first retry is due baseDelay after the failure,
+// each further failure doubles the wait up to maxDelay. After MaxAttempts
+// failed deliveries the entry is no longer retried automatically but stays
+// in the outbox until it is retried manually or dropped.
+const (
+	baseDelay   = 30 * time.Second
+	maxDelay    = time.Hour
+	MaxAttempts = 10
+
+	// leaseDuration pushes an entry's next attempt into the future while
+	// it is being sent, so a concurrent notify process doesn't pick up the
+	// same entry and deliver it twice.
+	leaseDuration = 5 * time.Minute
+
+	// flushLimit caps how many entries an automatic (non-forced) flush
+	// attempts. Every notify invocation waits for that flush, so a long
+	// outbox built up while offline must not turn into minutes of serial
+	// sends. The oldest entries go first; the rest stay due.
+	flushLimit = 5
+)
+
+// flushBudget is how long an automatic flush keeps starting new sends.
+// Leased entries it doesn't reach are released, still due. Replaced in
+// tests.
+var flushBudget = 15 * time.Second
+
+// Entry is a single undelivered step. Credentials are deliberately not
+// stored; they are re-resolved from the config for Profile at retry time.
+type Entry struct {
+	ID          string      `json:"id"`
+	Created     time.Time   `json:"created"`
+	Profile     string      `json:"profile"`
+	Step        config.Step `json:"step"`
+	Vars        tmpl.Vars   `json:"vars"`
+	SendID      string      `json:"send_id,omitempty"`
+	Attempts    int         `json:"attempts"`
+	NextAttempt time.Time   `json:"next_attempt"`
+	LastError   string      `json:"last_error,omitempty"`
+}
+
+// GaveUp reports whether automatic retries have been exhausted.
+func (e Entry) GaveUp() bool {
+	return e.Attempts >= MaxAttempts
+}
+
+// Add queues a step whose delivery failed with sendErr. sendID is the
+// step's config.StepEnv.SendID, kept so retries reuse it. Errors are
+// printed to stderr but never fatal (best-effort).
+func Add(step config.Step, vars tmpl.Vars, sendID string, sendErr error) {
+	if err := add(outboxPath(), step, vars, sendID, sendErr); err != nil {
+		fmt.Fprintf(os.Stderr, "outbox: %v\n", err)
+	}
+}
+
+// List returns all queued entries, oldest first.
+func List() ([]Entry, error) {
+	return load(outboxPath())
+}
+
+// Drop removes the entry with the given ID. Returns false if no such
+// entry exists.
+func Drop(id string) (bool, error) {
+	return drop(outboxPath(), id)
+}
+
+// Clear removes all queued entries.
+func Clear() error {
+	return clearAll(outboxPath())
+}
+
+// Flush retries queued entries via send, oldest first. Normally only
+// entries whose backoff has elapsed are attempted, at most flushLimit of
+// them within flushBudget; force retries every entry (including ones that
+// gave up) immediately. A non-empty id restricts the flush to that single
+// entry. Delivered entries are removed; failed ones are rescheduled.
+// Returns the number of entries sent and still failing.
+func Flush(send func(Entry) error, force bool, id string) (sent, failed int) {
+	sent, failed, err := flush(outboxPath(), send, force, id)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "outbox: %v\n", err)
+	}
+	return sent, failed
+}
+
+// backoff returns the wait before the next attempt after the given number
+// of failed attempts.
+func backoff(attempts int) time.Duration {
+	d := baseDelay
+	for i := 1; i < attempts; i++ {
+		d *= 2
+		if d >= maxDelay {
+			return maxDelay
+		}
+	}
+	return d
+}
+
+func add(path string, step config.Step, vars tmpl.Vars, sendID string, sendErr error) error {
+	unlock, err := paths.Lock(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	entries, err := load(path)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	e := Entry{
+		ID:          newID(),
+		Created:     now,
+		Profile:     vars.Profile,
+		Step:        step,
+		Vars:        vars,
+		SendID:      sendID,
+		Attempts:    1,
+		NextAttempt: now.Add(backoff(1)),
+	}
+	if sendErr != nil {
+		e.LastError = sendErr.Error()
+	}
+	return save(path, append(entries, e))
+}
+
+func drop(path, id string) (bool, error) {
+	unlock, err := paths.Lock(path)
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+
+	entries, err := load(path)
+	if err != nil {
+		return false, err
+	}
+	for i, e := range entries {
+		if e.ID == id {
+			return true, save(path, append(entries[:i], entries[i+1:]...))
+		}
+	}
+	return false, nil
+}
+
+func clearAll(path string) error {
+	unlock, err := paths.Lock(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func flush(path string, send func(Entry) error, force bool, id string) (sent, failed int, err error) {
+	// Fast path: most invocations have nothing queued.
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return 0, 0, nil
+	}
+
+	// Phase 1: under the lock, pick due entries and lease them.
+	unlock, err := paths.Lock(path)
+	if err != nil {
+		return 0, 0, err
+	}
+	entries, err := load(path)
+	if err != nil {
+		unlock()
+		return 0, 0, err
+	}
+	now := time.Now()
+	var due []Entry
+	for i, e := range entries {
+		if id != "" && e.ID != id {
+			continue
+		}
+		if !force && (e.GaveUp() || now.Before(e.NextAttempt)) {
+			continue
+		}
+		if !force && len(due) == flushLimit {
+			break
+		}
+		entries[i].NextAttempt = now.Add(leaseDuration)
+		due = append(due, e)
+	}
+	if len(due) == 0 {
+		unlock()
+		return 0, 0, nil
+	}
+	err = save(path, entries)
+	unlock()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	// Phase 2: deliver without holding the lock (network calls are slow).
+	// An automatic flush stops starting sends once its budget is spent.
+	deadline := now.Add(flushBudget)
+	results := make(map[string]error, len(due))
+	leased := make(map[string]time.Time, len(due))
+	for _, e := range due {
+		if !force && len(results) > 0 && !time.Now().Before(deadline) {
+			leased[e.ID] = e.NextAttempt
+			continue
+		}
+		results[e.ID] = send(e)
+	}
+
+	// Phase 3: re-read (other processes may have added entries) and apply
+	// the outcomes.
+	unlock, err = paths.Lock(path)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer unlock()
+	entries, err = load(path)
+	if err != nil {
+		return 0, 0, err
+	}
+	now = time.Now()
+	kept := entries[:0]
+	for _, e := range entries {
+		if next, ok := leased[e.ID]; ok {
+			e.NextAttempt = next // not reached: release the lease
+		}
+		sendErr, attempted := results[e.ID]
+		if !attempted {
+			kept = append(kept, e)
+			continue
+		}
+		if sendErr == nil {
+			sent++
+			continue
+		}
+		failed++
+		e.Attempts++
+		e.LastError = sendErr.Error()
+		e.NextAttempt = now.Add(backoff(e.Attempts))
+		kept = append(kept, e)
+	}
+	return sent, failed, save(path, kept)
+}
+
+// load reads the outbox file. A missing file is an empty outbox; a corrupt
+// file is reported so queued notifications are never silently discarded.
+func load(path string) ([]Entry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var entries []Entry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return entries, nil
+}
+
+func save(path string, entries []Entry) error {
+	if len(entries) == 0 {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal: %w", err)
+	}
+	return paths.AtomicWrite(path, data)
+}
+
+// newID returns a short random hex identifier for an entry.
+func newID() string {
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%08x", time.Now().UnixNano()&0xffffffff)
+	}
+	return hex.EncodeToString(b)
+}
+
+func outboxPath() string {
+	return filepath.Join(paths.DataDir(), paths.OutboxFileName)
+}
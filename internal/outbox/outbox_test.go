@@ -0,0 +1,274 @@
+package outbox
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Mavwarf/notify/internal/config"
+	"github.com/Mavwarf/notify/internal/tmpl"
+)
+
+func TestAddAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "outbox.json")
+	step := config.Step{Type: "discord", Text: "build failed"}
+	vars := tmpl.Vars{Profile: "boss", Command: "make"}
+
+	if err := add(path, step, vars, "s1", errors.New("dial tcp: no route to host")); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+	entries, err := load(path)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("got %d entries, want 1", len(entries))
+	}
+	e := entries[0]
+	if e.Profile != "boss" || e.Step.Type != "discord" || e.Vars.Command != "make" {
+		t.Errorf("entry = %+v, want profile boss, discord step, command make", e)
+	}
+	if e.Attempts != 1 {
+		t.Errorf("Attempts = %d, want 1", e.Attempts)
+	}
+	if e.LastError != "dial tcp: no route to host" {
+		t.Errorf("LastError = %q", e.LastError)
+	}
+	if e.ID == "" {
+		t.Error("ID is empty")
+	}
+	if !e.NextAttempt.After(e.Created) {
+		t.Errorf("NextAttempt %v not after Created %v", e.NextAttempt, e.Created)
+	}
+}
+
+func TestLoadMissingFile(t *testing.T) {
+	entries, err := load(filepath.Join(t.TempDir(), "nonexistent.json"))
+	if err != nil || entries != nil {
+		t.Errorf("load(missing) = %v, %v; want nil, nil", entries, err)
+	}
+}
+
+func TestLoadCorruptFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "outbox.json")
+	os.WriteFile(path, []byte("{not json"), 0644)
+	if _, err := load(path); err == nil {
+		t.Error("expected error for corrupt outbox")
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{3, 2 * time.Minute},
+		{7, 32 * time.Minute},
+		{8, time.Hour},
+		{20, time.Hour},
+	}
+	for _, tt := range tests {
+		if got := backoff(tt.attempts); got != tt.want {
+			t.Errorf("backoff(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+// writeDue writes entries whose next attempt is already in the past.
+func writeDue(t *testing.T, path string, entries ...Entry) {
+	t.Helper()
+	for i := range entries {
+		if entries[i].NextAttempt.IsZero() {
+			entries[i].NextAttempt = time.Now().Add(-time.Second)
+		}
+	}
+	if err := save(path, entries); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFlushRemovesDelivered(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "outbox.json")
+	writeDue(t, path, Entry{ID: "a", Attempts: 1}, Entry{ID: "b", Attempts: 1})
+
+	var got []string
+	sent, failed, err := flush(path, func(e Entry) error {
+		got = append(got, e.ID)
+		return nil
+	}, false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sent != 2 || failed != 0 {
+		t.Errorf("sent, failed = %d, %d; want 2, 0", sent, failed)
+	}
+	if len(got) != 2 {
+		t.Errorf("send called for %v, want a and b", got)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("expected outbox file removed when empty")
+	}
+}
+
+func TestFlushReschedulesFailed(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "outbox.json")
+	writeDue(t, path, Entry{ID: "a", Attempts: 1})
+
+	before := time.Now()
+	_, failed, err := flush(path, func(Entry) error { return errors.New("timeout") }, false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if failed != 1 {
+		t.Errorf("failed = %d, want 1", failed)
+	}
+	entries, _ := load(path)
+	if len(entries) != 1 {
+		t.Fatalf("got %d entries, want 1", len(entries))
+	}
+	e := entries[0]
+	if e.Attempts != 2 {
+		t.Errorf("Attempts = %d, want 2", e.Attempts)
+	}
+	if e.LastError != "timeout" {
+		t.Errorf("LastError = %q, want %q", e.LastError, "timeout")
+	}
+	if e.NextAttempt.Before(before.Add(backoff(2))) {
+		t.Errorf("NextAttempt %v earlier than backoff(2) from now", e.NextAttempt)
+	}
+}
+
+func TestFlushSkipsNotDue(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "outbox.json")
+	writeDue(t, path,
+		Entry{ID: "later", Attempts: 1, NextAttempt: time.Now().Add(time.Hour)},
+		Entry{ID: "dead", Attempts: MaxAttempts},
+	)
+
+	called := 0
+	sent, failed, err := flush(path, func(Entry) error { called++; return nil }, false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if called != 0 || sent != 0 || failed != 0 {
+		t.Errorf("called=%d sent=%d failed=%d; want all 0", called, sent, failed)
+	}
+	entries, _ := load(path)
+	if len(entries) != 2 {
+		t.Errorf("got %d entries, want 2 untouched", len(entries))
+	}
+}
+
+func TestFlushForceAndID(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "outbox.json")
+	writeDue(t, path,
+		Entry{ID: "a", Attempts: 1, NextAttempt: time.Now().Add(time.Hour)},
+		Entry{ID: "b", Attempts: MaxAttempts},
+	)
+
+	var got []string
+	sent, _, err := flush(path, func(e Entry) error {
+		got = append(got, e.ID)
+		return nil
+	}, true, "b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sent != 1 || len(got) != 1 || got[0] != "b" {
+		t.Errorf("sent=%d got=%v; want only b", sent, got)
+	}
+	entries, _ := load(path)
+	if len(entries) != 1 || entries[0].ID != "a" {
+		t.Errorf("remaining = %+v, want only a", entries)
+	}
+}
+
+func TestFlushLimit(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "outbox.json")
+	var queued []Entry
+	for i := 0; i < flushLimit+2; i++ {
+		queued = append(queued, Entry{ID: fmt.Sprint(i), Attempts: 1})
+	}
+	writeDue(t, path, queued...)
+
+	var got []string
+	sent, _, err := flush(path, func(e Entry) error {
+		got = append(got, e.ID)
+		return nil
+	}, false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sent != flushLimit || got[0] != "0" || got[len(got)-1] != fmt.Sprint(flushLimit-1) {
+		t.Errorf("sent=%d got=%v; want the %d oldest", sent, got, flushLimit)
+	}
+	entries, _ := load(path)
+	if len(entries) != 2 || entries[0].NextAttempt.After(time.Now()) {
+		t.Errorf("remaining = %+v, want 2 entries still due", entries)
+	}
+
+	// A forced flush is not capped.
+	writeDue(t, path, queued...)
+	if sent, _, _ := flush(path, func(Entry) error { return nil }, true, ""); sent != len(queued) {
+		t.Errorf("forced flush sent %d, want %d", sent, len(queued))
+	}
+}
+
+func TestFlushBudget(t *testing.T) {
+	orig := flushBudget
+	flushBudget = 0
+	defer func() { flushBudget = orig }()
+
+	path := filepath.Join(t.TempDir(), "outbox.json")
+	writeDue(t, path, Entry{ID: "a", Attempts: 1}, Entry{ID: "b", Attempts: 1}, Entry{ID: "c", Attempts: 1})
+
+	// The budget is spent after the first send; b and c are released.
+	sent, failed, err := flush(path, func(Entry) error { return nil }, false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sent != 1 || failed != 0 {
+		t.Errorf("sent, failed = %d, %d; want 1, 0", sent, failed)
+	}
+	entries, _ := load(path)
+	if len(entries) != 2 || entries[0].ID != "b" || entries[0].Attempts != 1 || entries[0].NextAttempt.After(time.Now()) {
+		t.Errorf("remaining = %+v, want b and c still due and unattempted", entries)
+	}
+}
+
+func TestDrop(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "outbox.json")
+	writeDue(t, path, Entry{ID: "a"}, Entry{ID: "b"})
+
+	ok, err := drop(path, "a")
+	if err != nil || !ok {
+		t.Fatalf("drop(a) = %v, %v; want true, nil", ok, err)
+	}
+	ok, err = drop(path, "zzz")
+	if err != nil || ok {
+		t.Errorf("drop(zzz) = %v, %v; want false, nil", ok, err)
+	}
+	entries, _ := load(path)
+	if len(entries) != 1 || entries[0].ID != "b" {
+		t.Errorf("remaining = %+v, want only b", entries)
+	}
+}
+
+func TestClearAll(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "outbox.json")
+	writeDue(t, path, Entry{ID: "a"})
+	if err := clearAll(path); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("expected outbox file removed")
+	}
+	if err := clearAll(path); err != nil {
+		t.Errorf("clearAll on missing file: %v", err)
+	}
+}
@@ -0,0 +1,81 @@
+// Package pagerduty triggers and resolves incidents through the PagerDuty
+// Events API v2.
+package pagerduty
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/Mavwarf/notify/internal/httputil"
+)
+
+// DefaultServer is the Events API host; EU accounts use
+// https://events.eu.pagerduty.com.
+const DefaultServer = "https://events.pagerduty.com"
+
+// Event actions.
+const (
+	Trigger = "trigger"
+	Resolve = "resolve"
+)
+
+// Event is one Events API v2 event. Summary, Source, and Severity are
+// only sent with Trigger; a Resolve needs just the dedup key.
+type Event struct {
+	Action   string // Trigger or Resolve
+	DedupKey string
+	Summary  string
+	Source   string            // e.g. the hostname
+	Severity string            // critical, error, warning, or info
+	Details  map[string]string // custom_details
+}
+
+// payload is the request body of an event.
+type payload struct {
+	RoutingKey  string        `json:"routing_key"`
+	EventAction string        `json:"event_action"`
+	DedupKey    string        `json:"dedup_key"`
+	Payload     *eventPayload `json:"payload,omitempty"`
+}
+
+type eventPayload struct {
+	Summary       string            `json:"summary"`
+	Source        string            `json:"source"`
+	Severity      string            `json:"severity"`
+	CustomDetails map[string]string `json:"custom_details,omitempty"`
+}
+
+// Send enqueues e on server (DefaultServer if empty) for the service with
+// the integration routing key.
+func Send(server, routingKey string, e Event) error {
+	if server == "" {
+		server = DefaultServer
+	}
+	endpoint := strings.TrimRight(server, "/") + "/v2/enqueue"
+	p := payload{RoutingKey: routingKey, EventAction: e.Action, DedupKey: e.DedupKey}
+	if e.Action == Trigger {
+		p.Payload = &eventPayload{
+			Summary:       e.Summary,
+			Source:        e.Source,
+			Severity:      e.Severity,
+			CustomDetails: e.Details,
+		}
+	}
+	body, err := json.Marshal(p)
+	if err != nil {
+		return fmt.Errorf("pagerduty: marshal: %w", err)
+	}
+
+	resp, err := httputil.Limited(endpoint+"#"+routingKey, func() (*http.Response, error) {
+		return httputil.Post(endpoint, "application/json", bytes.NewReader(body))
+	})
+	if err != nil {
+		return fmt.Errorf("pagerduty: post: %w", err)
+	}
+	defer resp.Body.Close()
+
+	return httputil.CheckStatus(resp, "pagerduty: events API")
+}
@@ -0,0 +1,71 @@
+package pagerduty
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func capture(t *testing.T, e Event) map[string]any {
+	t.Helper()
+	var raw map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v2/enqueue" {
+			t.Errorf("path = %s", r.URL.Path)
+		}
+		data, _ := io.ReadAll(r.Body)
+		json.Unmarshal(data, &raw)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	if err := Send(srv.URL, "R0UT1NG", e); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	return raw
+}
+
+func TestTrigger(t *testing.T) {
+	raw := capture(t, Event{
+		Action:   Trigger,
+		DedupKey: "notify/webapp/make",
+		Summary:  "make failed",
+		Source:   "buildbox",
+		Severity: "error",
+		Details:  map[string]string{"Exit code": "2"},
+	})
+	if raw["routing_key"] != "R0UT1NG" || raw["event_action"] != "trigger" || raw["dedup_key"] != "notify/webapp/make" {
+		t.Errorf("event = %v", raw)
+	}
+	p, _ := raw["payload"].(map[string]any)
+	if p["summary"] != "make failed" || p["source"] != "buildbox" || p["severity"] != "error" {
+		t.Errorf("payload = %v", p)
+	}
+	if d, _ := p["custom_details"].(map[string]any); d["Exit code"] != "2" {
+		t.Errorf("custom_details = %v", p["custom_details"])
+	}
+}
+
+func TestResolveOmitsPayload(t *testing.T) {
+	raw := capture(t, Event{Action: Resolve, DedupKey: "notify/webapp/make", Summary: "ignored"})
+	if raw["event_action"] != "resolve" || raw["dedup_key"] != "notify/webapp/make" {
+		t.Errorf("event = %v", raw)
+	}
+	if _, ok := raw["payload"]; ok {
+		t.Error("resolve sent a payload")
+	}
+}
+
+func TestSendError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"status":"invalid event"}`))
+	}))
+	defer srv.Close()
+
+	if err := Send(srv.URL, "bad", Event{Action: Trigger}); err == nil {
+		t.Fatal("expected error for 400 response")
+	}
+}
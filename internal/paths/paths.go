@@ -2,18 +2,26 @@
 package paths
 
 import (
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 )
 
 const (
-	AppDirName       = "notify"
-	ConfigFileName   = "notify-config.json"
-	CooldownFileName = "cooldown.json"
-	SilentFileName   = "silent.json"
-	LogFileName      = "notify.log"
-	DirPerm  = 0755 // rwxr-xr-x — owner full, group/other read+execute
-	FilePerm = 0644 // rw-r--r-- — owner read+write, group/other read-only
+	AppDirName        = "notify"
+	ConfigFileName    = "notify-config.json"
+	CooldownFileName  = "cooldown.json"
+	SilentFileName    = "silent.json"
+	LogFileName       = "notify.log"
+	OutboxFileName    = "outbox.json"
+	RateLimitFileName = "ratelimit.json"
+	BatchFileName     = "batch.json"
+	IncidentFileName  = "incidents.json"
+	JobDirName        = "jobs"
+	DirPerm           = 0755 // rwxr-xr-x — owner full, group/other read+execute
+	FilePerm          = 0644 // rw-r--r-- — owner read+write, group/other read-only
 )
 
 // CooldownKey returns the map key for a profile/action pair.
@@ -39,6 +47,45 @@ func AtomicWrite(path string, data []byte) error {
 	return nil
 }
 
+// Lock timing: how long Lock waits for a held lock, how often it polls,
+// and the age after which a lock file is considered abandoned by a
+// crashed process and removed.
+const (
+	lockTimeout = 5 * time.Second
+	lockPoll    = 20 * time.Millisecond
+	lockStale   = 30 * time.Second
+)
+
+// Lock acquires an exclusive cross-process lock for path by creating
+// path + ".lock". Returns an unlock function that removes the lock file.
+// Used for read-modify-write cycles on state files that several notify
+// processes may update concurrently.
+func Lock(path string) (unlock func(), err error) {
+	if err := os.MkdirAll(filepath.Dir(path), DirPerm); err != nil {
+		return nil, err
+	}
+	lockPath := path + ".lock"
+	deadline := time.Now().Add(lockTimeout)
+	for {
+		f, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, FilePerm)
+		if err == nil {
+			_ = f.Close()
+			return func() { _ = os.Remove(lockPath) }, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, err
+		}
+		if info, sErr := os.Stat(lockPath); sErr == nil && time.Since(info.ModTime()) > lockStale {
+			_ = os.Remove(lockPath)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("timed out waiting for %s", lockPath)
+		}
+		time.Sleep(lockPoll)
+	}
+}
+
 // DataDir returns the data directory for notify: ~/.config/notify
 //
 // Falls back to os.TempDir()/notify if the home directory cannot be determined.
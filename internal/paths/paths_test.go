@@ -4,6 +4,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 )
 
 func TestCooldownKey(t *testing.T) {
@@ -33,3 +34,40 @@ func TestDataDirUsesHomeConfig(t *testing.T) {
 		t.Errorf("DataDir() = %q, want %q", got, want)
 	}
 }
+
+func TestLockExclusive(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	unlock, err := Lock(path)
+	if err != nil {
+		t.Fatalf("Lock: %v", err)
+	}
+	if _, err := os.Stat(path + ".lock"); err != nil {
+		t.Errorf("lock file missing while held: %v", err)
+	}
+	unlock()
+	if _, err := os.Stat(path + ".lock"); !os.IsNotExist(err) {
+		t.Errorf("lock file still present after unlock")
+	}
+	unlock2, err := Lock(path)
+	if err != nil {
+		t.Fatalf("Lock after unlock: %v", err)
+	}
+	unlock2()
+}
+
+func TestLockRemovesStale(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	lockPath := path + ".lock"
+	if err := os.WriteFile(lockPath, nil, FilePerm); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-2 * lockStale)
+	if err := os.Chtimes(lockPath, old, old); err != nil {
+		t.Fatal(err)
+	}
+	unlock, err := Lock(path)
+	if err != nil {
+		t.Fatalf("Lock with stale lock file: %v", err)
+	}
+	unlock()
+}
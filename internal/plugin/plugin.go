@@ -101,6 +101,30 @@ func buildEnv(text string, vars tmpl.Vars) []string {
 	if vars.Output != "" {
 		env = append(env, "NOTIFY_OUTPUT="+vars.Output)
 	}
+	if vars.BatchCount != "" {
+		env = append(env, "NOTIFY_BATCH_COUNT="+vars.BatchCount)
+	}
+	if vars.BatchList != "" {
+		env = append(env, "NOTIFY_BATCH_LIST="+vars.BatchList)
+	}
+	if vars.RepeatCount != "" {
+		env = append(env, "NOTIFY_REPEAT_COUNT="+vars.RepeatCount)
+	}
+	if vars.AckID != "" {
+		env = append(env, "NOTIFY_ACK_ID="+vars.AckID)
+	}
+	if vars.Severity != "" {
+		env = append(env, "NOTIFY_SEVERITY="+vars.Severity)
+	}
+	if vars.Action != "" {
+		env = append(env, "NOTIFY_ACTION="+vars.Action)
+	}
+	if vars.ExitCode != "" {
+		env = append(env, "NOTIFY_EXIT_CODE="+vars.ExitCode)
+	}
+	if vars.TmuxPane != "" {
+		env = append(env, "NOTIFY_TMUX_PANE="+vars.TmuxPane)
+	}
 	if vars.ClaudeMessage != "" {
 		env = append(env, "NOTIFY_CLAUDE_MESSAGE="+vars.ClaudeMessage)
 	}
@@ -0,0 +1,112 @@
+// Package pushover sends notifications via the Pushover Message API.
+package pushover
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/Mavwarf/notify/internal/httputil"
+)
+
+const endpoint = "https://api.pushover.net/1/messages.json"
+
+// Priority levels. Emergency repeats the alert every Retry seconds until
+// acknowledged on a device or Expire seconds pass.
+const (
+	PriorityLowest    = -2
+	PriorityLow       = -1
+	PriorityNormal    = 0
+	PriorityHigh      = 1
+	PriorityEmergency = 2
+)
+
+// Emergency-priority defaults and limits (seconds), from the API docs.
+const (
+	DefaultRetry  = 60
+	DefaultExpire = 3600
+	MinRetry      = 30
+	MaxExpire     = 10800
+)
+
+// Message is one Pushover notification. Zero values are omitted, so the
+// app's defaults apply.
+type Message struct {
+	Message  string
+	Title    string
+	Priority int
+	Sound    string // one of the app's sounds, e.g. "siren"
+	Device   string // device name(s), comma-separated (default: all)
+	URL      string // supplementary URL
+	URLTitle string
+	Retry    int // emergency only
+	Expire   int // emergency only
+}
+
+// priorities maps Pushover's priority names to their levels.
+var priorities = map[string]int{
+	"lowest": PriorityLowest, "low": PriorityLow, "normal": PriorityNormal,
+	"high": PriorityHigh, "emergency": PriorityEmergency,
+}
+
+// ParsePriority accepts a priority name ("high") or level ("-2" to "2").
+func ParsePriority(s string) (int, error) {
+	if p, ok := priorities[strings.ToLower(s)]; ok {
+		return p, nil
+	}
+	if p, err := strconv.Atoi(s); err == nil && p >= PriorityLowest && p <= PriorityEmergency {
+		return p, nil
+	}
+	return 0, fmt.Errorf("priority %q must be lowest, low, normal, high, emergency, or -2 to 2", s)
+}
+
+// Send posts m with the application token and user (or group) key.
+func Send(token, user string, m Message) error {
+	return sendTo(endpoint, token, user, m)
+}
+
+// sendTo posts m to the given endpoint. Extracted for testing.
+func sendTo(endpoint, token, user string, m Message) error {
+	form := url.Values{
+		"token":   {token},
+		"user":    {user},
+		"message": {m.Message},
+	}
+	set := func(k, v string) {
+		if v != "" {
+			form.Set(k, v)
+		}
+	}
+	set("title", m.Title)
+	set("sound", m.Sound)
+	set("device", m.Device)
+	set("url", m.URL)
+	set("url_title", m.URLTitle)
+	if m.Priority != PriorityNormal {
+		form.Set("priority", strconv.Itoa(m.Priority))
+	}
+	if m.Priority == PriorityEmergency {
+		retry, expire := m.Retry, m.Expire
+		if retry == 0 {
+			retry = DefaultRetry
+		}
+		if expire == 0 {
+			expire = DefaultExpire
+		}
+		form.Set("retry", strconv.Itoa(retry))
+		form.Set("expire", strconv.Itoa(expire))
+	}
+
+	// The rate limit key includes the app token: Pushover limits per app.
+	resp, err := httputil.Limited(endpoint+"#"+token, func() (*http.Response, error) {
+		return httputil.PostForm(endpoint, form)
+	})
+	if err != nil {
+		return fmt.Errorf("pushover: post: %w", err)
+	}
+	defer resp.Body.Close()
+
+	return httputil.CheckStatus(resp, "pushover: API")
+}
@@ -0,0 +1,93 @@
+package pushover
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestSendSuccess(t *testing.T) {
+	var got url.Values
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		got = r.PostForm
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	m := Message{
+		Message:  "webapp failed",
+		Title:    "Webapp",
+		Priority: PriorityHigh,
+		Sound:    "siren",
+		Device:   "phone",
+		URL:      "https://ci.example.com/1",
+	}
+	if err := sendTo(srv.URL, "app_tok", "user_key", m); err != nil {
+		t.Fatalf("sendTo: %v", err)
+	}
+	want := map[string]string{
+		"token": "app_tok", "user": "user_key", "message": "webapp failed", "title": "Webapp",
+		"priority": "1", "sound": "siren", "device": "phone", "url": "https://ci.example.com/1",
+	}
+	for k, v := range want {
+		if got.Get(k) != v {
+			t.Errorf("%s = %q, want %q", k, got.Get(k), v)
+		}
+	}
+	for _, k := range []string{"url_title", "retry", "expire"} {
+		if got.Has(k) {
+			t.Errorf("unset field %q sent", k)
+		}
+	}
+}
+
+func TestSendEmergencyDefaults(t *testing.T) {
+	var got url.Values
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		got = r.PostForm
+	}))
+	defer srv.Close()
+
+	if err := sendTo(srv.URL, "t", "u", Message{Message: "down", Priority: PriorityEmergency, Expire: 600}); err != nil {
+		t.Fatalf("sendTo: %v", err)
+	}
+	if got.Get("priority") != "2" || got.Get("retry") != "60" || got.Get("expire") != "600" {
+		t.Errorf("priority=%q retry=%q expire=%q, want 2 60 600", got.Get("priority"), got.Get("retry"), got.Get("expire"))
+	}
+}
+
+func TestSendError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	if err := sendTo(srv.URL, "bad", "u", Message{Message: "hi"}); err == nil {
+		t.Fatal("expected error for 400 response")
+	}
+}
+
+func TestParsePriority(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int
+		ok   bool
+	}{
+		{"lowest", -2, true},
+		{"High", 1, true},
+		{"emergency", 2, true},
+		{"-1", -1, true},
+		{"0", 0, true},
+		{"3", 0, false},
+		{"urgent", 0, false},
+	}
+	for _, tt := range tests {
+		got, err := ParsePriority(tt.in)
+		if (err == nil) != tt.ok || got != tt.want {
+			t.Errorf("ParsePriority(%q) = %d, %v; want %d, ok=%v", tt.in, got, err, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+// Package redis publishes notifications to Redis with PUBLISH (pub/sub
+// channels) or XADD (streams). It speaks just enough RESP for those
+// commands plus AUTH and SELECT, with a fresh connection per call.
+package redis
+
+import (
+	"bufio"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// timeout bounds the whole exchange, from dial to the last reply.
+const timeout = 5 * time.Second
+
+// Server is a parsed redis:// or rediss:// URL.
+type Server struct {
+	Addr     string // host:port
+	TLS      bool
+	Username string
+	Password string
+	DB       int
+}
+
+// ParseURL parses "redis://[user[:password]@]host[:port][/db]". rediss://
+// connects with TLS. The port defaults to 6379.
+func ParseURL(raw string) (Server, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return Server{}, fmt.Errorf("url %q: %w", raw, err)
+	}
+	if u.Scheme != "redis" && u.Scheme != "rediss" {
+		return Server{}, fmt.Errorf("url %q must start with redis:// or rediss://", raw)
+	}
+	if u.Hostname() == "" {
+		return Server{}, fmt.Errorf("url %q has no host", raw)
+	}
+	s := Server{Addr: u.Host, TLS: u.Scheme == "rediss"}
+	if u.Port() == "" {
+		s.Addr = net.JoinHostPort(u.Hostname(), "6379")
+	}
+	if u.User != nil {
+		s.Username = u.User.Username()
+		s.Password, _ = u.User.Password()
+	}
+	if db := strings.Trim(u.Path, "/"); db != "" {
+		if s.DB, err = strconv.Atoi(db); err != nil || s.DB < 0 {
+			return Server{}, fmt.Errorf("url %q: database %q must be a number", raw, db)
+		}
+	}
+	return s, nil
+}
+
+// Publish sends payload to a pub/sub channel.
+func Publish(s Server, channel string, payload []byte) error {
+	_, err := do(s, []string{"PUBLISH", channel, string(payload)})
+	return err
+}
+
+// XAdd appends payload to a stream as the entry's "payload" field and
+// returns the entry ID. maxLen > 0 trims the stream to about that many
+// entries.
+func XAdd(s Server, stream string, maxLen int, payload []byte) (string, error) {
+	cmd := []string{"XADD", stream}
+	if maxLen > 0 {
+		cmd = append(cmd, "MAXLEN", "~", strconv.Itoa(maxLen))
+	}
+	return do(s, append(cmd, "*", "payload", string(payload)))
+}
+
+// do connects, authenticates, selects the database, and runs cmd,
+// returning its reply.
+func do(s Server, cmd []string) (string, error) {
+	dialer := &net.Dialer{Timeout: timeout}
+	var conn net.Conn
+	var err error
+	if s.TLS {
+		host, _, _ := net.SplitHostPort(s.Addr)
+		conn, err = tls.DialWithDialer(dialer, "tcp", s.Addr, &tls.Config{ServerName: host})
+	} else {
+		conn, err = dialer.Dial("tcp", s.Addr)
+	}
+	if err != nil {
+		return "", fmt.Errorf("redis: dial: %w", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(timeout))
+
+	var cmds [][]string
+	switch {
+	case s.Username != "" && s.Password != "":
+		cmds = append(cmds, []string{"AUTH", s.Username, s.Password})
+	case s.Password != "":
+		cmds = append(cmds, []string{"AUTH", s.Password})
+	}
+	if s.DB != 0 {
+		cmds = append(cmds, []string{"SELECT", strconv.Itoa(s.DB)})
+	}
+	cmds = append(cmds, cmd)
+
+	// Pipeline everything, then read one reply per command.
+	w := bufio.NewWriter(conn)
+	for _, c := range cmds {
+		writeCommand(w, c)
+	}
+	if err := w.Flush(); err != nil {
+		return "", fmt.Errorf("redis: write: %w", err)
+	}
+	r := bufio.NewReader(conn)
+	var reply string
+	for _, c := range cmds {
+		if reply, err = readReply(r); err != nil {
+			return "", fmt.Errorf("redis: %s: %w", c[0], err)
+		}
+	}
+	return reply, nil
+}
+
+// writeCommand encodes cmd as a RESP array of bulk strings.
+func writeCommand(w *bufio.Writer, cmd []string) {
+	fmt.Fprintf(w, "*%d\r\n", len(cmd))
+	for _, arg := range cmd {
+		fmt.Fprintf(w, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+}
+
+// readReply reads one simple string, error, integer, or bulk string reply.
+func readReply(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return "", errors.New("empty reply")
+	}
+	switch line[0] {
+	case '+', ':':
+		return line[1:], nil
+	case '-':
+		return "", errors.New(line[1:])
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return "", fmt.Errorf("bad reply %q", line)
+		}
+		if n < 0 {
+			return "", nil
+		}
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return "", err
+		}
+		return string(buf[:n]), nil
+	}
+	return "", fmt.Errorf("unexpected reply %q", line)
+}
@@ -0,0 +1,139 @@
+package redis
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// fakeServer answers RESP commands on a local port and reports each
+// command it received. AUTH succeeds only with the password "secret".
+func fakeServer(t *testing.T) (Server, <-chan []string) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	got := make(chan []string, 10)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go serve(conn, got)
+		}
+	}()
+	return Server{Addr: ln.Addr().String()}, got
+}
+
+func serve(conn net.Conn, got chan<- []string) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	for {
+		cmd, err := readCommand(r)
+		if err != nil {
+			return
+		}
+		got <- cmd
+		switch strings.ToUpper(cmd[0]) {
+		case "AUTH":
+			if cmd[len(cmd)-1] != "secret" {
+				io.WriteString(conn, "-WRONGPASS invalid username-password pair\r\n")
+				continue
+			}
+			io.WriteString(conn, "+OK\r\n")
+		case "SELECT":
+			io.WriteString(conn, "+OK\r\n")
+		case "PUBLISH":
+			io.WriteString(conn, ":2\r\n")
+		case "XADD":
+			io.WriteString(conn, "$15\r\n1760693400000-0\r\n")
+		default:
+			fmt.Fprintf(conn, "-ERR unknown command '%s'\r\n", cmd[0])
+		}
+	}
+}
+
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	n, _ := strconv.Atoi(strings.TrimSpace(line[1:]))
+	cmd := make([]string, n)
+	for i := range cmd {
+		if _, err := r.ReadString('\n'); err != nil {
+			return nil, err
+		}
+		arg, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		cmd[i] = strings.TrimSuffix(arg, "\r\n")
+	}
+	return cmd, nil
+}
+
+func TestPublish(t *testing.T) {
+	s, got := fakeServer(t)
+	s.Password, s.DB = "secret", 2
+	if err := Publish(s, "notify", []byte(`{"profile":"webapp"}`)); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	want := []string{"AUTH secret", "SELECT 2", `PUBLISH notify {"profile":"webapp"}`}
+	for _, w := range want {
+		if cmd := strings.Join(<-got, " "); cmd != w {
+			t.Errorf("command = %q, want %q", cmd, w)
+		}
+	}
+}
+
+func TestXAdd(t *testing.T) {
+	s, got := fakeServer(t)
+	id, err := XAdd(s, "notify:events", 1000, []byte(`{"text":"hi"}`))
+	if err != nil {
+		t.Fatalf("XAdd: %v", err)
+	}
+	if id != "1760693400000-0" {
+		t.Errorf("id = %q", id)
+	}
+	if cmd := strings.Join(<-got, " "); cmd != `XADD notify:events MAXLEN ~ 1000 * payload {"text":"hi"}` {
+		t.Errorf("command = %q", cmd)
+	}
+}
+
+func TestAuthError(t *testing.T) {
+	s, _ := fakeServer(t)
+	s.Username, s.Password = "notify", "wrong"
+	err := Publish(s, "notify", []byte("{}"))
+	if err == nil || !strings.Contains(err.Error(), "AUTH: WRONGPASS") {
+		t.Errorf("err = %v, want AUTH error", err)
+	}
+}
+
+func TestParseURL(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Server
+		ok   bool
+	}{
+		{"redis://localhost", Server{Addr: "localhost:6379"}, true},
+		{"redis://:pw@10.0.0.5:6380/3", Server{Addr: "10.0.0.5:6380", Password: "pw", DB: 3}, true},
+		{"rediss://notify:pw@cache.example.com", Server{Addr: "cache.example.com:6379", TLS: true, Username: "notify", Password: "pw"}, true},
+		{"http://localhost", Server{}, false},
+		{"redis://", Server{}, false},
+		{"redis://localhost/db", Server{}, false},
+	}
+	for _, tt := range tests {
+		got, err := ParseURL(tt.in)
+		if (err == nil) != tt.ok || got != tt.want {
+			t.Errorf("ParseURL(%q) = %+v, %v; want %+v, ok=%v", tt.in, got, err, tt.want, tt.ok)
+		}
+	}
+}
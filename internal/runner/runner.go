@@ -2,60 +2,29 @@
 package runner
 
 import (
+	"crypto/rand"
 	"errors"
 	"fmt"
 	"os"
+	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
 
-	"github.com/Mavwarf/notify/internal/audio"
 	"github.com/Mavwarf/notify/internal/config"
-	"github.com/Mavwarf/notify/internal/discord"
-	"github.com/Mavwarf/notify/internal/ffmpeg"
-	"github.com/Mavwarf/notify/internal/mqtt"
-	"github.com/Mavwarf/notify/internal/plugin"
-	"github.com/Mavwarf/notify/internal/slack"
-	"github.com/Mavwarf/notify/internal/speech"
-	"github.com/Mavwarf/notify/internal/telegram"
+	"github.com/Mavwarf/notify/internal/holiday"
+	"github.com/Mavwarf/notify/internal/outbox"
+	// Built-in step handlers register themselves with config.RegisterStep.
+	_ "github.com/Mavwarf/notify/internal/steps"
 	"github.com/Mavwarf/notify/internal/tmpl"
-	"github.com/Mavwarf/notify/internal/toast"
-	"github.com/Mavwarf/notify/internal/voice"
-	"github.com/Mavwarf/notify/internal/webhook"
 )
 
-// remoteVolume is used for TTS in remote voice steps. Volume control
-// is left to the receiving side, so we always render at full volume.
+// remoteVolume is the volume for outbox retries, which only hold remote
+// steps. Volume control is left to the receiving side.
 const remoteVolume = 100
 
-// ttsToTempFile renders text to a temporary WAV file via TTS and returns the
-// file path plus a cleanup function that removes the temp file. If a cached
-// AI voice exists for the text, returns the cached path with a no-op cleanup.
-func ttsToTempFile(prefix, text string) (path string, cleanup func(), err error) {
-	// Check voice cache first.
-	if cache, cErr := voice.OpenCache(); cErr == nil {
-		if wavPath, ok := cache.Lookup(text); ok {
-			return wavPath, func() {}, nil
-		}
-	}
-
-	f, err := os.CreateTemp("", prefix)
-	if err != nil {
-		return "", nil, fmt.Errorf("temp file: %w", err)
-	}
-	path = f.Name()
-	if err := f.Close(); err != nil {
-		return "", nil, fmt.Errorf("close temp: %w", err)
-	}
-	if err := speech.SayToFile(text, remoteVolume, path); err != nil {
-		_ = os.Remove(path)
-		return "", nil, fmt.Errorf("tts: %w", err)
-	}
-	cleanup = func() { _ = os.Remove(path) }
-	return path, cleanup, nil
-}
-
 const retryDelay = 2 * time.Second
 
 // retryOnce calls fn and, if it fails, waits and tries once more.
@@ -70,22 +39,42 @@ func retryOnce(fn func() error) error {
 }
 
 // sequential returns true for step types that use the audio pipeline
-// and must run one after another.
+// and must run one after another (see config.StepHandler.Sequential).
 func sequential(typ string) bool {
-	return typ == "sound" || typ == "say"
+	h, ok := config.LookupStep(typ)
+	return ok && h.Sequential()
+}
+
+// Conditions is the invocation state that step "when" conditions are
+// evaluated against, plus the step types the severity routing table
+// allows.
+type Conditions struct {
+	AFK      bool          // user idle at or above the AFK threshold
+	Run      bool          // invoked via `notify run` (or watch/shell hook)
+	Elapsed  time.Duration // wrapped command duration (0 = non-run context)
+	ExitCode *int          // wrapped command exit code (nil = unknown)
+	Output   string        // captured output of the wrapped command
+	Holidays *holiday.Set  // dates matched by "holiday" (nil = none)
+	Repeat   bool          // dedup follow-up reporting repeats within the cooldown window
+	Route    []string      // step types allowed by config.Options.RouteFor (nil = all)
 }
 
 // FilterSteps returns only the steps that should run given the current
-// AFK state, invocation mode, and elapsed time. Steps with When="" always
-// run; "afk"/"present" filter on idle state; "run"/"direct" filter on
-// whether the invocation came from `notify run`; "hours:X-Y" filters on
-// the current hour (24h local time); "long:DURATION" filters on elapsed
-// time (0 = non-run context, always skipped).
-func FilterSteps(steps []config.Step, afk, run bool, elapsed time.Duration) []config.Step {
+// conditions. Steps with When="" always run; "afk"/"present" filter on
+// idle state; "run"/"direct" filter on whether the invocation came from
+// `notify run`; "hours:X-Y" filters on the current hour (24h local time);
+// "long:DURATION" filters on elapsed time (0 = non-run context, always
+// skipped); "exit:SPEC" and "output:/REGEX/" filter on the wrapped
+// command's result; "days:", "date:", and "holiday" filter on the
+// calendar; "repeat" matches dedup follow-ups. Conditions can be combined with "and", "or", "not",
+// and parentheses (see config.ParseWhen). Steps whose type is not in
+// c.Route are skipped regardless of their condition, unless the routing
+// table added them (see Routed).
+func FilterSteps(steps []config.Step, c Conditions) []config.Step {
 	now := time.Now()
 	out := make([]config.Step, 0, len(steps))
 	for _, s := range steps {
-		if matchWhen(s.When, afk, run, now, elapsed) {
+		if Routed(s, c) && matchWhen(s, c, now) {
 			out = append(out, s)
 		}
 	}
@@ -94,46 +83,155 @@ func FilterSteps(steps []config.Step, afk, run bool, elapsed time.Duration) []co
 
 // FilteredIndices returns a boolean map indicating which step indices
 // would run. Used by dry-run to mark each step as RUN or SKIP.
-func FilteredIndices(steps []config.Step, afk, run bool, elapsed time.Duration) map[int]bool {
+func FilteredIndices(steps []config.Step, c Conditions) map[int]bool {
 	now := time.Now()
 	m := make(map[int]bool, len(steps))
 	for i, s := range steps {
-		if matchWhen(s.When, afk, run, now, elapsed) {
+		if Routed(s, c) && matchWhen(s, c, now) {
 			m[i] = true
 		}
 	}
 	return m
 }
 
-// matchWhen evaluates a single "when" condition string against the
-// current state. Unknown conditions return false (fail-closed) with a
-// warning printed to stderr.
-func matchWhen(when string, afk, run bool, now time.Time, elapsed time.Duration) bool {
-	switch when {
-	case "":
+// Routed reports whether the severity routing table lets a step run: its
+// type is allowed by c.Route, or the table added the step itself.
+// Fallback steps are not routed: they run whenever their parent fails.
+func Routed(s config.Step, c Conditions) bool {
+	return s.Routed || c.Route == nil || slices.Contains(c.Route, s.Type)
+}
+
+// ExplainWhen returns the sub-clauses of a step's "when" condition that
+// evaluate to false in the given state, for dry-run output. Returns nil
+// when the step would run.
+func ExplainWhen(s config.Step, c Conditions) []string {
+	expr, err := s.Condition()
+	if err != nil {
+		return []string{err.Error()}
+	}
+	if expr == nil {
+		return nil
+	}
+	now := time.Now()
+	return expr.Explain(func(atom *config.WhenExpr) bool {
+		return matchAtom(atom, c, now)
+	})
+}
+
+// matchWhen evaluates a step's "when" condition against the current
+// state, using the expression parsed at config load when there is one.
+// Conditions that don't parse return false (fail-closed) with a warning
+// printed to stderr.
+func matchWhen(s config.Step, c Conditions, now time.Time) bool {
+	expr, err := s.Condition()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: %v, skipping step\n", err)
+		return false
+	}
+	if expr == nil {
 		return true
+	}
+	return expr.Eval(func(atom *config.WhenExpr) bool {
+		return matchAtom(atom, c, now)
+	})
+}
+
+// matchAtom evaluates a single condition atom such as "afk" or
+// "hours:8-22". Unknown atoms return false (fail-closed) with a warning
+// printed to stderr.
+func matchAtom(atom *config.WhenExpr, c Conditions, now time.Time) bool {
+	when := atom.Atom
+	switch when {
 	case "never":
 		return false
 	case "afk":
-		return afk
+		return c.AFK
 	case "present":
-		return !afk
+		return !c.AFK
 	case "run":
-		return run
+		return c.Run
 	case "direct":
-		return !run
+		return !c.Run
+	case "holiday":
+		return c.Holidays.Contains(now)
+	case "repeat":
+		return c.Repeat
 	default:
 		if strings.HasPrefix(when, "hours:") {
 			return matchHours(when[6:], now)
 		}
 		if strings.HasPrefix(when, "long:") {
-			return matchLong(when[5:], elapsed)
+			return matchLong(when[5:], c.Elapsed)
+		}
+		if strings.HasPrefix(when, "exit:") {
+			return matchExit(when[5:], c.ExitCode)
+		}
+		if strings.HasPrefix(when, "output:") {
+			return matchOutput(atom.Output, c.Run, c.Output)
+		}
+		if strings.HasPrefix(when, "days:") {
+			return matchDays(when[5:], now)
+		}
+		if strings.HasPrefix(when, "date:") {
+			return matchDate(when[5:], now)
 		}
 		fmt.Fprintf(os.Stderr, "warning: unknown when condition %q, skipping step\n", when)
 		return false
 	}
 }
 
+// matchExit checks the wrapped command's exit code against spec: "N",
+// "A-B" (inclusive range), or either form prefixed with "!" to negate.
+// Returns false when the exit code is unknown (direct invocation) or on
+// parse errors.
+func matchExit(spec string, code *int) bool {
+	if code == nil {
+		return false
+	}
+	negate := strings.HasPrefix(spec, "!")
+	lo, hi, err := config.ParseExitSpec(strings.TrimPrefix(spec, "!"))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: %v, skipping step\n", err)
+		return false
+	}
+	in := *code >= lo && *code <= hi
+	return in != negate
+}
+
+// matchOutput reports whether the wrapped command's captured output
+// matches re, the pattern compiled when the condition was parsed. Always
+// false outside run mode, where there is no command output.
+func matchOutput(re *regexp.Regexp, run bool, output string) bool {
+	if !run || re == nil {
+		return false
+	}
+	return re.MatchString(output)
+}
+
+// matchDays reports whether now's weekday is in a spec like "mon-fri" or
+// "sat,sun". Returns false (skip step) on parse errors.
+func matchDays(spec string, now time.Time) bool {
+	days, err := config.ParseDaysSpec(spec)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: %v, skipping step\n", err)
+		return false
+	}
+	return days[now.Weekday()]
+}
+
+// matchDate reports whether now's local date falls within a spec like
+// "2026-12-24" or "2026-12-24..2027-01-02" (inclusive). Returns false
+// (skip step) on parse errors.
+func matchDate(spec string, now time.Time) bool {
+	from, to, err := config.ParseDateSpec(spec)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: %v, skipping step\n", err)
+		return false
+	}
+	today := now.Format("2006-01-02")
+	return today >= from && today <= to
+}
+
 // matchLong returns true when elapsed time meets or exceeds the threshold
 // parsed from spec (e.g. "5m", "30s"). Returns false if elapsed is zero
 // (non-run context like direct invocation or dry-run) or on parse errors.
@@ -176,16 +274,70 @@ func matchHours(spec string, now time.Time) bool {
 	return h >= start || h < end
 }
 
-// Execute runs the given steps (already filtered by the caller).
-// Remote steps (discord, slack, telegram, webhook, mqtt, toast, plugin) are
-// fired in parallel via goroutines so network latency doesn't serialize.
-// Audio steps (sound, say) run sequentially to avoid overlapping playback
-// on the local speaker. Both groups execute concurrently with each other.
-func Execute(steps []config.Step, defaultVolume int, creds config.Credentials, vars tmpl.Vars, desktop *int) error {
+// Step result statuses reported in StepResult.Status.
+const (
+	StatusOK       = "ok"       // delivered
+	StatusFailed   = "failed"   // delivery failed and was not queued
+	StatusQueued   = "queued"   // delivery failed; step written to the outbox
+	StatusSkipped  = "skipped"  // not attempted (an earlier audio step failed)
+	StatusFallback = "fallback" // failed, but its fallback steps delivered
+)
+
+// StepResult describes how a single executed step went. Index refers to
+// the position in the steps slice passed to Execute.
+type StepResult struct {
+	Index    int
+	Type     string
+	Status   string
+	Error    string
+	Latency  time.Duration
+	Attempts int
+}
+
+// errQueued marks step errors whose step was written to the outbox.
+var errQueued = config.ErrQueued
+
+// Execute runs the given steps (already filtered by the caller) and returns
+// one result per step, in input order, plus the joined error of all failed
+// steps. Remote steps (discord, slack, telegram, webhook, mqtt, toast,
+// plugin) are fired in parallel via goroutines so network latency doesn't
+// serialize. Audio steps (sound, say; any handler reporting Sequential) run
+// sequentially to avoid overlapping playback on the local speaker. Both groups execute concurrently with each other.
+// A step that fails runs its fallback steps (see execFallback) before its
+// result is recorded.
+func Execute(steps []config.Step, defaultVolume int, creds config.Credentials, vars tmpl.Vars, desktop *int) ([]StepResult, error) {
 
 	var wg sync.WaitGroup
 	var mu sync.Mutex
 	var parallelErrs []error
+	results := make([]StepResult, len(steps))
+
+	// run executes one step (and its fallback chain, if it fails) and
+	// records its result at results[idx].
+	run := func(idx int, s config.Step) error {
+		start := time.Now()
+		attempts, err := stepExec(s, defaultVolume, creds, vars, desktop)
+		r := StepResult{Index: idx, Type: s.Type, Status: StatusOK, Attempts: attempts}
+		if err != nil && len(s.Fallback) > 0 {
+			if fbErr := execFallback(s.Fallback, defaultVolume, creds, vars, desktop); fbErr != nil {
+				err = fmt.Errorf("%w; fallback: %w", err, fbErr)
+			} else {
+				r.Status = StatusFallback
+				r.Error = err.Error()
+				err = nil
+			}
+		}
+		r.Latency = time.Since(start)
+		if err != nil {
+			r.Status = StatusFailed
+			if errors.Is(err, errQueued) {
+				r.Status = StatusQueued
+			}
+			r.Error = err.Error()
+		}
+		results[idx] = r
+		return err
+	}
 
 	// Launch non-sequential (remote/network) steps in parallel immediately.
 	for i, step := range steps {
@@ -195,7 +347,7 @@ func Execute(steps []config.Step, defaultVolume int, creds config.Credentials, v
 		wg.Add(1)
 		go func(idx int, s config.Step) {
 			defer wg.Done()
-			if err := stepExec(s, defaultVolume, creds, vars, desktop); err != nil {
+			if err := run(idx, s); err != nil {
 				mu.Lock()
 				parallelErrs = append(parallelErrs, fmt.Errorf("step %d (%s): %w", idx+1, s.Type, err))
 				mu.Unlock()
@@ -203,15 +355,19 @@ func Execute(steps []config.Step, defaultVolume int, creds config.Credentials, v
 		}(i, step)
 	}
 
-	// Run sequential (audio-pipeline) steps in order.
+	// Run sequential (audio-pipeline) steps in order. After a failure the
+	// remaining audio steps are not attempted.
 	var seqErr error
 	for i, step := range steps {
 		if !sequential(step.Type) {
 			continue
 		}
-		if err := stepExec(step, defaultVolume, creds, vars, desktop); err != nil {
+		if seqErr != nil {
+			results[i] = StepResult{Index: i, Type: step.Type, Status: StatusSkipped}
+			continue
+		}
+		if err := run(i, step); err != nil {
 			seqErr = fmt.Errorf("step %d (%s): %w", i+1, step.Type, err)
-			break
 		}
 	}
 
@@ -219,116 +375,105 @@ func Execute(steps []config.Step, defaultVolume int, creds config.Credentials, v
 	wg.Wait()
 
 	if seqErr != nil {
-		return seqErr
+		return results, seqErr
 	}
 	if len(parallelErrs) > 0 {
-		return errors.Join(parallelErrs...)
+		return results, errors.Join(parallelErrs...)
 	}
-	return nil
+	return results, nil
 }
 
-// stepExec is the function used to execute a single step. It can be
-// replaced in tests to avoid real audio/network calls.
+// audioMu serializes audio steps that run as fallbacks of remote steps
+// (inside their goroutine) with the sequential audio steps of Execute.
+var audioMu sync.Mutex
+
+// execFallback runs a failed step's fallback steps in order, replacing its
+// delivery. A fallback that fails runs its own fallback chain in turn.
+// Returns the joined error of every fallback that could not be delivered.
+func execFallback(steps []config.Step, defaultVolume int, creds config.Credentials, vars tmpl.Vars, desktop *int) error {
+	var errs []error
+	for i, s := range steps {
+		_, err := stepExec(s, defaultVolume, creds, vars, desktop)
+		if err != nil && len(s.Fallback) > 0 {
+			if fbErr := execFallback(s.Fallback, defaultVolume, creds, vars, desktop); fbErr != nil {
+				err = fmt.Errorf("%w; fallback: %w", err, fbErr)
+			} else {
+				err = nil
+			}
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%d (%s): %w", i+1, s.Type, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// stepExec is the function used to execute a single step. It returns the
+// number of delivery attempts made. It can be replaced in tests to avoid
+// real audio/network calls.
 var stepExec = execStep
 
-// execStep dispatches a single step to the appropriate notification backend
-// based on its type (sound, say, toast, discord, slack, telegram, webhook, etc.).
-// Template variables are expanded just before delivery. Remote steps use
-// retryOnce to tolerate transient network failures.
-func execStep(step config.Step, defaultVolume int, creds config.Credentials, vars tmpl.Vars, desktop *int) error {
+// outboxAdd queues a failed remote step for later retry. It can be
+// replaced in tests to keep the real outbox file untouched.
+var outboxAdd = outbox.Add
+
+// execStep dispatches a single step to the handler registered for its type
+// (see internal/steps).
+// Template variables are expanded just before delivery. Each call draws a
+// new SendID for the step, shared by its retry and outbox entry. Remote steps use
+// retryOnce to tolerate transient network failures; if the retry fails too,
+// the step is written to the persistent outbox for later delivery, unless
+// it has fallback steps to take over instead. Audio steps hold audioMu.
+// Returns the number of delivery attempts (1 for local steps).
+func execStep(step config.Step, defaultVolume int, creds config.Credentials, vars tmpl.Vars, desktop *int) (int, error) {
+	if sequential(step.Type) {
+		audioMu.Lock()
+		defer audioMu.Unlock()
+	}
+	attempts := 1
+	sendID := rand.Text()
+	err := dispatch(step, defaultVolume, creds, vars, desktop, sendID, func(send func() error) error {
+		attempts = 0
+		err := retryOnce(func() error {
+			attempts++
+			return send()
+		})
+		if err != nil && len(step.Fallback) == 0 {
+			outboxAdd(step, vars, sendID, err)
+			return fmt.Errorf("%w (%w)", err, errQueued)
+		}
+		return err
+	})
+	return attempts, err
+}
+
+// dispatch hands a step to its registered handler; it is shared by
+// execStep and outbox retries. Remote network calls are routed through
+// deliver, which decides the retry policy; local preparation (TTS
+// rendering, conversion) happens outside it so a missing TTS engine is
+// never queued for retry.
+func dispatch(step config.Step, defaultVolume int, creds config.Credentials, vars tmpl.Vars, desktop *int, sendID string, deliver func(func() error) error) error {
 	vol := defaultVolume
 	if step.Volume != nil {
 		vol = *step.Volume
 	}
 
-	switch step.Type {
-	case "sound":
-		return audio.Play(step.Sound, float64(vol)/100.0)
-	case "say":
-		text := tmpl.Expand(step.Text, vars)
-		// Fail-open voice cache: if the cache can't be opened or has no entry
-		// for this text, fall through to system TTS rather than returning an error.
-		if cache, err := voice.OpenCache(); err == nil {
-			if wavPath, ok := cache.Lookup(text); ok {
-				return audio.Play(wavPath, float64(vol)/100.0)
-			}
-		}
-		return speech.Say(text, vol)
-	case "toast":
-		title := step.Title
-		if title == "" {
-			title = vars.Profile
-		}
-		return toast.Show(tmpl.Expand(title, vars), tmpl.Expand(step.Message, vars), desktop)
-	case "discord":
-		msg := tmpl.Expand(step.Text, vars)
-		return retryOnce(func() error { return discord.Send(creds.DiscordWebhook, msg) })
-	case "discord_voice":
-		text := tmpl.Expand(step.Text, vars)
-		wavPath, cleanup, err := ttsToTempFile("notify-voice-*.wav", text)
-		if err != nil {
-			return fmt.Errorf("discord_voice: %w", err)
-		}
-		defer cleanup()
-		return retryOnce(func() error { return discord.SendVoice(creds.DiscordWebhook, wavPath, text) })
-	case "slack":
-		msg := tmpl.Expand(step.Text, vars)
-		return retryOnce(func() error { return slack.Send(creds.SlackWebhook, msg) })
-	case "telegram":
-		msg := tmpl.Expand(step.Text, vars)
-		return retryOnce(func() error { return telegram.Send(creds.TelegramToken, creds.TelegramChatID, msg) })
-	case "telegram_audio":
-		text := tmpl.Expand(step.Text, vars)
-		wavPath, cleanup, err := ttsToTempFile("notify-tgaudio-*.wav", text)
-		if err != nil {
-			return fmt.Errorf("telegram_audio: %w", err)
-		}
-		defer cleanup()
-		return retryOnce(func() error { return telegram.SendAudio(creds.TelegramToken, creds.TelegramChatID, wavPath, text) })
-	case "telegram_voice":
-		text := tmpl.Expand(step.Text, vars)
-		// Two temp files are created: WAV (from TTS) and OGG (converted for Telegram).
-		// Each needs its own deferred cleanup. wavCleanup may be a no-op if using
-		// a cached AI voice file; the OGG is always a fresh temp file.
-		wavPath, wavCleanup, err := ttsToTempFile("notify-tgvoice-*.wav", text)
-		if err != nil {
-			return fmt.Errorf("telegram_voice: %w", err)
-		}
-		defer wavCleanup()
-		oggFile, err := os.CreateTemp("", "notify-tgvoice-*.ogg")
-		if err != nil {
-			return fmt.Errorf("telegram_voice ogg temp file: %w", err)
-		}
-		oggPath := oggFile.Name()
-		if err := oggFile.Close(); err != nil {
-			return fmt.Errorf("telegram_voice close ogg temp: %w", err)
-		}
-		if err := ffmpeg.ToOGG(wavPath, oggPath); err != nil {
-			return fmt.Errorf("telegram_voice convert: %w", err)
-		}
-		defer func() { _ = os.Remove(oggPath) }()
-		return retryOnce(func() error { return telegram.SendVoice(creds.TelegramToken, creds.TelegramChatID, oggPath, text) })
-	case "webhook":
-		msg := tmpl.Expand(step.Text, vars)
-		return retryOnce(func() error { return webhook.Send(step.URL, msg, step.Headers) })
-	case "plugin":
-		text := ""
-		if step.Text != "" {
-			text = tmpl.Expand(step.Text, vars)
-		}
-		return plugin.Run(step.Command, text, step.Timeout, vars)
-	case "mqtt":
-		msg := tmpl.Expand(step.Text, vars)
-		qos := byte(0)
-		if step.QoS != nil {
-			qos = byte(*step.QoS)
-		}
-		// Client ID includes PID to ensure unique IDs when multiple notify
-		// instances publish simultaneously (MQTT brokers reject duplicate IDs).
-		return retryOnce(func() error {
-			return mqtt.Publish(step.Broker, fmt.Sprintf("notify-%d", os.Getpid()), step.Topic, msg, qos, step.Retain, creds.MQTTUsername, creds.MQTTPassword)
-		})
-	default:
+	h, ok := config.LookupStep(step.Type)
+	if !ok {
 		return fmt.Errorf("unknown step type: %q", step.Type)
 	}
+	return h.Execute(step, config.StepEnv{Volume: vol, Creds: creds, Vars: vars, Desktop: desktop, SendID: sendID, Deliver: deliver})
+}
+
+// RetryOutbox re-sends queued outbox entries using the current config's
+// credentials for each entry's profile. Only entries whose backoff has
+// elapsed are attempted unless force is set; id restricts the retry to a
+// single entry. Each entry gets exactly one delivery attempt per call.
+func RetryOutbox(cfg config.Config, force bool, id string) (sent, failed int) {
+	return outbox.Flush(func(e outbox.Entry) error {
+		creds := config.MergeCredentials(cfg.Options.Credentials, cfg.Profiles[e.Profile].Credentials)
+		return dispatch(e.Step, remoteVolume, creds, e.Vars, nil, e.SendID, func(send func() error) error {
+			return send()
+		})
+	}, force, id)
 }
@@ -2,12 +2,18 @@ package runner
 
 import (
 	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
 	"sync"
 	"testing"
 	"time"
 
 	"github.com/Mavwarf/notify/internal/config"
+	"github.com/Mavwarf/notify/internal/holiday"
 	"github.com/Mavwarf/notify/internal/tmpl"
 )
 
@@ -16,11 +22,11 @@ func TestFilterStepsAllRun(t *testing.T) {
 		{Type: "sound", Sound: "blip"},
 		{Type: "say", Text: "hi"},
 	}
-	got := FilterSteps(steps, false, false, 0)
+	got := FilterSteps(steps, Conditions{})
 	if len(got) != 2 {
 		t.Errorf("len = %d, want 2", len(got))
 	}
-	got = FilterSteps(steps, true, false, 0)
+	got = FilterSteps(steps, Conditions{AFK: true})
 	if len(got) != 2 {
 		t.Errorf("len = %d, want 2 (no when = always run)", len(got))
 	}
@@ -33,7 +39,7 @@ func TestFilterStepsPresent(t *testing.T) {
 		{Type: "toast", Message: "afk msg", When: "afk"},
 	}
 
-	got := FilterSteps(steps, false, false, 0)
+	got := FilterSteps(steps, Conditions{})
 	if len(got) != 2 {
 		t.Fatalf("present: len = %d, want 2", len(got))
 	}
@@ -52,7 +58,7 @@ func TestFilterStepsAFK(t *testing.T) {
 		{Type: "toast", Message: "afk msg", When: "afk"},
 	}
 
-	got := FilterSteps(steps, true, false, 0)
+	got := FilterSteps(steps, Conditions{AFK: true})
 	if len(got) != 2 {
 		t.Fatalf("afk: len = %d, want 2", len(got))
 	}
@@ -64,8 +70,40 @@ func TestFilterStepsAFK(t *testing.T) {
 	}
 }
 
+func TestFilterStepsRoute(t *testing.T) {
+	steps := []config.Step{
+		{Type: "sound", Sound: "blip"},
+		{Type: "telegram", Text: "down"},
+		{Type: "toast", Message: "down", When: "afk"},
+	}
+
+	got := FilterSteps(steps, Conditions{AFK: true, Route: []string{"telegram", "toast"}})
+	if len(got) != 2 || got[0].Type != "telegram" || got[1].Type != "toast" {
+		t.Errorf("route [telegram toast]: got %v", got)
+	}
+	if got := FilterSteps(steps, Conditions{AFK: true, Route: []string{}}); len(got) != 0 {
+		t.Errorf("empty route: len = %d, want 0", len(got))
+	}
+	if got := FilterSteps(steps, Conditions{AFK: true}); len(got) != 3 {
+		t.Errorf("nil route: len = %d, want 3", len(got))
+	}
+	if got := FilteredIndices(steps, Conditions{Route: []string{"sound"}}); !got[0] || got[1] || got[2] {
+		t.Errorf("FilteredIndices = %v, want only step 0", got)
+	}
+
+	// Steps added by the routing table pass the allow-list but still
+	// need their own condition.
+	added := append(steps,
+		config.Step{Type: "telegram", Text: "critical", Routed: true},
+		config.Step{Type: "say", Text: "critical", When: "present", Routed: true})
+	got = FilterSteps(added, Conditions{AFK: true, Route: []string{"sound"}})
+	if len(got) != 2 || got[0].Type != "sound" || got[1].Type != "telegram" || !got[1].Routed {
+		t.Errorf("routed steps: got %v, want sound and the added telegram", got)
+	}
+}
+
 func TestFilterStepsEmpty(t *testing.T) {
-	got := FilterSteps(nil, false, false, 0)
+	got := FilterSteps(nil, Conditions{})
 	if len(got) != 0 {
 		t.Errorf("len = %d, want 0", len(got))
 	}
@@ -75,7 +113,7 @@ func TestFilterStepsAllFiltered(t *testing.T) {
 	steps := []config.Step{
 		{Type: "say", Text: "hi", When: "present"},
 	}
-	got := FilterSteps(steps, true, false, 0)
+	got := FilterSteps(steps, Conditions{AFK: true})
 	if len(got) != 0 {
 		t.Errorf("len = %d, want 0 (all filtered when afk)", len(got))
 	}
@@ -89,7 +127,7 @@ func TestFilterStepsRun(t *testing.T) {
 	}
 
 	// In run mode: sound + "cmd done"
-	got := FilterSteps(steps, false, true, 0)
+	got := FilterSteps(steps, Conditions{Run: true})
 	if len(got) != 2 {
 		t.Fatalf("run mode: len = %d, want 2", len(got))
 	}
@@ -98,7 +136,7 @@ func TestFilterStepsRun(t *testing.T) {
 	}
 
 	// In direct mode: sound + "ready"
-	got = FilterSteps(steps, false, false, 0)
+	got = FilterSteps(steps, Conditions{})
 	if len(got) != 2 {
 		t.Fatalf("direct mode: len = %d, want 2", len(got))
 	}
@@ -191,7 +229,7 @@ func TestMatchWhen(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := matchWhen(tt.when, tt.afk, tt.run, tt.now, tt.elapsed)
+			got := matchWhen(config.Step{When: tt.when}, Conditions{AFK: tt.afk, Run: tt.run, Elapsed: tt.elapsed}, tt.now)
 			if got != tt.want {
 				t.Errorf("matchWhen(%q, afk=%v, run=%v, elapsed=%v) = %v, want %v",
 					tt.when, tt.afk, tt.run, tt.elapsed, got, tt.want)
@@ -245,6 +283,114 @@ func TestRetryOnceBothFail(t *testing.T) {
 	}
 }
 
+// --- Outbox ---
+
+// mockOutboxAdd replaces outboxAdd for testing and returns a pointer to
+// the steps that were queued.
+func mockOutboxAdd(t *testing.T) *[]config.Step {
+	t.Helper()
+	orig := outboxAdd
+	t.Cleanup(func() { outboxAdd = orig })
+	var queued []config.Step
+	outboxAdd = func(s config.Step, _ tmpl.Vars, _ string, _ error) {
+		queued = append(queued, s)
+	}
+	return &queued
+}
+
+func TestExecStepQueuesFailedRemote(t *testing.T) {
+	queued := mockOutboxAdd(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	step := config.Step{Type: "webhook", URL: srv.URL, Text: "build failed"}
+	attempts, err := execStep(step, 100, config.Credentials{}, tmpl.Vars{Profile: "boss"}, nil)
+	if !errors.Is(err, errQueued) {
+		t.Errorf("err = %v, want queued for retry", err)
+	}
+	if attempts != 2 {
+		t.Errorf("attempts = %d, want 2", attempts)
+	}
+	if len(*queued) != 1 || (*queued)[0].URL != srv.URL {
+		t.Errorf("queued = %+v, want the webhook step", *queued)
+	}
+}
+
+func TestExecStepSendID(t *testing.T) {
+	var queued []string
+	orig := outboxAdd
+	t.Cleanup(func() { outboxAdd = orig })
+	outboxAdd = func(_ config.Step, _ tmpl.Vars, sendID string, _ error) {
+		queued = append(queued, sendID)
+	}
+	var mu sync.Mutex
+	var txns []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		txns = append(txns, r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:])
+		mu.Unlock()
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	step := config.Step{Type: "matrix", Room: "!r:x", Text: "build done"}
+	creds := config.Credentials{MatrixURL: srv.URL, MatrixToken: "t"}
+	vars := tmpl.Vars{Profile: "boss"}
+	for range 2 {
+		if _, err := execStep(step, 100, creds, vars, nil); !errors.Is(err, errQueued) {
+			t.Fatalf("err = %v, want queued for retry", err)
+		}
+	}
+	// An outbox retry sends with the queued ID.
+	dispatch(step, 100, creds, vars, nil, queued[0], func(send func() error) error {
+		return send()
+	})
+
+	if len(queued) != 2 || queued[0] == "" || queued[0] == queued[1] {
+		t.Fatalf("queued send IDs = %q, want two distinct IDs", queued)
+	}
+	want := "notify-" + queued[0]
+	if len(txns) != 5 || txns[0] != want || txns[1] != want || txns[4] != want {
+		t.Errorf("transaction IDs = %q, want %s for the first send, its retry and the outbox retry", txns, want)
+	}
+	if txns[2] != "notify-"+queued[1] {
+		t.Errorf("second send used %s, want notify-%s", txns[2], queued[1])
+	}
+}
+
+func TestExecStepLocalErrorNotQueued(t *testing.T) {
+	queued := mockOutboxAdd(t)
+	_, err := execStep(config.Step{Type: "bogus"}, 100, config.Credentials{}, tmpl.Vars{}, nil)
+	if err == nil {
+		t.Error("err = nil, want unknown step type error")
+	}
+	if len(*queued) != 0 {
+		t.Errorf("queued = %+v, want nothing", *queued)
+	}
+}
+
+func TestDispatchSingleAttempt(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	step := config.Step{Type: "webhook", URL: srv.URL, Text: "hi"}
+	err := dispatch(step, 100, config.Credentials{}, tmpl.Vars{}, nil, "", func(send func() error) error {
+		return send()
+	})
+	if err != nil {
+		t.Fatalf("err = %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("calls = %d, want 1", calls)
+	}
+}
+
 // --- Execute ---
 
 // mockStepExec replaces stepExec for testing and restores it on cleanup.
@@ -252,7 +398,9 @@ func mockStepExec(t *testing.T, fn func(config.Step, int, config.Credentials, tm
 	t.Helper()
 	orig := stepExec
 	t.Cleanup(func() { stepExec = orig })
-	stepExec = fn
+	stepExec = func(s config.Step, vol int, c config.Credentials, v tmpl.Vars, d *int) (int, error) {
+		return 1, fn(s, vol, c, v, d)
+	}
 }
 
 func TestExecuteEmpty(t *testing.T) {
@@ -260,7 +408,7 @@ func TestExecuteEmpty(t *testing.T) {
 		t.Fatal("should not be called")
 		return nil
 	})
-	if err := Execute(nil, 80, config.Credentials{}, tmpl.Vars{}, nil); err != nil {
+	if _, err := Execute(nil, 80, config.Credentials{}, tmpl.Vars{}, nil); err != nil {
 		t.Errorf("err = %v, want nil", err)
 	}
 }
@@ -281,7 +429,7 @@ func TestExecuteAllParallel(t *testing.T) {
 		{Type: "telegram", Text: "b"},
 		{Type: "toast", Message: "c"},
 	}
-	if err := Execute(steps, 80, config.Credentials{}, tmpl.Vars{}, nil); err != nil {
+	if _, err := Execute(steps, 80, config.Credentials{}, tmpl.Vars{}, nil); err != nil {
 		t.Fatalf("err = %v", err)
 	}
 	if len(ran) != 3 {
@@ -302,7 +450,7 @@ func TestExecuteAllSequential(t *testing.T) {
 		{Type: "say", Text: "second"},
 		{Type: "sound", Sound: "third"},
 	}
-	if err := Execute(steps, 80, config.Credentials{}, tmpl.Vars{}, nil); err != nil {
+	if _, err := Execute(steps, 80, config.Credentials{}, tmpl.Vars{}, nil); err != nil {
 		t.Fatalf("err = %v", err)
 	}
 	// Sequential steps must run in order.
@@ -331,7 +479,7 @@ func TestExecuteMixed(t *testing.T) {
 		{Type: "telegram", Text: "b"},
 		{Type: "say", Text: "hi"},
 	}
-	if err := Execute(steps, 80, config.Credentials{}, tmpl.Vars{}, nil); err != nil {
+	if _, err := Execute(steps, 80, config.Credentials{}, tmpl.Vars{}, nil); err != nil {
 		t.Fatalf("err = %v", err)
 	}
 	if len(ran) != 4 {
@@ -354,7 +502,7 @@ func TestExecuteSequentialStopsOnError(t *testing.T) {
 		{Type: "sound", Sound: "bad"},
 		{Type: "sound", Sound: "never"},
 	}
-	err := Execute(steps, 80, config.Credentials{}, tmpl.Vars{}, nil)
+	_, err := Execute(steps, 80, config.Credentials{}, tmpl.Vars{}, nil)
 	if err == nil {
 		t.Fatal("expected error")
 	}
@@ -375,7 +523,7 @@ func TestExecuteParallelJoinsErrors(t *testing.T) {
 		{Type: "discord", Text: "fail-a"},
 		{Type: "telegram", Text: "fail-b"},
 	}
-	err := Execute(steps, 80, config.Credentials{}, tmpl.Vars{}, nil)
+	_, err := Execute(steps, 80, config.Credentials{}, tmpl.Vars{}, nil)
 	if err == nil {
 		t.Fatal("expected error")
 	}
@@ -402,7 +550,7 @@ func TestExecuteSeqErrorWaitsForParallel(t *testing.T) {
 		{Type: "discord", Text: "slow"},
 		{Type: "sound", Sound: "bad"},
 	}
-	err := Execute(steps, 80, config.Credentials{}, tmpl.Vars{}, nil)
+	_, err := Execute(steps, 80, config.Credentials{}, tmpl.Vars{}, nil)
 	if err == nil || !strings.Contains(err.Error(), "seq-fail") {
 		t.Fatalf("err = %v, want seq-fail", err)
 	}
@@ -460,13 +608,13 @@ func TestFilterStepsLong(t *testing.T) {
 	}
 
 	// With 10m elapsed: all 3 steps run.
-	got := FilterSteps(steps, false, true, 10*time.Minute)
+	got := FilterSteps(steps, Conditions{Run: true, Elapsed: 10 * time.Minute})
 	if len(got) != 3 {
 		t.Errorf("long elapsed: len = %d, want 3", len(got))
 	}
 
 	// With 2m elapsed: only 2 steps run (long:5m is skipped).
-	got = FilterSteps(steps, false, true, 2*time.Minute)
+	got = FilterSteps(steps, Conditions{Run: true, Elapsed: 2 * time.Minute})
 	if len(got) != 2 {
 		t.Errorf("short elapsed: len = %d, want 2", len(got))
 	}
@@ -477,8 +625,305 @@ func TestFilterStepsLong(t *testing.T) {
 	}
 
 	// With 0 elapsed (direct mode): only 2 steps run.
-	got = FilterSteps(steps, false, false, 0)
+	got = FilterSteps(steps, Conditions{})
 	if len(got) != 2 {
 		t.Errorf("zero elapsed: len = %d, want 2", len(got))
 	}
 }
+
+func TestExecuteResults(t *testing.T) {
+	queued := fmt.Errorf("timeout (%w)", errQueued)
+	mockStepExec(t, func(s config.Step, _ int, _ config.Credentials, _ tmpl.Vars, _ *int) error {
+		switch s.Type {
+		case "discord":
+			return queued
+		case "sound":
+			if s.Sound == "error" {
+				return errors.New("no device")
+			}
+		}
+		return nil
+	})
+
+	steps := []config.Step{
+		{Type: "sound", Sound: "error"},
+		{Type: "discord", Text: "a"},
+		{Type: "say", Text: "b"},
+		{Type: "toast", Message: "c"},
+	}
+	results, err := Execute(steps, 80, config.Credentials{}, tmpl.Vars{}, nil)
+	if err == nil {
+		t.Fatal("err = nil, want error")
+	}
+	if len(results) != len(steps) {
+		t.Fatalf("got %d results, want %d", len(results), len(steps))
+	}
+	want := []string{StatusFailed, StatusQueued, StatusSkipped, StatusOK}
+	for i, r := range results {
+		if r.Index != i || r.Type != steps[i].Type {
+			t.Errorf("results[%d] = index %d type %q, want %d %q", i, r.Index, r.Type, i, steps[i].Type)
+		}
+		if r.Status != want[i] {
+			t.Errorf("results[%d].Status = %q, want %q", i, r.Status, want[i])
+		}
+	}
+	if results[0].Error != "no device" {
+		t.Errorf("results[0].Error = %q, want %q", results[0].Error, "no device")
+	}
+	if results[3].Attempts != 1 {
+		t.Errorf("results[3].Attempts = %d, want 1", results[3].Attempts)
+	}
+	if results[2].Attempts != 0 {
+		t.Errorf("skipped step Attempts = %d, want 0", results[2].Attempts)
+	}
+}
+
+func TestExecStepCountsAttempts(t *testing.T) {
+	mockOutboxAdd(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	attempts, err := execStep(config.Step{Type: "webhook", URL: srv.URL, Text: "hi"}, 100, config.Credentials{}, tmpl.Vars{}, nil)
+	if err != nil {
+		t.Fatalf("err = %v", err)
+	}
+	if attempts != 1 {
+		t.Errorf("attempts = %d, want 1", attempts)
+	}
+}
+
+func TestExecuteFallbackChain(t *testing.T) {
+	var mu sync.Mutex
+	var ran []string
+	mockStepExec(t, func(s config.Step, _ int, _ config.Credentials, _ tmpl.Vars, _ *int) error {
+		mu.Lock()
+		ran = append(ran, s.Type)
+		mu.Unlock()
+		if s.Type == "slack" || s.Type == "discord" {
+			return errors.New(s.Type + " down")
+		}
+		return nil
+	})
+
+	// Slack, else Discord, else toast.
+	steps := []config.Step{{
+		Type: "slack", Text: "a",
+		Fallback: []config.Step{{
+			Type: "discord", Text: "a",
+			Fallback: []config.Step{{Type: "toast", Message: "a"}},
+		}},
+	}}
+	results, err := Execute(steps, 80, config.Credentials{}, tmpl.Vars{}, nil)
+	if err != nil {
+		t.Fatalf("err = %v, want nil (fallback delivered)", err)
+	}
+	if got := strings.Join(ran, ","); got != "slack,discord,toast" {
+		t.Errorf("ran = %s, want slack,discord,toast", got)
+	}
+	if results[0].Status != StatusFallback || results[0].Error != "slack down" {
+		t.Errorf("result = %+v, want status fallback with the slack error", results[0])
+	}
+}
+
+func TestExecuteFallbackNotRunOnSuccess(t *testing.T) {
+	mockStepExec(t, func(s config.Step, _ int, _ config.Credentials, _ tmpl.Vars, _ *int) error {
+		if s.Type == "toast" {
+			t.Error("fallback ran although the step succeeded")
+		}
+		return nil
+	})
+	steps := []config.Step{{Type: "slack", Text: "a", Fallback: []config.Step{{Type: "toast", Message: "a"}}}}
+	results, err := Execute(steps, 80, config.Credentials{}, tmpl.Vars{}, nil)
+	if err != nil || results[0].Status != StatusOK {
+		t.Errorf("status = %q, err = %v, want ok", results[0].Status, err)
+	}
+}
+
+func TestExecuteFallbackFails(t *testing.T) {
+	mockStepExec(t, func(s config.Step, _ int, _ config.Credentials, _ tmpl.Vars, _ *int) error {
+		return errors.New(s.Type + " down")
+	})
+	steps := []config.Step{{Type: "say", Text: "a", Fallback: []config.Step{{Type: "toast", Message: "a"}}}}
+	results, err := Execute(steps, 80, config.Credentials{}, tmpl.Vars{}, nil)
+	if err == nil {
+		t.Fatal("err = nil, want error")
+	}
+	if !strings.Contains(err.Error(), "say down") || !strings.Contains(err.Error(), "toast down") {
+		t.Errorf("err = %v, want both the step and fallback errors", err)
+	}
+	if results[0].Status != StatusFailed {
+		t.Errorf("status = %q, want %q", results[0].Status, StatusFailed)
+	}
+}
+
+func TestExecStepWithFallbackNotQueued(t *testing.T) {
+	queued := mockOutboxAdd(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	step := config.Step{Type: "webhook", URL: srv.URL, Text: "x", Fallback: []config.Step{{Type: "toast", Message: "x"}}}
+	_, err := execStep(step, 100, config.Credentials{}, tmpl.Vars{}, nil)
+	if err == nil || errors.Is(err, errQueued) {
+		t.Errorf("err = %v, want a plain delivery error", err)
+	}
+	if len(*queued) != 0 {
+		t.Errorf("queued = %+v, want nothing (fallback takes over)", *queued)
+	}
+}
+
+func TestMatchWhenExpression(t *testing.T) {
+	night := time.Date(2026, 1, 1, 23, 0, 0, 0, time.Local)
+	tests := []struct {
+		when    string
+		afk     bool
+		elapsed time.Duration
+		want    bool
+	}{
+		{"afk and long:5m", true, 10 * time.Minute, true},
+		{"afk and long:5m", true, time.Minute, false},
+		{"afk and long:5m", false, 10 * time.Minute, false},
+		{"present or hours:22-8", true, 0, true},
+		{"not afk", true, 0, false},
+		{"(afk or present) and not hours:22-8", false, 0, false},
+		{"afk and", true, 0, false}, // parse error fails closed
+	}
+	for _, tt := range tests {
+		if got := matchWhen(config.Step{When: tt.when}, Conditions{AFK: tt.afk, Elapsed: tt.elapsed}, night); got != tt.want {
+			t.Errorf("matchWhen(%q, afk=%v, elapsed=%v) = %v, want %v", tt.when, tt.afk, tt.elapsed, got, tt.want)
+		}
+	}
+}
+
+func TestMatchWhenUsesParsedCondition(t *testing.T) {
+	cond, err := config.ParseWhen("present")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Cond, set at config load, wins over the When text.
+	s := config.Step{When: "afk", Cond: cond}
+	if !matchWhen(s, Conditions{}, time.Now()) {
+		t.Error("matchWhen ignored the parsed condition")
+	}
+}
+
+func TestExplainWhen(t *testing.T) {
+	if got := ExplainWhen(config.Step{}, Conditions{}); got != nil {
+		t.Errorf("ExplainWhen(\"\") = %q, want nil", got)
+	}
+	if got := ExplainWhen(config.Step{When: "afk and long:5m"}, Conditions{AFK: true, Run: true, Elapsed: time.Minute}); len(got) != 1 || got[0] != "long:5m" {
+		t.Errorf("ExplainWhen = %q, want [long:5m]", got)
+	}
+	if got := ExplainWhen(config.Step{When: "run or afk"}, Conditions{}); len(got) != 2 {
+		t.Errorf("ExplainWhen = %q, want both alternatives", got)
+	}
+}
+
+func TestMatchExit(t *testing.T) {
+	code := func(n int) *int { return &n }
+	tests := []struct {
+		spec string
+		code *int
+		want bool
+	}{
+		{"0", code(0), true},
+		{"0", code(1), false},
+		{"!0", code(1), true},
+		{"!0", code(0), false},
+		{"2-5", code(3), true},
+		{"2-5", code(6), false},
+		{"!2-5", code(6), true},
+		{"0", nil, false},  // direct invocation: no exit code
+		{"!0", nil, false}, // negation doesn't make unknown match
+		{"x", code(0), false},
+	}
+	for _, tt := range tests {
+		if got := matchExit(tt.spec, tt.code); got != tt.want {
+			t.Errorf("matchExit(%q, %v) = %v, want %v", tt.spec, tt.code, got, tt.want)
+		}
+	}
+}
+
+func TestFilterStepsExitAndOutput(t *testing.T) {
+	steps := []config.Step{
+		{Type: "sound", Sound: "error", When: "exit:!0"},
+		{Type: "slack", Text: "x", When: "exit:!0 and output:/FATAL/"},
+		{Type: "sound", Sound: "success", When: "exit:0"},
+	}
+	one := 1
+	got := FilterSteps(steps, Conditions{Run: true, ExitCode: &one, Output: "step 3\nFATAL: disk full\n"})
+	if len(got) != 2 || got[1].Type != "slack" {
+		t.Errorf("FATAL failure: got %+v, want beep and slack", got)
+	}
+	got = FilterSteps(steps, Conditions{Run: true, ExitCode: &one, Output: "1 test failed"})
+	if len(got) != 1 || got[0].Sound != "error" {
+		t.Errorf("ordinary failure: got %+v, want only the beep", got)
+	}
+	got = FilterSteps(steps, Conditions{Output: "FATAL"})
+	if len(got) != 0 {
+		t.Errorf("direct invocation: got %+v, want nothing", got)
+	}
+}
+
+func TestMatchWhenCalendar(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "holidays.txt")
+	if err := os.WriteFile(path, []byte("2026-12-25\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	hs, err := holiday.Parse(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	friday := time.Date(2026, 12, 25, 12, 0, 0, 0, time.Local)
+	saturday := time.Date(2026, 12, 26, 12, 0, 0, 0, time.Local)
+	newYear := time.Date(2027, 1, 1, 12, 0, 0, 0, time.Local)
+
+	tests := []struct {
+		when string
+		now  time.Time
+		want bool
+	}{
+		{"days:mon-fri", friday, true},
+		{"days:mon-fri", saturday, false},
+		{"days:sat,sun", saturday, true},
+		{"days:fri-mon", saturday, true},
+		{"days:funday", friday, false},
+		{"date:2026-12-24..2027-01-02", friday, true},
+		{"date:2026-12-24..2027-01-02", newYear, true},
+		{"date:2026-12-24..2026-12-25", saturday, false},
+		{"date:2026-12-26", saturday, true},
+		{"holiday", friday, true},
+		{"holiday", saturday, false},
+		{"days:mon-fri and not holiday", friday, false},
+	}
+	for _, tt := range tests {
+		if got := matchWhen(config.Step{When: tt.when}, Conditions{Holidays: hs}, tt.now); got != tt.want {
+			t.Errorf("matchWhen(%q, %s) = %v, want %v", tt.when, tt.now.Format("Mon 2006-01-02"), got, tt.want)
+		}
+	}
+	if matchWhen(config.Step{When: "holiday"}, Conditions{}, friday) {
+		t.Error("holiday matched without a holiday file")
+	}
+}
+
+func TestMatchWhenRepeat(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		when string
+		c    Conditions
+		want bool
+	}{
+		{"repeat", Conditions{Repeat: true}, true},
+		{"repeat", Conditions{}, false},
+		{"not repeat", Conditions{}, true},
+		{"not repeat", Conditions{Repeat: true}, false},
+	}
+	for _, tt := range tests {
+		if got := matchWhen(config.Step{When: tt.when}, tt.c, now); got != tt.want {
+			t.Errorf("matchWhen(%q, repeat=%v) = %v, want %v", tt.when, tt.c.Repeat, got, tt.want)
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"net/http"
 
 	"github.com/Mavwarf/notify/internal/httputil"
 )
@@ -16,7 +17,9 @@ func Send(webhookURL, message string) error {
 		return fmt.Errorf("slack: marshal: %w", err)
 	}
 
-	resp, err := httputil.Post(webhookURL, "application/json", bytes.NewReader(body))
+	resp, err := httputil.Limited(webhookURL, func() (*http.Response, error) {
+		return httputil.Post(webhookURL, "application/json", bytes.NewReader(body))
+	})
 	if err != nil {
 		return fmt.Errorf("slack: post: %w", err)
 	}
@@ -0,0 +1,70 @@
+package steps
+
+import (
+	"fmt"
+
+	"github.com/Mavwarf/notify/internal/config"
+	"github.com/Mavwarf/notify/internal/discord"
+	"github.com/Mavwarf/notify/internal/tmpl"
+)
+
+func init() {
+	config.RegisterStep("discord", discordStep{})
+	config.RegisterStep("discord_voice", discordVoiceStep{})
+}
+
+// discordStep posts a text message to a Discord channel via webhook.
+type discordStep struct{ parallel }
+
+func (discordStep) Validate(s config.Step, creds config.Credentials) []string {
+	return validateDiscord(s, creds)
+}
+
+func (discordStep) Credentials(config.Step) []string { return []string{"discord_webhook"} }
+
+func (discordStep) Sendable() bool { return true }
+
+func (discordStep) Summary(s config.Step, vars *tmpl.Vars) []string {
+	return []string{fmt.Sprintf("text=%q", expand(s.Text, vars))}
+}
+
+func (discordStep) Execute(s config.Step, env config.StepEnv) error {
+	msg := tmpl.Expand(s.Text, env.Vars)
+	return env.Deliver(func() error { return discord.Send(env.Creds.DiscordWebhook, msg) })
+}
+
+// discordVoiceStep renders text via TTS and uploads it as a WAV attachment.
+type discordVoiceStep struct{}
+
+func (discordVoiceStep) Validate(s config.Step, creds config.Credentials) []string {
+	return validateDiscord(s, creds)
+}
+
+func (discordVoiceStep) Credentials(config.Step) []string { return []string{"discord_webhook"} }
+
+func (discordVoiceStep) Sendable() bool { return true }
+
+func (discordVoiceStep) Summary(s config.Step, vars *tmpl.Vars) []string {
+	return []string{fmt.Sprintf("text=%q", expand(s.Text, vars))}
+}
+
+func (discordVoiceStep) Execute(s config.Step, env config.StepEnv) error {
+	text := tmpl.Expand(s.Text, env.Vars)
+	wavPath, cleanup, err := ttsToTempFile("notify-voice-*.wav", text)
+	if err != nil {
+		return fmt.Errorf("discord_voice: %w", err)
+	}
+	defer cleanup()
+	return env.Deliver(func() error { return discord.SendVoice(env.Creds.DiscordWebhook, wavPath, text) })
+}
+
+func (discordVoiceStep) Sequential() bool { return false }
+func (discordVoiceStep) UsesVoice() bool  { return true }
+
+func validateDiscord(s config.Step, creds config.Credentials) []string {
+	errs := require(s, "text", s.Text)
+	if creds.DiscordWebhook == "" {
+		errs = append(errs, fmt.Sprintf("%s step requires credentials.discord_webhook", s.Type))
+	}
+	return errs
+}
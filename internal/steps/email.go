@@ -0,0 +1,118 @@
+package steps
+
+import (
+	"fmt"
+	"net"
+	"net/mail"
+	"strings"
+
+	"github.com/Mavwarf/notify/internal/config"
+	"github.com/Mavwarf/notify/internal/email"
+	"github.com/Mavwarf/notify/internal/tmpl"
+)
+
+func init() { config.RegisterStep("email", emailStep{}) }
+
+// emailStep sends the text as a plain-text email over SMTP.
+type emailStep struct{ parallel }
+
+func (emailStep) Validate(s config.Step, creds config.Credentials) []string {
+	errs := require(s, "text", s.Text)
+	if creds.SMTPHost == "" {
+		errs = append(errs, "email step requires credentials.smtp_host")
+	}
+	if creds.SMTPFrom == "" {
+		errs = append(errs, "email step requires credentials.smtp_from")
+	}
+	if s.To == "" && creds.SMTPTo == "" {
+		errs = append(errs, `email step requires "to" field or credentials.smtp_to`)
+	}
+	if s.To != "" {
+		if _, err := mail.ParseAddressList(s.To); err != nil {
+			errs = append(errs, fmt.Sprintf("email to %q: %v", s.To, err))
+		}
+	}
+	if s.Attach != "" && s.Attach != "output" {
+		errs = append(errs, fmt.Sprintf(`email attach %q is not valid (use "output")`, s.Attach))
+	}
+	return errs
+}
+
+func (emailStep) Credentials(s config.Step) []string {
+	if s.To != "" {
+		return []string{"smtp_host", "smtp_from"}
+	}
+	return []string{"smtp_host", "smtp_from", "smtp_to"}
+}
+
+func (emailStep) Sendable() bool { return true }
+
+func (emailStep) Summary(s config.Step, vars *tmpl.Vars) []string {
+	parts := []string{}
+	if s.To != "" {
+		parts = append(parts, fmt.Sprintf("to=%s", s.To))
+	}
+	if s.Subject != "" {
+		parts = append(parts, fmt.Sprintf("subject=%q", expand(s.Subject, vars)))
+	}
+	parts = append(parts, fmt.Sprintf("text=%q", expand(s.Text, vars)))
+	if s.Attach != "" {
+		parts = append(parts, "attach="+s.Attach)
+	}
+	return parts
+}
+
+func (emailStep) Execute(s config.Step, env config.StepEnv) error {
+	to := s.To
+	if to == "" {
+		to = env.Creds.SMTPTo
+	}
+	rcpts, err := addresses(to)
+	if err != nil {
+		return fmt.Errorf("email: to: %w", err)
+	}
+
+	subject := s.Subject
+	if subject == "" {
+		subject = "{profile}"
+	}
+	msg := email.Message{
+		From:    env.Creds.SMTPFrom,
+		To:      rcpts,
+		Subject: tmpl.Expand(subject, env.Vars),
+		Body:    tmpl.Expand(s.Text, env.Vars),
+	}
+	if s.Attach == "output" && env.Vars.Output != "" {
+		msg.Attachments = []email.Attachment{{Name: "output.txt", Data: []byte(env.Vars.Output)}}
+	}
+	srv := email.Server{
+		Addr:     smtpAddr(env.Creds.SMTPHost),
+		Username: env.Creds.SMTPUsername,
+		Password: env.Creds.SMTPPassword,
+		NoTLS:    env.Creds.SMTPTLS == "none",
+	}
+	_, port, _ := net.SplitHostPort(srv.Addr)
+	srv.ImplicitTLS = port == email.ImplicitTLSPort
+	return env.Deliver(func() error { return email.Send(srv, msg) })
+}
+
+// smtpAddr adds the default submission port to a host without one.
+func smtpAddr(host string) string {
+	if _, _, err := net.SplitHostPort(host); err == nil {
+		return host
+	}
+	return net.JoinHostPort(strings.Trim(host, "[]"), email.DefaultPort)
+}
+
+// addresses splits a comma-separated address list.
+func addresses(list string) ([]string, error) {
+	parsed, err := mail.ParseAddressList(list)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]string, len(parsed))
+	for i, a := range parsed {
+		out[i] = a.String()
+	}
+	return out, nil
+}
@@ -0,0 +1,52 @@
+package steps
+
+import (
+	"fmt"
+
+	"github.com/Mavwarf/notify/internal/config"
+	"github.com/Mavwarf/notify/internal/filesink"
+	"github.com/Mavwarf/notify/internal/tmpl"
+)
+
+func init() { config.RegisterStep("file", fileStep{}) }
+
+// fileStep appends the notification as a JSON line to a file or named
+// pipe. It is local, so it runs once without retry or outbox queueing.
+type fileStep struct{ parallel }
+
+func (fileStep) Validate(s config.Step, _ config.Credentials) []string {
+	errs := require(s, "path", s.Path)
+	if s.MaxSize != "" {
+		if _, err := filesink.ParseSize(s.MaxSize); err != nil {
+			errs = append(errs, fmt.Sprintf("file max_size %q must be a byte count or use KB, MB, or GB", s.MaxSize))
+		}
+	}
+	if s.Keep < 0 {
+		errs = append(errs, "file keep must not be negative")
+	}
+	return errs
+}
+
+func (fileStep) Credentials(config.Step) []string { return nil }
+
+func (fileStep) Sendable() bool { return false }
+
+func (fileStep) Summary(s config.Step, vars *tmpl.Vars) []string {
+	parts := []string{"path=" + expand(s.Path, vars)}
+	if s.Text != "" {
+		parts = append(parts, fmt.Sprintf("text=%q", expand(s.Text, vars)))
+	}
+	if s.MaxSize != "" {
+		parts = append(parts, "max_size="+s.MaxSize)
+	}
+	return parts
+}
+
+func (fileStep) Execute(s config.Step, env config.StepEnv) error {
+	v := env.Vars
+	r := filesink.Rotation{Keep: s.Keep}
+	if s.MaxSize != "" {
+		r.MaxSize, _ = filesink.ParseSize(s.MaxSize)
+	}
+	return filesink.Append(tmpl.Expand(s.Path, v), notificationLine(tmpl.Expand(s.Text, v), v), r)
+}
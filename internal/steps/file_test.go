@@ -0,0 +1,40 @@
+package steps
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Mavwarf/notify/internal/config"
+	"github.com/Mavwarf/notify/internal/filesink"
+	"github.com/Mavwarf/notify/internal/tmpl"
+)
+
+func TestFileExecute(t *testing.T) {
+	dir := t.TempDir()
+	s := config.Step{Type: "file", Path: filepath.Join(dir, "{profile}.jsonl"), Text: "{Profile} {action}"}
+	v := tmpl.Vars{Profile: "webapp", Action: "ready", Severity: "info", ExitCode: "0"}
+	before := time.Now()
+	if err := (fileStep{}).Execute(s, testEnv(t, config.Credentials{}, v)); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "webapp.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var l filesink.Line
+	if err := json.Unmarshal(data, &l); err != nil {
+		t.Fatalf("line %q: %v", data, err)
+	}
+	if l.Profile != "webapp" || l.Action != "ready" || l.Severity != "info" || l.Text != "Webapp ready" {
+		t.Errorf("line = %+v", l)
+	}
+	if l.Vars["exit_code"] != "0" || l.Vars["profile"] != "webapp" {
+		t.Errorf("vars = %v", l.Vars)
+	}
+	if l.Time.Before(before.Add(-time.Second)) || l.Time.After(time.Now()) {
+		t.Errorf("time = %v, want about %v", l.Time, before)
+	}
+}
@@ -0,0 +1,55 @@
+package steps
+
+import (
+	"fmt"
+
+	"github.com/Mavwarf/notify/internal/config"
+	"github.com/Mavwarf/notify/internal/googlechat"
+	"github.com/Mavwarf/notify/internal/tmpl"
+)
+
+func init() { config.RegisterStep("googlechat", googlechatStep{}) }
+
+// googlechatStep posts a card to a Google Chat space via incoming webhook.
+type googlechatStep struct{ parallel }
+
+func (googlechatStep) Validate(s config.Step, creds config.Credentials) []string {
+	errs := require(s, "text", s.Text)
+	if creds.GoogleChatHook == "" {
+		errs = append(errs, "googlechat step requires credentials.googlechat_webhook")
+	}
+	return errs
+}
+
+func (googlechatStep) Credentials(config.Step) []string { return []string{"googlechat_webhook"} }
+
+func (googlechatStep) Sendable() bool { return true }
+
+func (googlechatStep) Summary(s config.Step, vars *tmpl.Vars) []string {
+	parts := []string{}
+	if s.Thread != "" {
+		parts = append(parts, fmt.Sprintf("thread=%q", expand(s.Thread, vars)))
+	}
+	return append(parts, fmt.Sprintf("text=%q", expand(s.Text, vars)))
+}
+
+func (googlechatStep) Execute(s config.Step, env config.StepEnv) error {
+	v := env.Vars
+	title, thread := s.Title, s.Thread
+	if title == "" {
+		title = "{Profile}"
+	}
+	if thread == "" {
+		thread = "notify-{profile}"
+	}
+	m := googlechat.Message{
+		Title:     tmpl.Expand(title, v),
+		Subtitle:  v.Hostname,
+		Text:      tmpl.Expand(s.Text, v),
+		ThreadKey: tmpl.Expand(thread, v),
+	}
+	for _, f := range runFacts(v)[1:] { // the profile is already the title
+		m.Facts = append(m.Facts, googlechat.Fact{Label: f.label, Value: f.value})
+	}
+	return env.Deliver(func() error { return googlechat.Send(env.Creds.GoogleChatHook, m) })
+}
@@ -0,0 +1,80 @@
+package steps
+
+import (
+	"fmt"
+
+	"github.com/Mavwarf/notify/internal/config"
+	"github.com/Mavwarf/notify/internal/gotify"
+	"github.com/Mavwarf/notify/internal/tmpl"
+)
+
+func init() { config.RegisterStep("gotify", gotifyStep{}) }
+
+// gotifyStep posts the text to a Gotify server.
+type gotifyStep struct{ parallel }
+
+func (gotifyStep) Validate(s config.Step, creds config.Credentials) []string {
+	errs := require(s, "text", s.Text)
+	if creds.GotifyURL == "" {
+		errs = append(errs, "gotify step requires credentials.gotify_url")
+	}
+	if creds.GotifyToken == "" {
+		errs = append(errs, "gotify step requires credentials.gotify_token")
+	}
+	if s.Priority != "" {
+		if _, err := gotify.ParsePriority(s.Priority); err != nil {
+			errs = append(errs, "gotify "+err.Error())
+		}
+	}
+	return errs
+}
+
+func (gotifyStep) Credentials(config.Step) []string { return []string{"gotify_url", "gotify_token"} }
+
+func (gotifyStep) Sendable() bool { return true }
+
+func (gotifyStep) Summary(s config.Step, vars *tmpl.Vars) []string {
+	parts := []string{}
+	if s.Priority != "" {
+		parts = append(parts, "priority="+s.Priority)
+	}
+	if s.Markdown {
+		parts = append(parts, "markdown")
+	}
+	return append(parts, fmt.Sprintf("text=%q", expand(s.Text, vars)))
+}
+
+func (gotifyStep) Execute(s config.Step, env config.StepEnv) error {
+	v := env.Vars
+	title := s.Title
+	if title == "" {
+		title = "{profile}"
+	}
+	m := gotify.Message{
+		Title:    tmpl.Expand(title, v),
+		Message:  tmpl.Expand(s.Text, v),
+		Priority: gotifyPriority(s.Priority, v.Severity),
+		Markdown: s.Markdown,
+		Click:    tmpl.Expand(s.Click, v),
+	}
+	return env.Deliver(func() error {
+		return gotify.Send(env.Creds.GotifyURL, env.Creds.GotifyToken, m)
+	})
+}
+
+// gotifyPriority returns the step's priority, or one derived from the
+// action's severity when unset. Android clients pop up from 4 and sound
+// from 8, so warning is 5 and critical is 8.
+func gotifyPriority(priority, severity string) int {
+	if priority != "" {
+		p, _ := gotify.ParsePriority(priority)
+		return p
+	}
+	switch severity {
+	case config.SeverityWarning:
+		return 5
+	case config.SeverityCritical:
+		return 8
+	}
+	return 0
+}
@@ -0,0 +1,257 @@
+package steps
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/Mavwarf/notify/internal/config"
+	"github.com/Mavwarf/notify/internal/incident"
+	"github.com/Mavwarf/notify/internal/opsgenie"
+	"github.com/Mavwarf/notify/internal/pagerduty"
+	"github.com/Mavwarf/notify/internal/tmpl"
+)
+
+func init() {
+	config.RegisterStep("pagerduty", pagerdutyStep{})
+	config.RegisterStep("opsgenie", opsgenieStep{})
+}
+
+// Incident events. A step without "event" triggers on failure, resolves
+// on success, and does nothing on any other outcome.
+const (
+	eventTrigger = "trigger"
+	eventResolve = "resolve"
+)
+
+var (
+	pagerdutySeverities = []string{"critical", "error", "warning", "info"}
+	opsgeniePriorities  = []string{"P1", "P2", "P3", "P4", "P5"}
+)
+
+// incidentErrors validates the fields shared by the incident steps.
+func incidentErrors(s config.Step) []string {
+	errs := require(s, "text", s.Text)
+	if s.Event != "" && s.Event != eventTrigger && s.Event != eventResolve {
+		errs = append(errs, fmt.Sprintf("%s event %q must be trigger or resolve", s.Type, s.Event))
+	}
+	return errs
+}
+
+// incidentEvent returns the step's event, or one derived from the run's
+// outcome when unset: "" for a run that neither failed nor succeeded
+// (a warning, a "heartbeat" action), which sends nothing.
+func incidentEvent(s config.Step, v tmpl.Vars) string {
+	if s.Event != "" {
+		return s.Event
+	}
+	switch outcome(v) {
+	case outcomeFailure:
+		return eventTrigger
+	case outcomeSuccess:
+		return eventResolve
+	}
+	return ""
+}
+
+// maxIncidentKey keeps dedup keys within PagerDuty's 255-byte limit
+// (Opsgenie aliases allow 512).
+const maxIncidentKey = 200
+
+// incidentKey returns the dedup key shared by the steps that open and
+// resolve one incident: "notify/<profile>/<command>" unless the step sets
+// "incident". Keys too long for the services are replaced by a hash.
+func incidentKey(s config.Step, v tmpl.Vars) string {
+	key := "notify/" + v.Profile
+	if v.Command != "" {
+		key += "/" + v.Command
+	}
+	if s.Incident != "" {
+		key = tmpl.Expand(s.Incident, v)
+	}
+	if len(key) > maxIncidentKey {
+		sum := sha256.Sum256([]byte(key))
+		key = "notify/" + hex.EncodeToString(sum[:16])
+	}
+	return key
+}
+
+// incidentSummary lists the event and dedup key for dry-run and logs.
+func incidentSummary(s config.Step, vars *tmpl.Vars) []string {
+	event, key := s.Event, s.Incident
+	if vars != nil {
+		event, key = incidentEvent(s, *vars), incidentKey(s, *vars)
+		if event == "" {
+			event = "none"
+		}
+	}
+	if event == "" {
+		event = "auto"
+	}
+	parts := []string{"event=" + event}
+	if key != "" {
+		parts = append(parts, "incident="+key)
+	}
+	return append(parts, fmt.Sprintf("text=%q", expand(s.Text, vars)))
+}
+
+// incidentDetails returns the run facts plus any captured output.
+func incidentDetails(v tmpl.Vars) map[string]string {
+	details := map[string]string{}
+	for _, f := range runFacts(v) {
+		details[f.label] = f.value
+	}
+	if v.Output != "" {
+		details["Output"] = v.Output
+	}
+	return details
+}
+
+// deliverIncident sends a trigger or resolve and keeps the open-incident
+// state in step with what the service was told. A resolve for a key that
+// notify never opened is skipped, so a "ready" after a success doesn't
+// ping the service. A trigger that ends up in the outbox counts as open
+// right away, and a resolve for it fails while the trigger is still
+// queued, so the resolve is queued behind it and sent after it.
+func deliverIncident(env config.StepEnv, service, event, key string, send func() error) error {
+	if event == "" {
+		return nil
+	}
+	if event == eventResolve {
+		if incident.IsQueued(service, key) {
+			return env.Deliver(func() error {
+				return fmt.Errorf("%s: trigger for %s not delivered yet", service, key)
+			})
+		}
+		if !incident.IsOpen(service, key) {
+			return nil
+		}
+	}
+	err := env.Deliver(func() error {
+		if err := send(); err != nil {
+			return err
+		}
+		if event == eventResolve {
+			incident.Close(service, key)
+		} else {
+			incident.Open(service, key)
+		}
+		return nil
+	})
+	if event == eventTrigger && errors.Is(err, config.ErrQueued) {
+		incident.Queue(service, key)
+	}
+	return err
+}
+
+// pagerdutyStep triggers or resolves a PagerDuty incident.
+type pagerdutyStep struct{ parallel }
+
+func (pagerdutyStep) Validate(s config.Step, creds config.Credentials) []string {
+	errs := incidentErrors(s)
+	if creds.PagerDutyKey == "" {
+		errs = append(errs, "pagerduty step requires credentials.pagerduty_key")
+	}
+	if s.Priority != "" && !slices.Contains(pagerdutySeverities, s.Priority) {
+		errs = append(errs, fmt.Sprintf("pagerduty priority %q must be critical, error, warning, or info", s.Priority))
+	}
+	return errs
+}
+
+func (pagerdutyStep) Credentials(config.Step) []string { return []string{"pagerduty_key"} }
+
+func (pagerdutyStep) Sendable() bool { return false }
+
+func (pagerdutyStep) Summary(s config.Step, vars *tmpl.Vars) []string {
+	return incidentSummary(s, vars)
+}
+
+func (pagerdutyStep) Execute(s config.Step, env config.StepEnv) error {
+	v := env.Vars
+	e := pagerduty.Event{
+		Action:   incidentEvent(s, v),
+		DedupKey: incidentKey(s, v),
+		Summary:  tmpl.Expand(s.Text, v),
+		Source:   v.Hostname,
+		Severity: s.Priority,
+		Details:  incidentDetails(v),
+	}
+	if e.Severity == "" {
+		e.Severity = pagerdutySeverity(v.Severity)
+	}
+	return deliverIncident(env, "pagerduty", e.Action, e.DedupKey, func() error {
+		return pagerduty.Send(env.Creds.PagerDutyURL, env.Creds.PagerDutyKey, e)
+	})
+}
+
+// pagerdutySeverity maps the action's severity onto PagerDuty's: an
+// incident opened at info severity is still an error.
+func pagerdutySeverity(severity string) string {
+	switch severity {
+	case config.SeverityCritical:
+		return "critical"
+	case config.SeverityWarning:
+		return "warning"
+	}
+	return "error"
+}
+
+// opsgenieStep creates or closes an Opsgenie alert.
+type opsgenieStep struct{ parallel }
+
+func (opsgenieStep) Validate(s config.Step, creds config.Credentials) []string {
+	errs := incidentErrors(s)
+	if creds.OpsgenieKey == "" {
+		errs = append(errs, "opsgenie step requires credentials.opsgenie_key")
+	}
+	if s.Priority != "" && !slices.Contains(opsgeniePriorities, s.Priority) {
+		errs = append(errs, fmt.Sprintf("opsgenie priority %q must be P1-P5", s.Priority))
+	}
+	return errs
+}
+
+func (opsgenieStep) Credentials(config.Step) []string { return []string{"opsgenie_key"} }
+
+func (opsgenieStep) Sendable() bool { return false }
+
+func (opsgenieStep) Summary(s config.Step, vars *tmpl.Vars) []string {
+	return incidentSummary(s, vars)
+}
+
+func (opsgenieStep) Execute(s config.Step, env config.StepEnv) error {
+	v := env.Vars
+	event, key := incidentEvent(s, v), incidentKey(s, v)
+	server, apiKey := env.Creds.OpsgenieURL, env.Creds.OpsgenieKey
+	if event == eventResolve {
+		return deliverIncident(env, "opsgenie", event, key, func() error {
+			return opsgenie.Close(server, apiKey, key, v.Hostname)
+		})
+	}
+	a := opsgenie.Alert{
+		Message:     tmpl.Expand(s.Text, v),
+		Alias:       key,
+		Description: v.Output,
+		Priority:    s.Priority,
+		Source:      v.Hostname,
+		Details:     incidentDetails(v),
+	}
+	if a.Priority == "" {
+		a.Priority = opsgeniePriority(v.Severity)
+	}
+	return deliverIncident(env, "opsgenie", event, key, func() error {
+		return opsgenie.Create(server, apiKey, a)
+	})
+}
+
+// opsgeniePriority maps the action's severity onto Opsgenie's priorities.
+func opsgeniePriority(severity string) string {
+	switch severity {
+	case config.SeverityCritical:
+		return "P1"
+	case config.SeverityWarning:
+		return "P3"
+	}
+	return "P2"
+}
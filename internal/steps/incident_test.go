@@ -0,0 +1,137 @@
+package steps
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/Mavwarf/notify/internal/config"
+	"github.com/Mavwarf/notify/internal/incident"
+	"github.com/Mavwarf/notify/internal/teams"
+	"github.com/Mavwarf/notify/internal/tmpl"
+)
+
+var (
+	failedRun    = tmpl.Vars{Profile: "webapp", Action: "error", Command: "make", ExitCode: "2"}
+	succeededRun = tmpl.Vars{Profile: "webapp", Action: "ready", Command: "make", ExitCode: "0"}
+)
+
+func TestIncidentTriggerResolve(t *testing.T) {
+	srv, got := fakeServer(t)
+	creds := config.Credentials{PagerDutyKey: "k", PagerDutyURL: srv.URL, OpsgenieKey: "k", OpsgenieURL: srv.URL}
+	tests := []struct {
+		typ              string
+		trigger, resolve string // request paths
+	}{
+		{"pagerduty", "/v2/enqueue", "/v2/enqueue"},
+		{"opsgenie", "/v2/alerts", "/v2/alerts/notify/webapp/make/close"},
+	}
+	for _, tt := range tests {
+		h, _ := config.LookupStep(tt.typ)
+		s := config.Step{Type: tt.typ, Text: "{command} {action}"}
+		env := testEnv(t, creds, failedRun)
+		run := func(v tmpl.Vars) {
+			t.Helper()
+			env.Vars = v
+			if err := h.Execute(s, env); err != nil {
+				t.Fatalf("%s: %v", tt.typ, err)
+			}
+		}
+
+		run(failedRun)
+		if r := <-got; r.path != tt.trigger || !strings.Contains(r.body, "make error") {
+			t.Errorf("%s trigger: %s %s", tt.typ, r.path, r.body)
+		}
+		if !incident.IsOpen(tt.typ, "notify/webapp/make") {
+			t.Errorf("%s: incident not open after trigger", tt.typ)
+		}
+
+		run(succeededRun)
+		if r := <-got; r.path != tt.resolve {
+			t.Errorf("%s resolve: %s %s", tt.typ, r.path, r.body)
+		}
+		if incident.IsOpen(tt.typ, "notify/webapp/make") {
+			t.Errorf("%s: incident open after resolve", tt.typ)
+		}
+
+		// Nothing is open, and a heartbeat is neither failure nor success.
+		run(succeededRun)
+		run(tmpl.Vars{Profile: "webapp", Action: "heartbeat"})
+		select {
+		case r := <-got:
+			t.Errorf("%s: unexpected request %s %s", tt.typ, r.path, r.body)
+		default:
+		}
+	}
+}
+
+// TestIncidentCriticalSuccess checks that a critical action's severity
+// doesn't make a successful run a failure: it resolves the open incident.
+func TestIncidentCriticalSuccess(t *testing.T) {
+	srv, got := fakeServer(t)
+	creds := config.Credentials{PagerDutyKey: "k", PagerDutyURL: srv.URL}
+	h, _ := config.LookupStep("pagerduty")
+	s := config.Step{Type: "pagerduty", Text: "{command} {action}"}
+	env := testEnv(t, creds, failedRun)
+
+	for _, v := range []tmpl.Vars{
+		{Profile: "webapp", Action: "deploy", Command: "make", ExitCode: "1", Severity: config.SeverityCritical},
+		{Profile: "webapp", Action: "deploy", Command: "make", ExitCode: "0", Severity: config.SeverityCritical},
+	} {
+		env.Vars = v
+		if err := h.Execute(s, env); err != nil {
+			t.Fatal(err)
+		}
+		<-got
+	}
+	if incident.IsOpen("pagerduty", "notify/webapp/make") {
+		t.Error("incident still open after the critical action exited 0")
+	}
+	if got := teamsColor(tmpl.Vars{Action: "deploy", ExitCode: "0", Severity: config.SeverityCritical}); got != teams.ColorGood {
+		t.Errorf("teams color = %q, want %q", got, teams.ColorGood)
+	}
+}
+
+// TestIncidentQueuedTrigger follows a trigger into the outbox: the resolve
+// waits behind it and both go out in order when the outbox retries them.
+func TestIncidentQueuedTrigger(t *testing.T) {
+	srv, got := fakeServer(t)
+	h, _ := config.LookupStep("pagerduty")
+	s := config.Step{Type: "pagerduty", Text: "{command} {action}"}
+	env := testEnv(t, config.Credentials{PagerDutyKey: "k", PagerDutyURL: srv.URL}, failedRun)
+	offline := env
+	offline.Deliver = func(func() error) error {
+		return fmt.Errorf("timeout (%w)", config.ErrQueued)
+	}
+
+	if err := h.Execute(s, offline); !errors.Is(err, config.ErrQueued) {
+		t.Fatalf("trigger: %v, want queued", err)
+	}
+	offline.Vars = succeededRun
+	if err := h.Execute(s, offline); !errors.Is(err, config.ErrQueued) {
+		t.Fatalf("resolve: %v, want queued behind the trigger", err)
+	}
+
+	// Back online, a resolve still can't overtake the queued trigger.
+	env.Vars = succeededRun
+	if err := h.Execute(s, env); err == nil {
+		t.Fatal("resolve delivered before the queued trigger")
+	}
+
+	// The outbox delivers the trigger, then the resolve.
+	for _, v := range []tmpl.Vars{failedRun, succeededRun} {
+		env.Vars = v
+		if err := h.Execute(s, env); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, want := range []string{`"trigger"`, `"resolve"`} {
+		if r := <-got; !strings.Contains(r.body, want) {
+			t.Errorf("body %s, want %s", r.body, want)
+		}
+	}
+	if incident.IsOpen("pagerduty", "notify/webapp/make") || incident.IsQueued("pagerduty", "notify/webapp/make") {
+		t.Error("incident state left behind")
+	}
+}
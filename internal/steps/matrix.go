@@ -0,0 +1,91 @@
+package steps
+
+import (
+	"crypto/rand"
+	"fmt"
+	"html"
+	"reflect"
+	"strings"
+
+	"github.com/Mavwarf/notify/internal/config"
+	"github.com/Mavwarf/notify/internal/matrix"
+	"github.com/Mavwarf/notify/internal/tmpl"
+)
+
+func init() { config.RegisterStep("matrix", matrixStep{}) }
+
+// matrixStep sends the text as an m.room.message event to a Matrix room.
+type matrixStep struct{ parallel }
+
+func (matrixStep) Validate(s config.Step, creds config.Credentials) []string {
+	errs := require(s, "text", s.Text)
+	if creds.MatrixURL == "" {
+		errs = append(errs, "matrix step requires credentials.matrix_url")
+	}
+	if creds.MatrixToken == "" {
+		errs = append(errs, "matrix step requires credentials.matrix_token")
+	}
+	if s.Room == "" && creds.MatrixRoom == "" {
+		errs = append(errs, `matrix step requires "room" field or credentials.matrix_room`)
+	}
+	if s.Room != "" && !strings.HasPrefix(s.Room, "!") && !strings.HasPrefix(s.Room, "#") {
+		errs = append(errs, fmt.Sprintf("matrix room %q must be a room ID (!id:server) or alias (#alias:server)", s.Room))
+	}
+	return errs
+}
+
+func (matrixStep) Credentials(s config.Step) []string {
+	if s.Room != "" {
+		return []string{"matrix_url", "matrix_token"}
+	}
+	return []string{"matrix_url", "matrix_token", "matrix_room"}
+}
+
+func (matrixStep) Sendable() bool { return true }
+
+func (matrixStep) Summary(s config.Step, vars *tmpl.Vars) []string {
+	parts := []string{}
+	if s.Room != "" {
+		parts = append(parts, "room="+s.Room)
+	}
+	parts = append(parts, fmt.Sprintf("text=%q", expand(s.Text, vars)))
+	if s.HTML != "" {
+		parts = append(parts, "html")
+	}
+	return parts
+}
+
+func (matrixStep) Execute(s config.Step, env config.StepEnv) error {
+	v := env.Vars
+	room := s.Room
+	if room == "" {
+		room = env.Creds.MatrixRoom
+	}
+	m := matrix.Message{
+		Body: tmpl.Expand(s.Text, v),
+		HTML: tmpl.Expand(s.HTML, htmlVars(v)),
+	}
+	// Every attempt of this notification (the runner's retry and later
+	// outbox retries) reuses the transaction ID, so a send whose response
+	// was lost isn't posted twice. Entries queued without one get a fresh ID.
+	id := env.SendID
+	if id == "" {
+		id = rand.Text()
+	}
+	txnID := "notify-" + id
+	return env.Deliver(func() error {
+		return matrix.Send(env.Creds.MatrixURL, env.Creds.MatrixToken, room, txnID, m)
+	})
+}
+
+// htmlVars returns v with every value HTML-escaped, for expanding the
+// formatted body: {output} and the other variables are untrusted text.
+func htmlVars(v tmpl.Vars) tmpl.Vars {
+	rv := reflect.ValueOf(&v).Elem()
+	for i := range rv.NumField() {
+		if f := rv.Field(i); f.Kind() == reflect.String {
+			f.SetString(html.EscapeString(f.String()))
+		}
+	}
+	return v
+}
@@ -0,0 +1,52 @@
+package steps
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/Mavwarf/notify/internal/config"
+	"github.com/Mavwarf/notify/internal/tmpl"
+)
+
+func TestMatrixExecute(t *testing.T) {
+	srv, got := fakeServer(t)
+	s := config.Step{Type: "matrix", Room: "!r:example.org", Text: "{command}: {output}", HTML: "<b>{command}</b>: <code>{output}</code>"}
+	v := tmpl.Vars{Profile: "webapp", Command: "make", Output: `<img src=x onerror="alert(1)">`, Time: "12:00"}
+	env := testEnv(t, config.Credentials{MatrixURL: srv.URL, MatrixToken: "t"}, v)
+	env.SendID = "a1"
+
+	var paths []string
+	for range 2 {
+		if err := (matrixStep{}).Execute(s, env); err != nil {
+			t.Fatal(err)
+		}
+		r := <-got
+		paths = append(paths, r.path)
+		var c struct {
+			Body          string `json:"body"`
+			FormattedBody string `json:"formatted_body"`
+		}
+		if err := json.Unmarshal([]byte(r.body), &c); err != nil {
+			t.Fatal(err)
+		}
+		if want := "<b>make</b>: <code>&lt;img src=x onerror=&#34;alert(1)&#34;&gt;</code>"; c.FormattedBody != want {
+			t.Errorf("formatted body = %q, want %q", c.FormattedBody, want)
+		}
+		if c.Body != "make: "+v.Output {
+			t.Errorf("plain body = %q", c.Body)
+		}
+	}
+	// A retry (same SendID) reuses the transaction ID.
+	if want := "/_matrix/client/v3/rooms/!r:example.org/send/m.room.message/notify-a1"; paths[0] != want || paths[1] != want {
+		t.Errorf("paths = %q, want both %q", paths, want)
+	}
+
+	// A new send of identical content gets a new one.
+	env.SendID = "b2"
+	if err := (matrixStep{}).Execute(s, env); err != nil {
+		t.Fatal(err)
+	}
+	if r := <-got; r.path == paths[0] {
+		t.Errorf("a new send reused transaction ID %s", r.path)
+	}
+}
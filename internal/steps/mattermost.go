@@ -0,0 +1,49 @@
+package steps
+
+import (
+	"fmt"
+
+	"github.com/Mavwarf/notify/internal/config"
+	"github.com/Mavwarf/notify/internal/mattermost"
+	"github.com/Mavwarf/notify/internal/tmpl"
+)
+
+func init() { config.RegisterStep("mattermost", mattermostStep{}) }
+
+// mattermostStep posts a message via a Mattermost incoming webhook.
+type mattermostStep struct{ parallel }
+
+func (mattermostStep) Validate(s config.Step, creds config.Credentials) []string {
+	errs := require(s, "text", s.Text)
+	if creds.MattermostHook == "" {
+		errs = append(errs, "mattermost step requires credentials.mattermost_webhook")
+	}
+	return errs
+}
+
+func (mattermostStep) Credentials(config.Step) []string { return []string{"mattermost_webhook"} }
+
+func (mattermostStep) Sendable() bool { return true }
+
+func (mattermostStep) Summary(s config.Step, vars *tmpl.Vars) []string {
+	parts := []string{}
+	if s.Channel != "" {
+		parts = append(parts, "channel="+s.Channel)
+	}
+	if s.Username != "" {
+		parts = append(parts, "username="+s.Username)
+	}
+	return append(parts, fmt.Sprintf("text=%q", expand(s.Text, vars)))
+}
+
+func (mattermostStep) Execute(s config.Step, env config.StepEnv) error {
+	m := mattermost.Message{
+		Text:     tmpl.Expand(s.Text, env.Vars),
+		Username: tmpl.Expand(s.Username, env.Vars),
+		Channel:  s.Channel,
+	}
+	if s.Icon != "" {
+		m.SetIcon(s.Icon)
+	}
+	return env.Deliver(func() error { return mattermost.Send(env.Creds.MattermostHook, m) })
+}
@@ -0,0 +1,50 @@
+package steps
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/Mavwarf/notify/internal/config"
+	"github.com/Mavwarf/notify/internal/mqtt"
+	"github.com/Mavwarf/notify/internal/tmpl"
+)
+
+func init() { config.RegisterStep("mqtt", mqttStep{}) }
+
+// mqttStep publishes the text to an MQTT broker topic.
+type mqttStep struct{ parallel }
+
+func (mqttStep) Validate(s config.Step, _ config.Credentials) []string {
+	errs := require(s, "broker", s.Broker)
+	errs = append(errs, require(s, "topic", s.Topic)...)
+	errs = append(errs, require(s, "text", s.Text)...)
+	if s.QoS != nil && (*s.QoS < 0 || *s.QoS > 2) {
+		errs = append(errs, "mqtt qos must be 0, 1, or 2")
+	}
+	return errs
+}
+
+func (mqttStep) Credentials(config.Step) []string { return nil }
+
+func (mqttStep) Sendable() bool { return false }
+
+func (mqttStep) Summary(s config.Step, vars *tmpl.Vars) []string {
+	return []string{
+		fmt.Sprintf("broker=%s", s.Broker),
+		fmt.Sprintf("topic=%s", s.Topic),
+		fmt.Sprintf("text=%q", expand(s.Text, vars)),
+	}
+}
+
+func (mqttStep) Execute(s config.Step, env config.StepEnv) error {
+	msg := tmpl.Expand(s.Text, env.Vars)
+	qos := byte(0)
+	if s.QoS != nil {
+		qos = byte(*s.QoS)
+	}
+	// Client ID includes PID to ensure unique IDs when multiple notify
+	// instances publish simultaneously (MQTT brokers reject duplicate IDs).
+	return env.Deliver(func() error {
+		return mqtt.Publish(s.Broker, fmt.Sprintf("notify-%d", os.Getpid()), s.Topic, msg, qos, s.Retain, env.Creds.MQTTUsername, env.Creds.MQTTPassword)
+	})
+}
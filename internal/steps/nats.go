@@ -0,0 +1,82 @@
+package steps
+
+import (
+	"fmt"
+
+	"github.com/Mavwarf/notify/internal/config"
+	"github.com/Mavwarf/notify/internal/nats"
+	"github.com/Mavwarf/notify/internal/tmpl"
+)
+
+func init() { config.RegisterStep("nats", natsStep{}) }
+
+// natsStep publishes the notification as JSON to a NATS subject,
+// optionally waiting for a JetStream stream to store it.
+type natsStep struct{ parallel }
+
+func (natsStep) Validate(s config.Step, creds config.Credentials) []string {
+	errs := require(s, "broker", s.Broker)
+	errs = append(errs, require(s, "topic", s.Topic)...)
+	errs = append(errs, require(s, "text", s.Text)...)
+	if s.Broker != "" {
+		srv, err := nats.ParseURL(s.Broker)
+		switch {
+		case err != nil:
+			errs = append(errs, "nats broker "+err.Error())
+		case srv.Password != "":
+			errs = append(errs, "nats broker must not contain a password (use credentials.nats_password)")
+		case srv.Token != "" && creds.NATSPassword == "":
+			errs = append(errs, "nats broker must not contain a token (use credentials.nats_token, or set credentials.nats_password to make it a user name)")
+		}
+	}
+	return errs
+}
+
+func (natsStep) Credentials(config.Step) []string { return nil }
+
+func (natsStep) Sendable() bool { return false }
+
+func (natsStep) Summary(s config.Step, vars *tmpl.Vars) []string {
+	parts := []string{
+		"broker=" + redactBroker(s.Broker),
+		fmt.Sprintf("subject=%s", expand(s.Topic, vars)),
+	}
+	if s.JS {
+		parts = append(parts, "jetstream")
+	}
+	return append(parts, fmt.Sprintf("text=%q", expand(s.Text, vars)))
+}
+
+func (natsStep) Execute(s config.Step, env config.StepEnv) error {
+	srv, err := nats.ParseURL(s.Broker)
+	if err != nil {
+		return fmt.Errorf("nats: %w", err)
+	}
+	natsAuth(&srv, env.Creds)
+	subject := tmpl.Expand(s.Topic, env.Vars)
+	payload := notificationJSON(tmpl.Expand(s.Text, env.Vars), env.Vars)
+	return env.Deliver(func() error {
+		if s.JS {
+			_, err := nats.PublishJetStream(srv, subject, payload)
+			return err
+		}
+		return nats.Publish(srv, subject, payload)
+	})
+}
+
+// natsAuth fills in the credentials. The URL carries at most a user name
+// ("nats://notify@host", which nats.ParseURL reads as a token), used with
+// nats_password; without one, nats_token authenticates. Secrets never come
+// from the URL, since the step (broker included) may be queued in the
+// outbox.
+func natsAuth(srv *nats.Server, creds config.Credentials) {
+	if srv.Token != "" {
+		srv.Username, srv.Token = srv.Token, ""
+	}
+	if srv.Username != "" {
+		srv.Password = creds.NATSPassword
+		return
+	}
+	srv.Password = ""
+	srv.Token = creds.NATSToken
+}
@@ -0,0 +1,100 @@
+package steps
+
+import (
+	"fmt"
+
+	"github.com/Mavwarf/notify/internal/config"
+	"github.com/Mavwarf/notify/internal/ntfy"
+	"github.com/Mavwarf/notify/internal/tmpl"
+)
+
+func init() { config.RegisterStep("ntfy", ntfyStep{}) }
+
+// ntfyMaxActions is the number of action buttons ntfy accepts.
+const ntfyMaxActions = 3
+
+// ntfyStep publishes the text to an ntfy topic.
+type ntfyStep struct{ parallel }
+
+func (ntfyStep) Validate(s config.Step, _ config.Credentials) []string {
+	errs := require(s, "topic", s.Topic)
+	errs = append(errs, require(s, "text", s.Text)...)
+	if _, err := ntfy.ParsePriority(s.Priority); err != nil {
+		errs = append(errs, "ntfy "+err.Error())
+	}
+	if len(s.Actions) > ntfyMaxActions {
+		errs = append(errs, fmt.Sprintf("ntfy allows at most %d actions, got %d", ntfyMaxActions, len(s.Actions)))
+	}
+	for i, a := range s.Actions {
+		switch a.Action {
+		case "view", "http":
+			if a.URL == "" {
+				errs = append(errs, fmt.Sprintf("ntfy actions[%d]: %s action requires \"url\"", i, a.Action))
+			}
+		case "broadcast":
+		default:
+			errs = append(errs, fmt.Sprintf("ntfy actions[%d]: action %q must be view, http, or broadcast", i, a.Action))
+		}
+		if a.Label == "" {
+			errs = append(errs, fmt.Sprintf("ntfy actions[%d]: requires \"label\"", i))
+		}
+	}
+	return errs
+}
+
+func (ntfyStep) Credentials(config.Step) []string { return nil }
+
+func (ntfyStep) Sendable() bool { return false }
+
+func (ntfyStep) Summary(s config.Step, vars *tmpl.Vars) []string {
+	parts := []string{fmt.Sprintf("topic=%s", s.Topic)}
+	if s.Priority != "" {
+		parts = append(parts, "priority="+s.Priority)
+	}
+	return append(parts, fmt.Sprintf("text=%q", expand(s.Text, vars)))
+}
+
+func (ntfyStep) Execute(s config.Step, env config.StepEnv) error {
+	v := env.Vars
+	title := s.Title
+	if title == "" {
+		title = "{profile}"
+	}
+	m := ntfy.Message{
+		Topic:    s.Topic,
+		Message:  tmpl.Expand(s.Text, v),
+		Title:    tmpl.Expand(title, v),
+		Priority: ntfyPriority(s.Priority, v),
+		Click:    tmpl.Expand(s.Click, v),
+	}
+	for _, t := range s.Tags {
+		m.Tags = append(m.Tags, tmpl.Expand(t, v))
+	}
+	for _, a := range s.Actions {
+		m.Actions = append(m.Actions, ntfy.Action{
+			Action: a.Action,
+			Label:  tmpl.Expand(a.Label, v),
+			URL:    tmpl.Expand(a.URL, v),
+			Method: a.Method,
+			Body:   tmpl.Expand(a.Body, v),
+			Clear:  a.Clear,
+		})
+	}
+	return env.Deliver(func() error { return ntfy.Send(env.Creds.NtfyURL, env.Creds.NtfyToken, m) })
+}
+
+// ntfyPriority returns the step's priority, or one derived from the run
+// when unset: warning severity is high and critical urgent. At the default
+// severity a failed run (an "error" action, a non-zero exit) is high too.
+func ntfyPriority(priority string, v tmpl.Vars) int {
+	if p, err := ntfy.ParsePriority(priority); err == nil && p > 0 {
+		return p
+	}
+	switch {
+	case v.Severity == config.SeverityCritical:
+		return 5
+	case v.Severity == config.SeverityWarning, outcome(v) == outcomeFailure:
+		return 4
+	}
+	return 0
+}
@@ -0,0 +1,29 @@
+package steps
+
+import (
+	"testing"
+
+	"github.com/Mavwarf/notify/internal/config"
+	"github.com/Mavwarf/notify/internal/tmpl"
+)
+
+func TestNtfyPriority(t *testing.T) {
+	tests := []struct {
+		priority string
+		vars     tmpl.Vars
+		want     int
+	}{
+		{"", tmpl.Vars{Action: "ready"}, 0},
+		{"", tmpl.Vars{Action: "error"}, 4},
+		{"", tmpl.Vars{Action: "build", ExitCode: "1"}, 4},
+		{"", tmpl.Vars{Action: "ready", Severity: config.SeverityWarning}, 4},
+		{"", tmpl.Vars{Action: "ready", Severity: config.SeverityCritical}, 5},
+		{"low", tmpl.Vars{Action: "error"}, 2},
+		{"5", tmpl.Vars{Action: "ready"}, 5},
+	}
+	for _, tt := range tests {
+		if got := ntfyPriority(tt.priority, tt.vars); got != tt.want {
+			t.Errorf("ntfyPriority(%q, %+v) = %d, want %d", tt.priority, tt.vars, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package steps
+
+import (
+	"fmt"
+
+	"github.com/Mavwarf/notify/internal/config"
+	"github.com/Mavwarf/notify/internal/plugin"
+	"github.com/Mavwarf/notify/internal/tmpl"
+)
+
+func init() { config.RegisterStep("plugin", pluginStep{}) }
+
+// pluginStep runs an external command with NOTIFY_* environment variables.
+// It is local, so it runs once without retry or outbox queueing.
+type pluginStep struct{ parallel }
+
+func (pluginStep) Validate(s config.Step, _ config.Credentials) []string {
+	errs := require(s, "command", s.Command)
+	if s.Timeout != nil && *s.Timeout < 0 {
+		errs = append(errs, "plugin timeout must not be negative")
+	}
+	return errs
+}
+
+func (pluginStep) Credentials(config.Step) []string { return nil }
+
+func (pluginStep) Sendable() bool { return false }
+
+func (pluginStep) Summary(s config.Step, vars *tmpl.Vars) []string {
+	parts := []string{fmt.Sprintf("command=%q", s.Command)}
+	if s.Text != "" {
+		parts = append(parts, fmt.Sprintf("text=%q", expand(s.Text, vars)))
+	}
+	if s.Timeout != nil {
+		parts = append(parts, fmt.Sprintf("timeout=%d", *s.Timeout))
+	}
+	return parts
+}
+
+func (pluginStep) Execute(s config.Step, env config.StepEnv) error {
+	text := ""
+	if s.Text != "" {
+		text = tmpl.Expand(s.Text, env.Vars)
+	}
+	return plugin.Run(s.Command, text, s.Timeout, env.Vars)
+}
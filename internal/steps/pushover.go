@@ -0,0 +1,90 @@
+package steps
+
+import (
+	"fmt"
+
+	"github.com/Mavwarf/notify/internal/config"
+	"github.com/Mavwarf/notify/internal/pushover"
+	"github.com/Mavwarf/notify/internal/tmpl"
+)
+
+func init() { config.RegisterStep("pushover", pushoverStep{}) }
+
+// pushoverStep sends the text through the Pushover Message API.
+type pushoverStep struct{ parallel }
+
+func (pushoverStep) Validate(s config.Step, creds config.Credentials) []string {
+	errs := require(s, "text", s.Text)
+	if creds.PushoverToken == "" {
+		errs = append(errs, "pushover step requires credentials.pushover_token")
+	}
+	if creds.PushoverUser == "" {
+		errs = append(errs, "pushover step requires credentials.pushover_user")
+	}
+	if s.Priority != "" {
+		if _, err := pushover.ParsePriority(s.Priority); err != nil {
+			errs = append(errs, "pushover "+err.Error())
+		}
+	}
+	if s.Retry != 0 && s.Retry < pushover.MinRetry {
+		errs = append(errs, fmt.Sprintf("pushover retry must be at least %d seconds, got %d", pushover.MinRetry, s.Retry))
+	}
+	if s.Expire < 0 || s.Expire > pushover.MaxExpire {
+		errs = append(errs, fmt.Sprintf("pushover expire must be at most %d seconds, got %d", pushover.MaxExpire, s.Expire))
+	}
+	return errs
+}
+
+func (pushoverStep) Credentials(config.Step) []string {
+	return []string{"pushover_token", "pushover_user"}
+}
+
+func (pushoverStep) Sendable() bool { return true }
+
+func (pushoverStep) Summary(s config.Step, vars *tmpl.Vars) []string {
+	parts := []string{}
+	if s.Priority != "" {
+		parts = append(parts, "priority="+s.Priority)
+	}
+	if s.Device != "" {
+		parts = append(parts, "device="+s.Device)
+	}
+	return append(parts, fmt.Sprintf("text=%q", expand(s.Text, vars)))
+}
+
+func (pushoverStep) Execute(s config.Step, env config.StepEnv) error {
+	v := env.Vars
+	title := s.Title
+	if title == "" {
+		title = "{profile}"
+	}
+	m := pushover.Message{
+		Message:  tmpl.Expand(s.Text, v),
+		Title:    tmpl.Expand(title, v),
+		Priority: pushoverPriority(s.Priority, v.Severity),
+		Sound:    s.Sound,
+		Device:   s.Device,
+		URL:      tmpl.Expand(s.Click, v),
+		Retry:    s.Retry,
+		Expire:   s.Expire,
+	}
+	return env.Deliver(func() error {
+		return pushover.Send(env.Creds.PushoverToken, env.Creds.PushoverUser, m)
+	})
+}
+
+// pushoverPriority returns the step's priority, or one derived from the
+// action's severity when unset: warning is high, critical is emergency.
+func pushoverPriority(priority, severity string) int {
+	if priority != "" {
+		p, _ := pushover.ParsePriority(priority)
+		return p
+	}
+	switch severity {
+	case config.SeverityWarning:
+		return pushover.PriorityHigh
+	case config.SeverityCritical:
+		return pushover.PriorityEmergency
+	}
+	return pushover.PriorityNormal
+}
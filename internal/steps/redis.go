@@ -0,0 +1,69 @@
+package steps
+
+import (
+	"fmt"
+
+	"github.com/Mavwarf/notify/internal/config"
+	"github.com/Mavwarf/notify/internal/redis"
+	"github.com/Mavwarf/notify/internal/tmpl"
+)
+
+func init() { config.RegisterStep("redis", redisStep{}) }
+
+// redisStep publishes the notification as JSON to a Redis channel or
+// appends it to a stream.
+type redisStep struct{ parallel }
+
+func (redisStep) Validate(s config.Step, _ config.Credentials) []string {
+	errs := require(s, "broker", s.Broker)
+	errs = append(errs, require(s, "topic", s.Topic)...)
+	errs = append(errs, require(s, "text", s.Text)...)
+	if s.Broker != "" {
+		if srv, err := redis.ParseURL(s.Broker); err != nil {
+			errs = append(errs, "redis broker "+err.Error())
+		} else if srv.Password != "" {
+			errs = append(errs, "redis broker must not contain a password (use credentials.redis_password)")
+		}
+	}
+	if s.MaxLen < 0 {
+		errs = append(errs, "redis max_len must not be negative")
+	}
+	if s.MaxLen > 0 && !s.Stream {
+		errs = append(errs, `redis max_len requires "stream": true`)
+	}
+	return errs
+}
+
+func (redisStep) Credentials(config.Step) []string { return nil }
+
+func (redisStep) Sendable() bool { return false }
+
+func (redisStep) Summary(s config.Step, vars *tmpl.Vars) []string {
+	parts := []string{"broker=" + redactBroker(s.Broker)}
+	if s.Stream {
+		parts = append(parts, "stream="+expand(s.Topic, vars))
+		if s.MaxLen > 0 {
+			parts = append(parts, fmt.Sprintf("max_len=%d", s.MaxLen))
+		}
+	} else {
+		parts = append(parts, "channel="+expand(s.Topic, vars))
+	}
+	return append(parts, fmt.Sprintf("text=%q", expand(s.Text, vars)))
+}
+
+func (redisStep) Execute(s config.Step, env config.StepEnv) error {
+	srv, err := redis.ParseURL(s.Broker)
+	if err != nil {
+		return fmt.Errorf("redis: %w", err)
+	}
+	srv.Password = env.Creds.RedisPassword
+	key := tmpl.Expand(s.Topic, env.Vars)
+	payload := notificationJSON(tmpl.Expand(s.Text, env.Vars), env.Vars)
+	return env.Deliver(func() error {
+		if s.Stream {
+			_, err := redis.XAdd(srv, key, s.MaxLen, payload)
+			return err
+		}
+		return redis.Publish(srv, key, payload)
+	})
+}
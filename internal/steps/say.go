@@ -0,0 +1,43 @@
+package steps
+
+import (
+	"fmt"
+
+	"github.com/Mavwarf/notify/internal/audio"
+	"github.com/Mavwarf/notify/internal/config"
+	"github.com/Mavwarf/notify/internal/speech"
+	"github.com/Mavwarf/notify/internal/tmpl"
+	"github.com/Mavwarf/notify/internal/voice"
+)
+
+func init() { config.RegisterStep("say", sayStep{}) }
+
+// sayStep speaks text on the local speaker, preferring a cached AI voice.
+type sayStep struct{}
+
+func (sayStep) Validate(s config.Step, _ config.Credentials) []string {
+	return require(s, "text", s.Text)
+}
+
+func (sayStep) Credentials(config.Step) []string { return nil }
+
+func (sayStep) Sendable() bool { return true }
+
+func (sayStep) Summary(s config.Step, vars *tmpl.Vars) []string {
+	return []string{fmt.Sprintf("text=%q", expand(s.Text, vars))}
+}
+
+func (sayStep) Execute(s config.Step, env config.StepEnv) error {
+	text := tmpl.Expand(s.Text, env.Vars)
+	// Fail-open voice cache: if the cache can't be opened or has no entry
+	// for this text, fall through to system TTS rather than returning an error.
+	if cache, err := voice.OpenCache(); err == nil {
+		if wavPath, ok := cache.Lookup(text); ok {
+			return audio.Play(wavPath, float64(env.Volume)/100.0)
+		}
+	}
+	return speech.Say(text, env.Volume)
+}
+
+func (sayStep) Sequential() bool { return true }
+func (sayStep) UsesVoice() bool  { return true }
@@ -0,0 +1,35 @@
+package steps
+
+import (
+	"fmt"
+
+	"github.com/Mavwarf/notify/internal/config"
+	"github.com/Mavwarf/notify/internal/slack"
+	"github.com/Mavwarf/notify/internal/tmpl"
+)
+
+func init() { config.RegisterStep("slack", slackStep{}) }
+
+// slackStep posts a message to a Slack channel via incoming webhook.
+type slackStep struct{ parallel }
+
+func (slackStep) Validate(s config.Step, creds config.Credentials) []string {
+	errs := require(s, "text", s.Text)
+	if creds.SlackWebhook == "" {
+		errs = append(errs, "slack step requires credentials.slack_webhook")
+	}
+	return errs
+}
+
+func (slackStep) Credentials(config.Step) []string { return []string{"slack_webhook"} }
+
+func (slackStep) Sendable() bool { return true }
+
+func (slackStep) Summary(s config.Step, vars *tmpl.Vars) []string {
+	return []string{fmt.Sprintf("text=%q", expand(s.Text, vars))}
+}
+
+func (slackStep) Execute(s config.Step, env config.StepEnv) error {
+	msg := tmpl.Expand(s.Text, env.Vars)
+	return env.Deliver(func() error { return slack.Send(env.Creds.SlackWebhook, msg) })
+}
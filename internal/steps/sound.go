@@ -0,0 +1,33 @@
+package steps
+
+import (
+	"fmt"
+
+	"github.com/Mavwarf/notify/internal/audio"
+	"github.com/Mavwarf/notify/internal/config"
+	"github.com/Mavwarf/notify/internal/tmpl"
+)
+
+func init() { config.RegisterStep("sound", soundStep{}) }
+
+// soundStep plays a built-in sound or a WAV file.
+type soundStep struct{}
+
+func (soundStep) Validate(s config.Step, _ config.Credentials) []string {
+	return require(s, "sound", s.Sound)
+}
+
+func (soundStep) Credentials(config.Step) []string { return nil }
+
+func (soundStep) Sendable() bool { return false }
+
+func (soundStep) Summary(s config.Step, _ *tmpl.Vars) []string {
+	return []string{fmt.Sprintf("sound=%s", s.Sound)}
+}
+
+func (soundStep) Execute(s config.Step, env config.StepEnv) error {
+	return audio.Play(s.Sound, float64(env.Volume)/100.0)
+}
+
+func (soundStep) Sequential() bool { return true }
+func (soundStep) UsesVoice() bool  { return false }
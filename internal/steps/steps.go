@@ -0,0 +1,158 @@
+// Package steps implements the built-in step types. Each file registers
+// one handler (or a family of related handlers) with config.RegisterStep;
+// adding a channel means adding a file here that implements
+// config.StepHandler.
+package steps
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Mavwarf/notify/internal/config"
+	"github.com/Mavwarf/notify/internal/filesink"
+	"github.com/Mavwarf/notify/internal/speech"
+	"github.com/Mavwarf/notify/internal/tmpl"
+	"github.com/Mavwarf/notify/internal/voice"
+)
+
+// remoteVolume is used for TTS in remote voice steps. Volume control
+// is left to the receiving side, so we always render at full volume.
+const remoteVolume = 100
+
+// parallel provides the Sequential and UsesVoice answers shared by steps
+// that don't touch the audio pipeline: they run in parallel and don't speak.
+type parallel struct{}
+
+func (parallel) Sequential() bool { return false }
+func (parallel) UsesVoice() bool  { return false }
+
+// expand returns text with template variables expanded when vars is set
+// (logging mode), or the raw text when it is nil (dry-run mode).
+func expand(text string, vars *tmpl.Vars) string {
+	if vars != nil {
+		return tmpl.Expand(text, *vars)
+	}
+	return text
+}
+
+// require returns the standard error for an empty required field.
+func require(s config.Step, field, value string) []string {
+	if value == "" {
+		return []string{fmt.Sprintf("%s step requires %q field", s.Type, field)}
+	}
+	return nil
+}
+
+// fact is one run detail: key names it in structured records, label on
+// card-style messages.
+type fact struct{ key, label, value string }
+
+// runFacts lists the run details that are known: the profile always, the
+// action, and for wrapped commands the command, duration, and exit code.
+func runFacts(v tmpl.Vars) []fact {
+	facts := []fact{{"profile", "Profile", v.Profile}}
+	for _, f := range []fact{
+		{"action", "Action", v.Action},
+		{"command", "Command", v.Command},
+		{"duration", "Duration", v.Duration},
+		{"exit_code", "Exit code", v.ExitCode},
+	} {
+		if f.value != "" {
+			facts = append(facts, f)
+		}
+	}
+	return facts
+}
+
+// Run outcomes, derived by outcome for channels that color or open and
+// resolve by result. The action's severity is not an outcome: a critical
+// action that exits 0 still succeeded, and callers weigh severity
+// separately for priorities and colors.
+const (
+	outcomeFailure = "failure"
+	outcomeWarning = "warning"
+	outcomeSuccess = "success"
+)
+
+// outcome classifies a run by its exit code and action name: a failed
+// wrapped command or an action named like "error" / "failed" is a
+// failure; a "warn" action a warning; exit code 0 or a "ready",
+// "success", or "done" action a success. Anything else returns "".
+func outcome(v tmpl.Vars) string {
+	action := strings.ToLower(v.Action)
+	switch {
+	case v.ExitCode != "" && v.ExitCode != "0",
+		strings.Contains(action, "error"), strings.Contains(action, "fail"):
+		return outcomeFailure
+	case strings.Contains(action, "warn"):
+		return outcomeWarning
+	case v.ExitCode == "0", action == "ready", action == "success", action == "done":
+		return outcomeSuccess
+	}
+	return ""
+}
+
+// redactBroker returns a broker URL without its userinfo, which may hold a
+// password or token, for summaries that end up in the event log.
+func redactBroker(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		if i := strings.LastIndex(raw, "@"); i >= 0 {
+			return raw[i+1:]
+		}
+		return raw
+	}
+	u.User = nil
+	return u.String()
+}
+
+// notificationLine is the notification for text sent with vars, as the
+// file, redis, and nats steps hand it to other programs.
+func notificationLine(text string, v tmpl.Vars) filesink.Line {
+	return filesink.Line{
+		Time:     time.Now(),
+		Profile:  v.Profile,
+		Action:   v.Action,
+		Severity: v.Severity,
+		Text:     text,
+		Vars:     tmpl.Fields(v),
+	}
+}
+
+// notificationJSON encodes notificationLine as one JSON document.
+func notificationJSON(text string, v tmpl.Vars) []byte {
+	// Strings and a time only, so Marshal cannot fail.
+	data, _ := json.Marshal(notificationLine(text, v))
+	return data
+}
+
+// ttsToTempFile renders text to a temporary WAV file via TTS and returns the
+// file path plus a cleanup function that removes the temp file. If a cached
+// AI voice exists for the text, returns the cached path with a no-op cleanup.
+func ttsToTempFile(prefix, text string) (path string, cleanup func(), err error) {
+	// Check voice cache first.
+	if cache, cErr := voice.OpenCache(); cErr == nil {
+		if wavPath, ok := cache.Lookup(text); ok {
+			return wavPath, func() {}, nil
+		}
+	}
+
+	f, err := os.CreateTemp("", prefix)
+	if err != nil {
+		return "", nil, fmt.Errorf("temp file: %w", err)
+	}
+	path = f.Name()
+	if err := f.Close(); err != nil {
+		return "", nil, fmt.Errorf("close temp: %w", err)
+	}
+	if err := speech.SayToFile(text, remoteVolume, path); err != nil {
+		_ = os.Remove(path)
+		return "", nil, fmt.Errorf("tts: %w", err)
+	}
+	cleanup = func() { _ = os.Remove(path) }
+	return path, cleanup, nil
+}
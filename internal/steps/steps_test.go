@@ -0,0 +1,288 @@
+package steps
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Mavwarf/notify/internal/config"
+	"github.com/Mavwarf/notify/internal/tmpl"
+)
+
+// testEnv returns a StepEnv that delivers immediately, with the data
+// directory (rate limits, incident state) in a temporary home.
+func testEnv(t *testing.T, creds config.Credentials, v tmpl.Vars) config.StepEnv {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	return config.StepEnv{
+		Volume:  100,
+		Creds:   creds,
+		Vars:    v,
+		Deliver: func(send func() error) error { return send() },
+	}
+}
+
+// request is what a fake server saw for one request.
+type request struct {
+	path string
+	body string
+}
+
+// fakeServer records every request and answers 200 with an empty JSON
+// object.
+func fakeServer(t *testing.T) (*httptest.Server, <-chan request) {
+	t.Helper()
+	got := make(chan request, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		got <- request{r.URL.Path, string(body)}
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, "{}")
+	}))
+	t.Cleanup(srv.Close)
+	return srv, got
+}
+
+func TestValidate(t *testing.T) {
+	view := config.NtfyAction{Action: "view", Label: "Open", URL: "https://ci.example.com"}
+	tests := []struct {
+		step config.Step
+		want string // substring of the only error, or "" for none
+	}{
+		{config.Step{Type: "discord", Text: "hi"}, ""},
+		{config.Step{Type: "slack"}, `slack step requires "text" field`},
+		{config.Step{Type: "webhook", Text: "hi"}, `webhook step requires "url" field`},
+		{config.Step{Type: "mqtt", Broker: "tcp://localhost:1883", Text: "hi"}, `mqtt step requires "topic" field`},
+
+		{config.Step{Type: "email", Text: "hi"}, ""},
+		{config.Step{Type: "email", To: "Ops <ops@example.com>, me@example.com", Text: "hi", Attach: "output"}, ""},
+		{config.Step{Type: "email"}, `email step requires "text" field`},
+		{config.Step{Type: "email", To: "not an address", Text: "hi"}, `email to "not an address"`},
+		{config.Step{Type: "email", Text: "hi", Attach: "log"}, `email attach "log" is not valid`},
+
+		{config.Step{Type: "ntfy", Topic: "builds", Text: "hi"}, ""},
+		{config.Step{Type: "ntfy", Topic: "builds", Text: "hi", Priority: "high", Actions: []config.NtfyAction{view}}, ""},
+		{config.Step{Type: "ntfy", Text: "hi"}, `ntfy step requires "topic" field`},
+		{config.Step{Type: "ntfy", Topic: "builds", Text: "hi", Priority: "loud"}, `ntfy priority "loud"`},
+		{config.Step{Type: "ntfy", Topic: "builds", Text: "hi", Actions: []config.NtfyAction{{Action: "view", Label: "Open"}}}, `view action requires "url"`},
+		{config.Step{Type: "ntfy", Topic: "builds", Text: "hi", Actions: []config.NtfyAction{{Action: "call", Label: "x"}}}, `action "call" must be view, http, or broadcast`},
+		{config.Step{Type: "ntfy", Topic: "builds", Text: "hi", Actions: []config.NtfyAction{view, view, view, view}}, "at most 3 actions"},
+
+		{config.Step{Type: "pushover", Text: "hi"}, ""},
+		{config.Step{Type: "pushover", Text: "hi", Priority: "emergency", Retry: 30, Expire: 10800, Sound: "siren"}, ""},
+		{config.Step{Type: "pushover"}, `pushover step requires "text" field`},
+		{config.Step{Type: "pushover", Text: "hi", Priority: "urgent"}, `pushover priority "urgent"`},
+		{config.Step{Type: "pushover", Text: "hi", Retry: 10}, "retry must be at least 30"},
+		{config.Step{Type: "pushover", Text: "hi", Expire: 86400}, "expire must be at most 10800"},
+		{config.Step{Type: "gotify", Text: "hi", Priority: "8", Markdown: true}, ""},
+		{config.Step{Type: "gotify"}, `gotify step requires "text" field`},
+		{config.Step{Type: "gotify", Text: "hi", Priority: "11"}, `gotify priority "11" must be 0-10`},
+
+		{config.Step{Type: "matrix", Text: "hi"}, ""},
+		{config.Step{Type: "matrix", Room: "#ops:example.org", Text: "hi", HTML: "<b>hi</b>"}, ""},
+		{config.Step{Type: "matrix", Room: "!abc:example.org"}, `matrix step requires "text" field`},
+		{config.Step{Type: "matrix", Room: "ops", Text: "hi"}, `matrix room "ops" must be a room ID`},
+
+		{config.Step{Type: "teams", Text: "hi", Attach: "output"}, ""},
+		{config.Step{Type: "teams"}, `teams step requires "text" field`},
+		{config.Step{Type: "teams", Text: "hi", Attach: "log"}, `teams attach "log"`},
+		{config.Step{Type: "mattermost", Text: "hi", Channel: "builds", Icon: ":rocket:"}, ""},
+		{config.Step{Type: "googlechat"}, `googlechat step requires "text" field`},
+
+		{config.Step{Type: "pagerduty", Text: "hi"}, ""},
+		{config.Step{Type: "pagerduty", Text: "hi", Event: "resolve", Priority: "warning", Incident: "deploy-{profile}"}, ""},
+		{config.Step{Type: "opsgenie", Text: "hi", Event: "trigger", Priority: "P2"}, ""},
+		{config.Step{Type: "pagerduty"}, `pagerduty step requires "text" field`},
+		{config.Step{Type: "pagerduty", Text: "hi", Event: "ack"}, `pagerduty event "ack" must be trigger or resolve`},
+		{config.Step{Type: "pagerduty", Text: "hi", Priority: "high"}, `pagerduty priority "high"`},
+		{config.Step{Type: "opsgenie", Text: "hi", Priority: "P6"}, `opsgenie priority "P6" must be P1-P5`},
+
+		{config.Step{Type: "syslog", Text: "hi"}, ""},
+		{config.Step{Type: "syslog", Text: "hi", Address: "journal", Facility: "local3", Priority: "warning"}, ""},
+		{config.Step{Type: "syslog", Text: "hi", Address: "udp://logs.example.com:514", Priority: "3"}, ""},
+		{config.Step{Type: "syslog"}, `syslog step requires "text" field`},
+		{config.Step{Type: "syslog", Text: "hi", Address: "http://logs.example.com"}, `syslog address "http://logs.example.com"`},
+		{config.Step{Type: "syslog", Text: "hi", Address: "tcp://logs.example.com"}, `syslog address "tcp://logs.example.com"`},
+		{config.Step{Type: "syslog", Text: "hi", Facility: "local9"}, `syslog facility "local9"`},
+		{config.Step{Type: "syslog", Text: "hi", Priority: "loud"}, `syslog severity "loud"`},
+
+		{config.Step{Type: "file", Path: "notify.jsonl"}, ""},
+		{config.Step{Type: "file", Path: "{profile}.jsonl", Text: "{Profile} {action}", MaxSize: "10MB", Keep: 5}, ""},
+		{config.Step{Type: "file"}, `file step requires "path" field`},
+		{config.Step{Type: "file", Path: "n.jsonl", MaxSize: "big"}, `file max_size "big"`},
+		{config.Step{Type: "file", Path: "n.jsonl", Keep: -1}, "file keep must not be negative"},
+
+		{config.Step{Type: "terminal", Message: "hi", Protocol: "osc777", Bell: true}, ""},
+		{config.Step{Type: "terminal"}, `terminal step requires "message" field`},
+		{config.Step{Type: "terminal", Message: "hi", Protocol: "osc52"}, `terminal protocol "osc52"`},
+		{config.Step{Type: "tmux", Text: "{command} done", Mode: "rename"}, ""},
+		{config.Step{Type: "tmux"}, `tmux step requires "text" field`},
+		{config.Step{Type: "tmux", Text: "hi", Mode: "blink"}, `tmux mode "blink"`},
+
+		{config.Step{Type: "redis", Broker: "redis://localhost", Topic: "notify", Text: "hi"}, ""},
+		{config.Step{Type: "redis", Broker: "rediss://cache:6380/2", Topic: "notify:{profile}", Text: "hi", Stream: true, MaxLen: 1000}, ""},
+		{config.Step{Type: "redis", Topic: "notify", Text: "hi"}, `redis step requires "broker" field`},
+		{config.Step{Type: "redis", Broker: "localhost:6379", Topic: "notify", Text: "hi"}, `redis broker url "localhost:6379"`},
+		{config.Step{Type: "redis", Broker: "redis://localhost", Topic: "notify", Text: "hi", Stream: true, MaxLen: -1}, "redis max_len must not be negative"},
+		{config.Step{Type: "redis", Broker: "redis://localhost", Topic: "notify", Text: "hi", MaxLen: 100}, `redis max_len requires "stream": true`},
+		{config.Step{Type: "nats", Broker: "nats://localhost:4222", Topic: "notify.{profile}", Text: "hi", JS: true}, ""},
+		{config.Step{Type: "nats", Broker: "nats://localhost", Text: "hi"}, `nats step requires "topic" field`},
+		{config.Step{Type: "nats", Broker: "http://localhost", Topic: "notify", Text: "hi"}, `nats broker url "http://localhost"`},
+	}
+	for _, tt := range tests {
+		errs := config.StepFieldErrors(tt.step)
+		if tt.want == "" {
+			if len(errs) != 0 {
+				t.Errorf("%+v: unexpected errors %v", tt.step, errs)
+			}
+			continue
+		}
+		if len(errs) != 1 || !strings.Contains(errs[0], tt.want) {
+			t.Errorf("%+v: errors = %v, want %q", tt.step, errs, tt.want)
+		}
+	}
+}
+
+// TestCredentials checks each handler's declared credentials against its
+// Validate: with only those set there is no credential error, and
+// leaving any one out adds one.
+func TestCredentials(t *testing.T) {
+	only := func(keys []string) config.Credentials {
+		m := map[string]string{}
+		for _, k := range keys {
+			m[k] = "x"
+		}
+		data, _ := json.Marshal(m)
+		var c config.Credentials
+		if err := json.Unmarshal(data, &c); err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+	var steps []config.Step
+	for _, typ := range config.StepTypes() {
+		steps = append(steps, config.Step{Type: typ, Text: "hi"})
+	}
+	steps = append(steps,
+		config.Step{Type: "email", Text: "hi", To: "ops@example.com"},
+		config.Step{Type: "matrix", Text: "hi", Room: "!abc:example.com"},
+	)
+	for _, s := range steps {
+		h, _ := config.LookupStep(s.Type)
+		keys := h.Credentials(s)
+		base := len(config.StepFieldErrors(s))
+		if got := h.Validate(s, only(keys)); len(got) != base {
+			t.Errorf("%+v with %v: errors = %v", s, keys, got)
+		}
+		for i := range keys {
+			rest := append(append([]string{}, keys[:i]...), keys[i+1:]...)
+			if got := h.Validate(s, only(rest)); len(got) <= base {
+				t.Errorf("%+v without %s: errors = %v", s, keys[i], got)
+			}
+		}
+	}
+}
+
+func TestSummary(t *testing.T) {
+	vars := &tmpl.Vars{Profile: "webapp", Action: "ready", Command: "make build"}
+	tests := []struct {
+		step config.Step
+		vars *tmpl.Vars
+		want string
+	}{
+		{config.Step{Type: "slack", Text: "{profile} {action}"}, nil, `text="{profile} {action}"`},
+		{config.Step{Type: "slack", Text: "{profile} {action}"}, vars, `text="webapp ready"`},
+		{config.Step{Type: "webhook", URL: "https://example.com/hook", Text: "hi"}, nil, `url=https://example.com/hook text="hi"`},
+		{config.Step{Type: "email", To: "me@example.com", Subject: "{profile}", Text: "hi", Attach: "output"}, vars, `to=me@example.com subject="webapp" text="hi" attach=output`},
+		{config.Step{Type: "ntfy", Topic: "builds", Priority: "high", Text: "hi"}, nil, `topic=builds priority=high text="hi"`},
+		{config.Step{Type: "pushover", Priority: "high", Device: "phone", Text: "hi"}, nil, `priority=high device=phone text="hi"`},
+		{config.Step{Type: "gotify", Priority: "8", Markdown: true, Text: "hi"}, nil, `priority=8 markdown text="hi"`},
+		{config.Step{Type: "matrix", Room: "#ops:example.org", Text: "hi", HTML: "<b>hi</b>"}, nil, `room=#ops:example.org text="hi" html`},
+		{config.Step{Type: "teams", Title: "{profile}", Text: "hi", Attach: "output"}, vars, `title="webapp" text="hi" attach=output`},
+		{config.Step{Type: "mattermost", Channel: "builds", Username: "ci", Text: "hi"}, nil, `channel=builds username=ci text="hi"`},
+		{config.Step{Type: "googlechat", Thread: "t-{profile}", Text: "hi"}, vars, `thread="t-webapp" text="hi"`},
+		{config.Step{Type: "pagerduty", Text: "hi"}, nil, `event=auto text="hi"`},
+		{config.Step{Type: "pagerduty", Text: "hi"}, vars, `event=resolve incident=notify/webapp/make build text="hi"`},
+		{config.Step{Type: "opsgenie", Text: "hi"}, &tmpl.Vars{Profile: "webapp", Action: "heartbeat"}, `event=none incident=notify/webapp text="hi"`},
+		{config.Step{Type: "syslog", Priority: "err", Text: "hi"}, nil, `address=local severity=err text="hi"`},
+		{config.Step{Type: "file", Path: "{profile}.jsonl", MaxSize: "10MB"}, vars, `path=webapp.jsonl max_size=10MB`},
+		{config.Step{Type: "terminal", Message: "{action}", Bell: true}, vars, `title="webapp" message="ready" bell`},
+		{config.Step{Type: "tmux", Text: "hi"}, nil, `mode=flag text="hi"`},
+		{config.Step{Type: "redis", Broker: "redis://localhost", Topic: "notify:{profile}", Text: "hi"}, vars, `broker=redis://localhost channel=notify:webapp text="hi"`},
+		{config.Step{Type: "redis", Broker: "redis://localhost", Topic: "notify", Stream: true, MaxLen: 100, Text: "hi"}, nil, `broker=redis://localhost stream=notify max_len=100 text="hi"`},
+		{config.Step{Type: "nats", Broker: "nats://localhost", Topic: "notify.{profile}", JS: true, Text: "hi"}, vars, `broker=nats://localhost subject=notify.webapp jetstream text="hi"`},
+	}
+	for _, tt := range tests {
+		h, ok := config.LookupStep(tt.step.Type)
+		if !ok {
+			t.Fatalf("%s not registered", tt.step.Type)
+		}
+		if got := strings.Join(h.Summary(tt.step, tt.vars), " "); got != tt.want {
+			t.Errorf("%s summary = %q, want %q", tt.step.Type, got, tt.want)
+		}
+	}
+}
+
+func TestOutcome(t *testing.T) {
+	tests := []struct {
+		vars tmpl.Vars
+		want string
+	}{
+		{tmpl.Vars{Action: "ready"}, outcomeSuccess},
+		{tmpl.Vars{Action: "build", ExitCode: "0"}, outcomeSuccess},
+		{tmpl.Vars{Action: "build", ExitCode: "2"}, outcomeFailure},
+		{tmpl.Vars{Action: "error"}, outcomeFailure},
+		{tmpl.Vars{Action: "deploy_failed"}, outcomeFailure},
+		{tmpl.Vars{Action: "ready", Severity: config.SeverityCritical}, outcomeSuccess},
+		{tmpl.Vars{Action: "deploy", ExitCode: "0", Severity: config.SeverityCritical}, outcomeSuccess},
+		{tmpl.Vars{Action: "deploy", ExitCode: "1", Severity: config.SeverityWarning}, outcomeFailure},
+		{tmpl.Vars{Action: "disk", Severity: config.SeverityWarning}, ""},
+		{tmpl.Vars{Action: "warning"}, outcomeWarning},
+		{tmpl.Vars{Action: "heartbeat"}, ""},
+	}
+	for _, tt := range tests {
+		if got := outcome(tt.vars); got != tt.want {
+			t.Errorf("outcome(%+v) = %q, want %q", tt.vars, got, tt.want)
+		}
+	}
+}
+
+// TestExecuteRemote delivers each webhook-style step to a fake server and
+// checks the expanded text arrives.
+func TestExecuteRemote(t *testing.T) {
+	srv, got := fakeServer(t)
+	tests := []struct {
+		step  config.Step
+		creds config.Credentials
+		path  string
+	}{
+		{config.Step{Type: "discord"}, config.Credentials{DiscordWebhook: srv.URL + "/discord"}, "/discord"},
+		{config.Step{Type: "slack"}, config.Credentials{SlackWebhook: srv.URL + "/slack"}, "/slack"},
+		{config.Step{Type: "webhook", URL: srv.URL + "/hook"}, config.Credentials{}, "/hook"},
+		{config.Step{Type: "ntfy", Topic: "builds"}, config.Credentials{NtfyURL: srv.URL}, "/"},
+		{config.Step{Type: "gotify"}, config.Credentials{GotifyURL: srv.URL, GotifyToken: "t"}, "/message"},
+		{config.Step{Type: "matrix", Room: "!r:example.org"}, config.Credentials{MatrixURL: srv.URL, MatrixToken: "t"}, "/_matrix/client/v3/rooms/!r:example.org/send/m.room.message/"},
+		{config.Step{Type: "teams"}, config.Credentials{TeamsWebhook: srv.URL + "/teams"}, "/teams"},
+		{config.Step{Type: "mattermost"}, config.Credentials{MattermostHook: srv.URL + "/mm"}, "/mm"},
+		{config.Step{Type: "googlechat"}, config.Credentials{GoogleChatHook: srv.URL + "/chat"}, "/chat"},
+	}
+	for _, tt := range tests {
+		tt.step.Text = "{profile} {action}"
+		h, _ := config.LookupStep(tt.step.Type)
+		env := testEnv(t, tt.creds, tmpl.Vars{Profile: "webapp", Action: "ready"})
+		if err := h.Execute(tt.step, env); err != nil {
+			t.Errorf("%s: %v", tt.step.Type, err)
+			continue
+		}
+		r := <-got
+		if !strings.HasPrefix(r.path, tt.path) {
+			t.Errorf("%s: path = %q, want prefix %q", tt.step.Type, r.path, tt.path)
+		}
+		if !strings.Contains(r.body, "webapp ready") {
+			t.Errorf("%s: body %q lacks the text", tt.step.Type, r.body)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package steps
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Mavwarf/notify/internal/config"
+	"github.com/Mavwarf/notify/internal/syslog"
+	"github.com/Mavwarf/notify/internal/tmpl"
+)
+
+func init() { config.RegisterStep("syslog", syslogStep{}) }
+
+// syslogStep writes the text as a structured record to syslog or journald.
+type syslogStep struct{ parallel }
+
+func (syslogStep) Validate(s config.Step, _ config.Credentials) []string {
+	errs := require(s, "text", s.Text)
+	if s.Address != "" {
+		if _, _, err := syslog.ParseAddress(s.Address); err != nil {
+			errs = append(errs, "syslog "+err.Error())
+		}
+	}
+	if _, err := syslog.ParseFacility(s.Facility); err != nil {
+		errs = append(errs, "syslog "+err.Error())
+	}
+	if s.Priority != "" {
+		if _, err := syslog.ParseSeverity(s.Priority); err != nil {
+			errs = append(errs, "syslog "+err.Error())
+		}
+	}
+	return errs
+}
+
+func (syslogStep) Credentials(config.Step) []string { return nil }
+
+func (syslogStep) Sendable() bool { return true }
+
+func (syslogStep) Summary(s config.Step, vars *tmpl.Vars) []string {
+	addr := s.Address
+	if addr == "" {
+		addr = "local"
+	}
+	parts := []string{"address=" + addr}
+	if s.Priority != "" {
+		parts = append(parts, "severity="+s.Priority)
+	}
+	return append(parts, fmt.Sprintf("text=%q", expand(s.Text, vars)))
+}
+
+func (syslogStep) Execute(s config.Step, env config.StepEnv) error {
+	v := env.Vars
+	addr := s.Address
+	if addr == "" {
+		if addr = syslog.DefaultAddress(); addr == "" {
+			return fmt.Errorf("syslog: no local journald or syslog socket; set \"address\"")
+		}
+	}
+	facility, _ := syslog.ParseFacility(s.Facility)
+	r := syslog.Record{
+		Time:     time.Now(),
+		Facility: facility,
+		Severity: syslogSeverity(s.Priority, v),
+		Hostname: v.Hostname,
+		AppName:  "notify",
+		MsgID:    v.Action,
+		Message:  tmpl.Expand(s.Text, v),
+	}
+	for _, f := range runFacts(v) {
+		r.Fields = append(r.Fields, syslog.Field{Name: f.key, Value: f.value})
+	}
+	r.Fields = append(r.Fields, syslog.Field{Name: "severity", Value: v.Severity})
+	return env.Deliver(func() error { return syslog.Send(addr, r) })
+}
+
+// syslogSeverity returns the step's severity, or one derived from the run:
+// a critical action is crit whatever its outcome, failures err, warnings
+// warning, successes notice, and anything else info.
+func syslogSeverity(priority string, v tmpl.Vars) int {
+	if priority != "" {
+		sev, _ := syslog.ParseSeverity(priority)
+		return sev
+	}
+	if v.Severity == config.SeverityCritical {
+		return syslog.SevCritical
+	}
+	switch outcome(v) {
+	case outcomeFailure:
+		return syslog.SevError
+	case outcomeWarning:
+		return syslog.SevWarning
+	case outcomeSuccess:
+		return syslog.SevNotice
+	}
+	return syslog.SevInfo
+}
@@ -0,0 +1,41 @@
+package steps
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Mavwarf/notify/internal/config"
+	"github.com/Mavwarf/notify/internal/tmpl"
+)
+
+func TestSyslogExecute(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	s := config.Step{Type: "syslog", Address: "udp://" + conn.LocalAddr().String(), Facility: "local0", Text: "{command} failed"}
+	v := tmpl.Vars{Profile: "webapp", Action: "error", Command: "make", ExitCode: "2"}
+	if err := (syslogStep{}).Execute(s, testEnv(t, config.Credentials{}, v)); err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, 2048)
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := string(buf[:n])
+	// local0 (16) * 8 + err (3) for a failure.
+	if !strings.HasPrefix(msg, "<131>1 ") {
+		t.Errorf("priority: %q", msg)
+	}
+	for _, want := range []string{` error [notify@32473 `, `profile="webapp"`, `exit_code="2"`, "make failed"} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("message %q lacks %q", msg, want)
+		}
+	}
+}
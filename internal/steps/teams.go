@@ -0,0 +1,86 @@
+package steps
+
+import (
+	"fmt"
+
+	"github.com/Mavwarf/notify/internal/config"
+	"github.com/Mavwarf/notify/internal/teams"
+	"github.com/Mavwarf/notify/internal/tmpl"
+)
+
+func init() { config.RegisterStep("teams", teamsStep{}) }
+
+// teamsStep posts an Adaptive Card to a Microsoft Teams webhook.
+type teamsStep struct{ parallel }
+
+func (teamsStep) Validate(s config.Step, creds config.Credentials) []string {
+	errs := require(s, "text", s.Text)
+	if creds.TeamsWebhook == "" {
+		errs = append(errs, "teams step requires credentials.teams_webhook")
+	}
+	if s.Attach != "" && s.Attach != "output" {
+		errs = append(errs, fmt.Sprintf(`teams attach %q is not valid (use "output")`, s.Attach))
+	}
+	return errs
+}
+
+func (teamsStep) Credentials(config.Step) []string { return []string{"teams_webhook"} }
+
+func (teamsStep) Sendable() bool { return true }
+
+func (teamsStep) Summary(s config.Step, vars *tmpl.Vars) []string {
+	parts := []string{}
+	if s.Title != "" {
+		parts = append(parts, fmt.Sprintf("title=%q", expand(s.Title, vars)))
+	}
+	parts = append(parts, fmt.Sprintf("text=%q", expand(s.Text, vars)))
+	if s.Attach != "" {
+		parts = append(parts, "attach="+s.Attach)
+	}
+	return parts
+}
+
+func (teamsStep) Execute(s config.Step, env config.StepEnv) error {
+	v := env.Vars
+	title := s.Title
+	if title == "" {
+		title = "{Profile}"
+	}
+	card := teams.Card{
+		Title: tmpl.Expand(title, v),
+		Text:  tmpl.Expand(s.Text, v),
+		Color: teamsColor(v),
+		Facts: teamsFacts(v),
+	}
+	if s.Attach == "output" {
+		card.Output = v.Output
+	}
+	return env.Deliver(func() error { return teams.Send(env.Creds.TeamsWebhook, card) })
+}
+
+// teamsFacts converts runFacts into a card fact set.
+func teamsFacts(v tmpl.Vars) []teams.Fact {
+	var facts []teams.Fact
+	for _, f := range runFacts(v) {
+		facts = append(facts, teams.Fact{Title: f.label, Value: f.value})
+	}
+	return facts
+}
+
+// teamsColor picks the title color from the run's outcome: failures are
+// red and successes green. Other runs take the action's severity into
+// account: critical is red, warning (or a warning outcome) yellow, and
+// anything else gets the accent color.
+func teamsColor(v tmpl.Vars) string {
+	switch o := outcome(v); {
+	case o == outcomeFailure:
+		return teams.ColorAttention
+	case o == outcomeSuccess:
+		return teams.ColorGood
+	case v.Severity == config.SeverityCritical:
+		return teams.ColorAttention
+	case o == outcomeWarning, v.Severity == config.SeverityWarning:
+		return teams.ColorWarning
+	}
+	return teams.ColorAccent
+}
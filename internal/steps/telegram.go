@@ -0,0 +1,113 @@
+package steps
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/Mavwarf/notify/internal/config"
+	"github.com/Mavwarf/notify/internal/ffmpeg"
+	"github.com/Mavwarf/notify/internal/telegram"
+	"github.com/Mavwarf/notify/internal/tmpl"
+)
+
+func init() {
+	config.RegisterStep("telegram", telegramStep{})
+	config.RegisterStep("telegram_audio", telegramAudioStep{})
+	config.RegisterStep("telegram_voice", telegramVoiceStep{})
+}
+
+// telegramStep sends a text message to a Telegram chat via bot.
+type telegramStep struct{ parallel }
+
+func (telegramStep) Validate(s config.Step, creds config.Credentials) []string {
+	return validateTelegram(s, creds)
+}
+
+func (telegramStep) Credentials(config.Step) []string {
+	return []string{"telegram_token", "telegram_chat_id"}
+}
+
+func (telegramStep) Sendable() bool { return true }
+
+func (telegramStep) Summary(s config.Step, vars *tmpl.Vars) []string {
+	return []string{fmt.Sprintf("text=%q", expand(s.Text, vars))}
+}
+
+func (telegramStep) Execute(s config.Step, env config.StepEnv) error {
+	msg := tmpl.Expand(s.Text, env.Vars)
+	return env.Deliver(func() error { return telegram.Send(env.Creds.TelegramToken, env.Creds.TelegramChatID, msg) })
+}
+
+// telegramAudioStep renders text via TTS and uploads it as a WAV audio file.
+type telegramAudioStep struct{ telegramVoiced }
+
+func (telegramAudioStep) Execute(s config.Step, env config.StepEnv) error {
+	text := tmpl.Expand(s.Text, env.Vars)
+	wavPath, cleanup, err := ttsToTempFile("notify-tgaudio-*.wav", text)
+	if err != nil {
+		return fmt.Errorf("telegram_audio: %w", err)
+	}
+	defer cleanup()
+	return env.Deliver(func() error {
+		return telegram.SendAudio(env.Creds.TelegramToken, env.Creds.TelegramChatID, wavPath, text)
+	})
+}
+
+// telegramVoiceStep renders text via TTS, converts it to OGG/OPUS, and
+// uploads it as a voice bubble.
+type telegramVoiceStep struct{ telegramVoiced }
+
+func (telegramVoiceStep) Execute(s config.Step, env config.StepEnv) error {
+	text := tmpl.Expand(s.Text, env.Vars)
+	// Two temp files are created: WAV (from TTS) and OGG (converted for Telegram).
+	// Each needs its own deferred cleanup. wavCleanup may be a no-op if using
+	// a cached AI voice file; the OGG is always a fresh temp file.
+	wavPath, wavCleanup, err := ttsToTempFile("notify-tgvoice-*.wav", text)
+	if err != nil {
+		return fmt.Errorf("telegram_voice: %w", err)
+	}
+	defer wavCleanup()
+	oggFile, err := os.CreateTemp("", "notify-tgvoice-*.ogg")
+	if err != nil {
+		return fmt.Errorf("telegram_voice ogg temp file: %w", err)
+	}
+	oggPath := oggFile.Name()
+	if err := oggFile.Close(); err != nil {
+		return fmt.Errorf("telegram_voice close ogg temp: %w", err)
+	}
+	if err := ffmpeg.ToOGG(wavPath, oggPath); err != nil {
+		return fmt.Errorf("telegram_voice convert: %w", err)
+	}
+	defer func() { _ = os.Remove(oggPath) }()
+	return env.Deliver(func() error {
+		return telegram.SendVoice(env.Creds.TelegramToken, env.Creds.TelegramChatID, oggPath, text)
+	})
+}
+
+// telegramVoiced holds what the TTS-based Telegram steps share.
+type telegramVoiced struct{}
+
+func (telegramVoiced) Validate(s config.Step, creds config.Credentials) []string {
+	return validateTelegram(s, creds)
+}
+
+func (telegramVoiced) Credentials(config.Step) []string {
+	return []string{"telegram_token", "telegram_chat_id"}
+}
+
+func (telegramVoiced) Sendable() bool { return true }
+
+func (telegramVoiced) Summary(s config.Step, vars *tmpl.Vars) []string {
+	return []string{fmt.Sprintf("text=%q", expand(s.Text, vars))}
+}
+
+func (telegramVoiced) Sequential() bool { return false }
+func (telegramVoiced) UsesVoice() bool  { return true }
+
+func validateTelegram(s config.Step, creds config.Credentials) []string {
+	errs := require(s, "text", s.Text)
+	if creds.TelegramToken == "" || creds.TelegramChatID == "" {
+		errs = append(errs, fmt.Sprintf("%s step requires credentials.telegram_token and telegram_chat_id", s.Type))
+	}
+	return errs
+}
@@ -0,0 +1,62 @@
+package steps
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/Mavwarf/notify/internal/config"
+	"github.com/Mavwarf/notify/internal/terminal"
+	"github.com/Mavwarf/notify/internal/tmpl"
+)
+
+func init() { config.RegisterStep("terminal", terminalStep{}) }
+
+// terminalStep shows a notification through the terminal emulator with
+// an OSC escape sequence. It is local, so it runs once without retry or
+// outbox queueing. The title defaults to the profile name.
+type terminalStep struct{ parallel }
+
+func (terminalStep) Validate(s config.Step, _ config.Credentials) []string {
+	errs := require(s, "message", s.Message)
+	if s.Protocol != "" && !slices.Contains(terminal.Protocols, s.Protocol) {
+		errs = append(errs, fmt.Sprintf("terminal protocol %q must be %s", s.Protocol, strings.Join(terminal.Protocols, ", ")))
+	}
+	return errs
+}
+
+func (terminalStep) Credentials(config.Step) []string { return nil }
+
+func (terminalStep) Sendable() bool { return true }
+
+func (terminalStep) Summary(s config.Step, vars *tmpl.Vars) []string {
+	var parts []string
+	if s.Protocol != "" {
+		parts = append(parts, "protocol="+s.Protocol)
+	}
+	title := s.Title
+	if title == "" && vars != nil {
+		title = vars.Profile
+	}
+	if title != "" {
+		parts = append(parts, fmt.Sprintf("title=%q", expand(title, vars)))
+	}
+	parts = append(parts, fmt.Sprintf("message=%q", expand(s.Message, vars)))
+	if s.Bell {
+		parts = append(parts, "bell")
+	}
+	return parts
+}
+
+func (terminalStep) Execute(s config.Step, env config.StepEnv) error {
+	title := s.Title
+	if title == "" {
+		title = env.Vars.Profile
+	}
+	return terminal.Send(terminal.Notification{
+		Protocol: s.Protocol,
+		Title:    tmpl.Expand(title, env.Vars),
+		Body:     tmpl.Expand(s.Message, env.Vars),
+		Bell:     s.Bell,
+	})
+}
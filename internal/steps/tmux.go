@@ -0,0 +1,53 @@
+package steps
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/Mavwarf/notify/internal/config"
+	"github.com/Mavwarf/notify/internal/tmpl"
+	"github.com/Mavwarf/notify/internal/tmux"
+)
+
+func init() { config.RegisterStep("tmux", tmuxStep{}) }
+
+// tmuxStep marks the tmux window the wrapped command ran in. It is local,
+// so it runs once without retry or outbox queueing, and does nothing when
+// the command didn't run inside tmux.
+type tmuxStep struct{ parallel }
+
+func (tmuxStep) Validate(s config.Step, _ config.Credentials) []string {
+	errs := require(s, "text", s.Text)
+	if s.Mode != "" && !slices.Contains(tmux.Modes, s.Mode) {
+		errs = append(errs, fmt.Sprintf("tmux mode %q must be %s", s.Mode, strings.Join(tmux.Modes, ", ")))
+	}
+	return errs
+}
+
+func (tmuxStep) Credentials(config.Step) []string { return nil }
+
+func (tmuxStep) Sendable() bool { return true }
+
+func (tmuxStep) Summary(s config.Step, vars *tmpl.Vars) []string {
+	mode := s.Mode
+	if mode == "" {
+		mode = tmux.ModeFlag
+	}
+	parts := []string{"mode=" + mode}
+	if vars != nil && vars.TmuxPane != "" {
+		parts = append(parts, "pane="+vars.TmuxPane)
+	}
+	return append(parts, fmt.Sprintf("text=%q", expand(s.Text, vars)))
+}
+
+func (tmuxStep) Execute(s config.Step, env config.StepEnv) error {
+	if env.Vars.TmuxPane == "" {
+		return nil
+	}
+	mode := s.Mode
+	if mode == "" {
+		mode = tmux.ModeFlag
+	}
+	return tmux.Notify(mode, env.Vars.TmuxPane, tmpl.Expand(s.Text, env.Vars))
+}
@@ -0,0 +1,43 @@
+package steps
+
+import (
+	"fmt"
+
+	"github.com/Mavwarf/notify/internal/config"
+	"github.com/Mavwarf/notify/internal/tmpl"
+	"github.com/Mavwarf/notify/internal/toast"
+)
+
+func init() { config.RegisterStep("toast", toastStep{}) }
+
+// toastStep shows a desktop notification. The title defaults to the
+// profile name.
+type toastStep struct{ parallel }
+
+func (toastStep) Validate(s config.Step, _ config.Credentials) []string {
+	return require(s, "message", s.Message)
+}
+
+func (toastStep) Credentials(config.Step) []string { return nil }
+
+func (toastStep) Sendable() bool { return true }
+
+func (toastStep) Summary(s config.Step, vars *tmpl.Vars) []string {
+	var parts []string
+	title := s.Title
+	if title == "" && vars != nil {
+		title = vars.Profile
+	}
+	if title != "" {
+		parts = append(parts, fmt.Sprintf("title=%q", expand(title, vars)))
+	}
+	return append(parts, fmt.Sprintf("message=%q", expand(s.Message, vars)))
+}
+
+func (toastStep) Execute(s config.Step, env config.StepEnv) error {
+	title := s.Title
+	if title == "" {
+		title = env.Vars.Profile
+	}
+	return toast.Show(tmpl.Expand(title, env.Vars), tmpl.Expand(s.Message, env.Vars), env.Desktop)
+}
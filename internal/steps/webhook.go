@@ -0,0 +1,31 @@
+package steps
+
+import (
+	"fmt"
+
+	"github.com/Mavwarf/notify/internal/config"
+	"github.com/Mavwarf/notify/internal/tmpl"
+	"github.com/Mavwarf/notify/internal/webhook"
+)
+
+func init() { config.RegisterStep("webhook", webhookStep{}) }
+
+// webhookStep POSTs the text to an arbitrary URL with custom headers.
+type webhookStep struct{ parallel }
+
+func (webhookStep) Validate(s config.Step, _ config.Credentials) []string {
+	return append(require(s, "url", s.URL), require(s, "text", s.Text)...)
+}
+
+func (webhookStep) Credentials(config.Step) []string { return nil }
+
+func (webhookStep) Sendable() bool { return false }
+
+func (webhookStep) Summary(s config.Step, vars *tmpl.Vars) []string {
+	return []string{fmt.Sprintf("url=%s", s.URL), fmt.Sprintf("text=%q", expand(s.Text, vars))}
+}
+
+func (webhookStep) Execute(s config.Step, env config.StepEnv) error {
+	msg := tmpl.Expand(s.Text, env.Vars)
+	return env.Deliver(func() error { return webhook.Send(s.URL, msg, s.Headers) })
+}
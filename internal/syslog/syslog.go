@@ -0,0 +1,248 @@
+// Package syslog writes notification records to a syslog server as
+// RFC 5424 messages (UDP, TCP, or a unix socket) or to systemd-journald
+// using its native protocol, so structured fields survive as fields.
+package syslog
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Severities (RFC 5424 section 6.2.1).
+const (
+	SevEmergency = iota
+	SevAlert
+	SevCritical
+	SevError
+	SevWarning
+	SevNotice
+	SevInfo
+	SevDebug
+)
+
+// FacilityUser is the default facility for user-level messages.
+const FacilityUser = 1
+
+// Journal is the address that selects systemd-journald.
+const Journal = "journal"
+
+// journalSocket is where journald listens for native protocol datagrams.
+const journalSocket = "/run/systemd/journal/socket"
+
+// sdID is the structured data ID of the notify element. 32473 is the
+// enterprise number reserved for documentation (RFC 5612).
+const sdID = "notify@32473"
+
+var severities = map[string]int{
+	"emerg": SevEmergency, "alert": SevAlert, "crit": SevCritical,
+	"err": SevError, "error": SevError, "warning": SevWarning, "warn": SevWarning,
+	"notice": SevNotice, "info": SevInfo, "debug": SevDebug,
+}
+
+var facilities = map[string]int{
+	"kern": 0, "user": 1, "mail": 2, "daemon": 3, "auth": 4, "syslog": 5,
+	"lpr": 6, "news": 7, "uucp": 8, "cron": 9, "authpriv": 10, "ftp": 11,
+	"local0": 16, "local1": 17, "local2": 18, "local3": 19,
+	"local4": 20, "local5": 21, "local6": 22, "local7": 23,
+}
+
+// ParseSeverity accepts a severity name ("err") or level ("3").
+func ParseSeverity(s string) (int, error) {
+	if v, ok := severities[strings.ToLower(s)]; ok {
+		return v, nil
+	}
+	if v, err := strconv.Atoi(s); err == nil && v >= SevEmergency && v <= SevDebug {
+		return v, nil
+	}
+	return 0, fmt.Errorf("severity %q must be emerg, alert, crit, err, warning, notice, info, debug, or 0-7", s)
+}
+
+// ParseFacility accepts a facility name ("local0"). An empty string
+// returns FacilityUser.
+func ParseFacility(s string) (int, error) {
+	if s == "" {
+		return FacilityUser, nil
+	}
+	if v, ok := facilities[strings.ToLower(s)]; ok {
+		return v, nil
+	}
+	return 0, fmt.Errorf("facility %q must be kern, user, mail, daemon, auth, syslog, lpr, news, uucp, cron, authpriv, ftp, or local0-local7", s)
+}
+
+// Field is a structured field. Names are lower case ("profile"); journald
+// receives them upper-cased with a NOTIFY_ prefix.
+type Field struct {
+	Name  string
+	Value string
+}
+
+// Record is one log record. Empty fields are omitted.
+type Record struct {
+	Time     time.Time
+	Facility int
+	Severity int
+	Hostname string
+	AppName  string
+	MsgID    string
+	Message  string
+	Fields   []Field
+}
+
+// ParseAddress validates an address: "udp://host:port", "tcp://host:port",
+// "unix:///path", or Journal.
+func ParseAddress(addr string) (network, target string, err error) {
+	if addr == Journal {
+		return "unixgram", journalSocket, nil
+	}
+	scheme, rest, ok := strings.Cut(addr, "://")
+	if !ok || rest == "" {
+		return "", "", fmt.Errorf("address %q must be udp://host:port, tcp://host:port, unix:///path, or journal", addr)
+	}
+	switch scheme {
+	case "udp", "tcp":
+		if _, _, err := net.SplitHostPort(rest); err != nil {
+			return "", "", fmt.Errorf("address %q: %w", addr, err)
+		}
+		return scheme, rest, nil
+	case "unix":
+		return "unixgram", rest, nil
+	}
+	return "", "", fmt.Errorf("address %q: scheme must be udp, tcp, or unix", addr)
+}
+
+// DefaultAddress returns the local log daemon: journald when it is
+// running, else the syslog socket. It returns "" when neither exists.
+func DefaultAddress() string {
+	if _, err := os.Stat(journalSocket); err == nil {
+		return Journal
+	}
+	for _, p := range []string{"/dev/log", "/var/run/syslog"} {
+		if _, err := os.Stat(p); err == nil {
+			return "unix://" + p
+		}
+	}
+	return ""
+}
+
+// Send writes r to addr (see ParseAddress).
+func Send(addr string, r Record) error {
+	network, target, err := ParseAddress(addr)
+	if err != nil {
+		return fmt.Errorf("syslog: %w", err)
+	}
+	conn, err := net.DialTimeout(network, target, 10*time.Second)
+	if err != nil && network == "unixgram" && addr != Journal {
+		// Not a datagram socket: try a stream socket (rsyslog imuxsock
+		// with SOCK_STREAM, syslog-ng unix-stream), like log/syslog does.
+		network = "unix"
+		conn, err = net.DialTimeout(network, target, 10*time.Second)
+	}
+	if err != nil {
+		return fmt.Errorf("syslog: dial: %w", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(10 * time.Second))
+
+	var data []byte
+	switch {
+	case addr == Journal:
+		data = journalData(r)
+	case network == "tcp":
+		// Octet-counting framing (RFC 6587), so messages may hold newlines.
+		msg := Format(r)
+		data = append([]byte(strconv.Itoa(len(msg))+" "), msg...)
+	case network == "unix":
+		// Local stream sockets split messages on newlines.
+		data = append(Format(r), '\n')
+	default:
+		data = Format(r)
+	}
+	if _, err := conn.Write(data); err != nil {
+		return fmt.Errorf("syslog: write: %w", err)
+	}
+	return nil
+}
+
+// Format renders r as an RFC 5424 message with the fields as the
+// structured data of a notify@32473 element.
+func Format(r Record) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "<%d>1 %s %s %s %d %s ",
+		r.Facility*8+r.Severity,
+		nilValue(r.Time.Format(time.RFC3339Nano), r.Time.IsZero()),
+		header(r.Hostname, 255), header(r.AppName, 48), os.Getpid(), header(r.MsgID, 32))
+	var params []string
+	for _, f := range r.Fields {
+		if f.Value != "" {
+			params = append(params, fmt.Sprintf(`%s="%s"`, f.Name, sdEscape.Replace(f.Value)))
+		}
+	}
+	if len(params) == 0 {
+		b.WriteString("-")
+	} else {
+		fmt.Fprintf(&b, "[%s %s]", sdID, strings.Join(params, " "))
+	}
+	if r.Message != "" {
+		b.WriteString(" " + r.Message)
+	}
+	return b.Bytes()
+}
+
+// nilValue returns "-" (the RFC 5424 NILVALUE) when empty is set.
+func nilValue(s string, empty bool) string {
+	if empty {
+		return "-"
+	}
+	return s
+}
+
+// header makes s a valid header field: printable ASCII without spaces,
+// at most limit characters, or "-" when empty.
+func header(s string, limit int) string {
+	s = strings.Map(func(r rune) rune {
+		if r <= ' ' || r > '~' {
+			return '_'
+		}
+		return r
+	}, s)
+	if len(s) > limit {
+		s = s[:limit]
+	}
+	return nilValue(s, s == "")
+}
+
+// sdEscape escapes the characters RFC 5424 reserves in parameter values.
+var sdEscape = strings.NewReplacer(`"`, `\"`, `\`, `\\`, `]`, `\]`)
+
+// journalData encodes r in journald's native protocol: one KEY=value line
+// per field, or KEY, newline, little-endian length, and the raw value for
+// values that contain a newline.
+func journalData(r Record) []byte {
+	var b bytes.Buffer
+	add := func(key, value string) {
+		if value == "" {
+			return
+		}
+		if !strings.Contains(value, "\n") {
+			fmt.Fprintf(&b, "%s=%s\n", key, value)
+			return
+		}
+		b.WriteString(key + "\n")
+		binary.Write(&b, binary.LittleEndian, uint64(len(value)))
+		b.WriteString(value + "\n")
+	}
+	add("MESSAGE", r.Message)
+	add("PRIORITY", strconv.Itoa(r.Severity))
+	add("SYSLOG_FACILITY", strconv.Itoa(r.Facility))
+	add("SYSLOG_IDENTIFIER", r.AppName)
+	for _, f := range r.Fields {
+		add("NOTIFY_"+strings.ToUpper(f.Name), f.Value)
+	}
+	return b.Bytes()
+}
@@ -0,0 +1,214 @@
+package syslog
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testRecord = Record{
+	Time:     time.Date(2026, 10, 17, 9, 30, 0, 0, time.UTC),
+	Facility: FacilityUser,
+	Severity: SevError,
+	Hostname: "buildbox",
+	AppName:  "notify",
+	MsgID:    "error",
+	Message:  "make failed",
+	Fields:   []Field{{"profile", "webapp"}, {"command", `make "all"`}, {"exit_code", "2"}, {"duration", ""}},
+}
+
+func TestFormat(t *testing.T) {
+	got := string(Format(testRecord))
+	wantPrefix := "<11>1 2026-10-17T09:30:00Z buildbox notify "
+	if !strings.HasPrefix(got, wantPrefix) {
+		t.Fatalf("Format = %q, want prefix %q", got, wantPrefix)
+	}
+	wantSuffix := ` error [notify@32473 profile="webapp" command="make \"all\"" exit_code="2"] make failed`
+	if !strings.HasSuffix(got, wantSuffix) {
+		t.Errorf("Format = %q, want suffix %q", got, wantSuffix)
+	}
+}
+
+func TestFormatNilValues(t *testing.T) {
+	got := string(Format(Record{Severity: SevInfo, Facility: 16, Message: "hi"}))
+	if !strings.HasPrefix(got, "<134>1 - - - ") || !strings.HasSuffix(got, " - - hi") {
+		t.Errorf("Format = %q", got)
+	}
+}
+
+func TestSendUDP(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pc.Close()
+
+	if err := Send("udp://"+pc.LocalAddr().String(), testRecord); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	buf := make([]byte, 2048)
+	pc.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, _, err := pc.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(buf[:n]); !strings.HasPrefix(got, "<11>1 ") || !strings.HasSuffix(got, "make failed") {
+		t.Errorf("datagram = %q", got)
+	}
+}
+
+func TestSendTCPOctetCounting(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	got := make(chan string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			got <- ""
+			return
+		}
+		defer conn.Close()
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		data, _ := io.ReadAll(conn)
+		got <- string(data)
+	}()
+
+	if err := Send("tcp://"+ln.Addr().String(), testRecord); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	data := <-got
+	size, msg, ok := strings.Cut(data, " ")
+	if !ok || size != strconv.Itoa(len(msg)) {
+		t.Errorf("frame = %q, want octet count prefix", data)
+	}
+	if !strings.HasPrefix(msg, "<11>1 ") || !strings.HasSuffix(msg, "make failed") {
+		t.Errorf("message = %q", msg)
+	}
+}
+
+func TestJournalData(t *testing.T) {
+	r := testRecord
+	r.Message = "line one\nline two"
+	data := journalData(r)
+
+	if !bytes.Contains(data, []byte("PRIORITY=3\n")) || !bytes.Contains(data, []byte("SYSLOG_IDENTIFIER=notify\n")) {
+		t.Errorf("missing standard fields: %q", data)
+	}
+	if !bytes.Contains(data, []byte("NOTIFY_PROFILE=webapp\n")) || !bytes.Contains(data, []byte("NOTIFY_EXIT_CODE=2\n")) {
+		t.Errorf("missing NOTIFY_ fields: %q", data)
+	}
+	if bytes.Contains(data, []byte("NOTIFY_DURATION")) {
+		t.Error("empty field sent")
+	}
+	// Multi-line values use the binary length-prefixed form.
+	var size [8]byte
+	binary.LittleEndian.PutUint64(size[:], uint64(len(r.Message)))
+	want := append(append([]byte("MESSAGE\n"), size[:]...), r.Message+"\n"...)
+	if !bytes.HasPrefix(data, want) {
+		t.Errorf("MESSAGE encoding = %q, want prefix %q", data, want)
+	}
+}
+
+func TestSendUnixgram(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log.sock")
+	pc, err := net.ListenPacket("unixgram", path)
+	if err != nil {
+		t.Skipf("unixgram not supported: %v", err)
+	}
+	defer pc.Close()
+
+	if err := Send("unix://"+path, testRecord); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	buf := make([]byte, 2048)
+	pc.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, _, err := pc.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(buf[:n]); !strings.HasPrefix(got, "<11>1 ") {
+		t.Errorf("datagram = %q", got)
+	}
+}
+
+func TestSendUnixStream(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log.sock")
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		t.Skipf("unix sockets not supported: %v", err)
+	}
+	defer ln.Close()
+
+	got := make(chan string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			got <- err.Error()
+			return
+		}
+		defer conn.Close()
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		line, _ := bufio.NewReader(conn).ReadString('\n')
+		got <- line
+	}()
+
+	if err := Send("unix://"+path, testRecord); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if line := <-got; !strings.HasPrefix(line, "<11>1 ") || !strings.HasSuffix(line, "\n") {
+		t.Errorf("stream message = %q, want a newline-terminated record", line)
+	}
+}
+
+func TestParseAddress(t *testing.T) {
+	tests := []struct {
+		in      string
+		network string
+		ok      bool
+	}{
+		{"journal", "unixgram", true},
+		{"udp://logs.example.com:514", "udp", true},
+		{"tcp://10.0.0.1:6514", "tcp", true},
+		{"unix:///dev/log", "unixgram", true},
+		{"udp://logs.example.com", "", false},
+		{"http://logs.example.com:514", "", false},
+		{"logs.example.com:514", "", false},
+	}
+	for _, tt := range tests {
+		network, _, err := ParseAddress(tt.in)
+		if (err == nil) != tt.ok || network != tt.network {
+			t.Errorf("ParseAddress(%q) = %q, %v; want %q, ok=%v", tt.in, network, err, tt.network, tt.ok)
+		}
+	}
+}
+
+func TestParseSeverityAndFacility(t *testing.T) {
+	if v, err := ParseSeverity("Warning"); err != nil || v != SevWarning {
+		t.Errorf("ParseSeverity(Warning) = %d, %v", v, err)
+	}
+	if v, err := ParseSeverity("7"); err != nil || v != SevDebug {
+		t.Errorf("ParseSeverity(7) = %d, %v", v, err)
+	}
+	if _, err := ParseSeverity("loud"); err == nil {
+		t.Error("ParseSeverity(loud) accepted")
+	}
+	if v, err := ParseFacility(""); err != nil || v != FacilityUser {
+		t.Errorf("ParseFacility(\"\") = %d, %v", v, err)
+	}
+	if v, err := ParseFacility("local3"); err != nil || v != 19 {
+		t.Errorf("ParseFacility(local3) = %d, %v", v, err)
+	}
+	if _, err := ParseFacility("local8"); err == nil {
+		t.Error("ParseFacility(local8) accepted")
+	}
+}
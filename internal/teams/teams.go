@@ -0,0 +1,92 @@
+// Package teams posts Adaptive Cards to Microsoft Teams incoming webhooks
+// and Workflows ("When a Teams webhook request is received") URLs.
+package teams
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/Mavwarf/notify/internal/httputil"
+)
+
+// Adaptive Card text colors used for the card title.
+const (
+	ColorDefault   = "default"
+	ColorAccent    = "accent"
+	ColorGood      = "good"
+	ColorWarning   = "warning"
+	ColorAttention = "attention"
+)
+
+// Card is the content of one Adaptive Card message.
+type Card struct {
+	Title  string
+	Text   string
+	Color  string // title color, one of the Color constants
+	Facts  []Fact // shown as a two-column fact set
+	Output string // shown in a code block when non-empty
+}
+
+// Fact is one name/value row of the card's fact set.
+type Fact struct {
+	Title string `json:"title"`
+	Value string `json:"value"`
+}
+
+// payload wraps the card in the message envelope both webhook kinds accept.
+func payload(c Card) map[string]any {
+	body := []any{
+		map[string]any{
+			"type": "TextBlock", "text": c.Title, "color": c.Color,
+			"weight": "Bolder", "size": "Medium", "wrap": true,
+		},
+	}
+	if c.Text != "" {
+		body = append(body, map[string]any{"type": "TextBlock", "text": c.Text, "wrap": true})
+	}
+	if len(c.Facts) > 0 {
+		body = append(body, map[string]any{"type": "FactSet", "facts": c.Facts})
+	}
+	if c.Output != "" {
+		// CodeBlock is Teams-only; other hosts fall back to monospace text.
+		body = append(body, map[string]any{
+			"type": "CodeBlock", "codeSnippet": c.Output, "language": "PlainText",
+			"fallback": map[string]any{
+				"type": "TextBlock", "text": c.Output, "fontType": "Monospace", "wrap": true,
+			},
+		})
+	}
+	return map[string]any{
+		"type": "message",
+		"attachments": []any{map[string]any{
+			"contentType": "application/vnd.microsoft.card.adaptive",
+			"content": map[string]any{
+				"$schema": "http://adaptivecards.io/schemas/adaptive-card.json",
+				"type":    "AdaptiveCard",
+				"version": "1.4",
+				"msteams": map[string]any{"width": "Full"},
+				"body":    body,
+			},
+		}},
+	}
+}
+
+// Send posts c to the webhook URL.
+func Send(webhookURL string, c Card) error {
+	data, err := json.Marshal(payload(c))
+	if err != nil {
+		return fmt.Errorf("teams: marshal: %w", err)
+	}
+
+	resp, err := httputil.Limited(webhookURL, func() (*http.Response, error) {
+		return httputil.Post(webhookURL, "application/json", bytes.NewReader(data))
+	})
+	if err != nil {
+		return fmt.Errorf("teams: post: %w", err)
+	}
+	defer resp.Body.Close()
+
+	return httputil.CheckStatus(resp, "teams: webhook")
+}
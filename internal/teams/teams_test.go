@@ -0,0 +1,93 @@
+package teams
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// sentCard is the part of the posted payload the tests inspect.
+type sentCard struct {
+	Type        string `json:"type"`
+	Attachments []struct {
+		ContentType string `json:"contentType"`
+		Content     struct {
+			Type string           `json:"type"`
+			Body []map[string]any `json:"body"`
+		} `json:"content"`
+	} `json:"attachments"`
+}
+
+func post(t *testing.T, c Card) sentCard {
+	t.Helper()
+	var got sentCard
+	var gotContentType string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotContentType = r.Header.Get("Content-Type")
+		data, _ := io.ReadAll(r.Body)
+		json.Unmarshal(data, &got)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	if err := Send(srv.URL, c); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if gotContentType != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", gotContentType)
+	}
+	return got
+}
+
+func TestSendCard(t *testing.T) {
+	got := post(t, Card{
+		Title:  "Webapp",
+		Text:   "build failed",
+		Color:  ColorAttention,
+		Facts:  []Fact{{"Command", "make"}, {"Exit code", "2"}},
+		Output: "error: missing ;",
+	})
+	if got.Type != "message" || len(got.Attachments) != 1 {
+		t.Fatalf("envelope = %+v", got)
+	}
+	a := got.Attachments[0]
+	if a.ContentType != "application/vnd.microsoft.card.adaptive" || a.Content.Type != "AdaptiveCard" {
+		t.Errorf("attachment = %+v", a)
+	}
+	body := a.Content.Body
+	if len(body) != 4 {
+		t.Fatalf("body has %d elements, want 4: %v", len(body), body)
+	}
+	if body[0]["text"] != "Webapp" || body[0]["color"] != "attention" {
+		t.Errorf("title = %v", body[0])
+	}
+	if body[1]["text"] != "build failed" {
+		t.Errorf("text = %v", body[1])
+	}
+	if facts, _ := body[2]["facts"].([]any); body[2]["type"] != "FactSet" || len(facts) != 2 {
+		t.Errorf("facts = %v", body[2])
+	}
+	if body[3]["type"] != "CodeBlock" || body[3]["codeSnippet"] != "error: missing ;" {
+		t.Errorf("output = %v", body[3])
+	}
+}
+
+func TestSendCardMinimal(t *testing.T) {
+	got := post(t, Card{Title: "Webapp", Color: ColorGood})
+	if body := got.Attachments[0].Content.Body; len(body) != 1 {
+		t.Errorf("body = %v, want only the title", body)
+	}
+}
+
+func TestSendError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	if err := Send(srv.URL, Card{Title: "x"}); err == nil {
+		t.Fatal("expected error for 400 response")
+	}
+}
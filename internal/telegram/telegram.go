@@ -3,7 +3,9 @@ package telegram
 
 import (
 	"fmt"
+	"net/http"
 	"net/url"
+	"strings"
 
 	"github.com/Mavwarf/notify/internal/httputil"
 )
@@ -16,9 +18,11 @@ func Send(token, chatID, message string) error {
 
 // sendTo posts a message to the given endpoint. Extracted for testing.
 func sendTo(endpoint, chatID, message string) error {
-	resp, err := httputil.PostForm(endpoint, url.Values{
-		"chat_id": {chatID},
-		"text":    {message},
+	resp, err := httputil.Limited(botURL(endpoint), func() (*http.Response, error) {
+		return httputil.PostForm(endpoint, url.Values{
+			"chat_id": {chatID},
+			"text":    {message},
+		})
 	})
 	if err != nil {
 		return fmt.Errorf("telegram: post: %w", err)
@@ -55,11 +59,13 @@ func sendVoiceTo(endpoint, chatID, oggPath, caption string) error {
 // sendFile uploads a file to the given endpoint with the specified form field name.
 // The contentType is set on the file part (e.g. "audio/ogg" for voice bubbles).
 func sendFile(endpoint, chatID, filePath, caption, fieldName string) error {
-	resp, err := httputil.PostMultipart(endpoint, httputil.FileUpload{
-		FieldName:   fieldName,
-		FilePath:    filePath,
-		ContentType: mimeForField(fieldName),
-	}, [][2]string{{"chat_id", chatID}, {"caption", caption}})
+	resp, err := httputil.Limited(botURL(endpoint), func() (*http.Response, error) {
+		return httputil.PostMultipart(endpoint, httputil.FileUpload{
+			FieldName:   fieldName,
+			FilePath:    filePath,
+			ContentType: mimeForField(fieldName),
+		}, [][2]string{{"chat_id", chatID}, {"caption", caption}})
+	})
 	if err != nil {
 		return fmt.Errorf("telegram: post %s: %w", fieldName, err)
 	}
@@ -68,6 +74,15 @@ func sendFile(endpoint, chatID, filePath, caption, fieldName string) error {
 	return httputil.CheckStatus(resp, fmt.Sprintf("telegram: %s API", fieldName))
 }
 
+// botURL strips the method from a Bot API endpoint, leaving the URL that
+// identifies the bot token, so all methods share one rate limit.
+func botURL(endpoint string) string {
+	if i := strings.LastIndex(endpoint, "/"); i >= 0 {
+		return endpoint[:i]
+	}
+	return endpoint
+}
+
 // mimeForField returns the MIME type for a given form field name.
 func mimeForField(fieldName string) string {
 	switch fieldName {
@@ -211,3 +211,10 @@ func TestSendVoiceMissingFile(t *testing.T) {
 		t.Fatal("expected error for missing file")
 	}
 }
+
+func TestBotURL(t *testing.T) {
+	got := botURL("https://api.telegram.org/bot123:ABC/sendMessage")
+	if want := "https://api.telegram.org/bot123:ABC"; got != want {
+		t.Errorf("botURL = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,111 @@
+// Package terminal shows desktop notifications through the terminal
+// emulator: OSC escape sequences written to the controlling TTY travel
+// over SSH and reach the local terminal, where notify-send on the remote
+// host would not.
+package terminal
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Escape sequence protocols.
+const (
+	OSC9   = "osc9"   // iTerm2, Windows Terminal, WezTerm, Ghostty, ConEmu: body only
+	OSC777 = "osc777" // rxvt-unicode, foot, Ghostty, WezTerm: title and body
+	OSC99  = "osc99"  // kitty: title and body
+)
+
+// Protocols lists the valid protocol names.
+var Protocols = []string{OSC9, OSC777, OSC99}
+
+// Notification is one terminal notification.
+type Notification struct {
+	Protocol string // one of Protocols; "" picks one from the environment
+	Title    string
+	Body     string
+	Bell     bool // also ring the terminal bell
+	Tmux     bool // wrap the sequence in tmux passthrough
+}
+
+// Detect picks a protocol from the terminal's environment variables. Over
+// SSH usually only TERM (and LC_TERMINAL, which iTerm2 sends) survive, so
+// anything unrecognized gets the widely supported OSC 9.
+func Detect(getenv func(string) string) string {
+	term := getenv("TERM")
+	switch {
+	case term == "xterm-kitty" || getenv("KITTY_WINDOW_ID") != "":
+		return OSC99
+	case strings.HasPrefix(term, "foot"), strings.HasPrefix(term, "rxvt"):
+		return OSC777
+	}
+	return OSC9
+}
+
+// Sequence returns the bytes that display n.
+func Sequence(n Notification) string {
+	title, body := clean(n.Title), clean(n.Body)
+	var seq string
+	switch n.Protocol {
+	case OSC777:
+		// The title ends at the first ';', the body may contain more.
+		seq = "\x1b]777;notify;" + strings.ReplaceAll(title, ";", ",") + ";" + body + "\a"
+	case OSC99:
+		// d=0 holds the title until the d=1 chunk with the body arrives.
+		seq = "\x1b]99;i=notify:d=0:p=title;" + title + "\x1b\\" +
+			"\x1b]99;i=notify:d=1:p=body;" + body + "\x1b\\"
+	default:
+		text := body
+		if title != "" {
+			text = title + ": " + body
+		}
+		seq = "\x1b]9;" + text + "\a"
+	}
+	if n.Tmux {
+		// DCS passthrough with every ESC doubled; tmux 3.3+ needs
+		// "set -g allow-passthrough on".
+		seq = "\x1bPtmux;" + strings.ReplaceAll(seq, "\x1b", "\x1b\x1b") + "\x1b\\"
+	}
+	if n.Bell {
+		seq += "\a"
+	}
+	return seq
+}
+
+// clean replaces control characters, which would end or corrupt the
+// sequence, with spaces.
+func clean(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r < ' ' || r == 0x7f || (r >= 0x80 && r < 0xa0) {
+			return ' '
+		}
+		return r
+	}, s)
+}
+
+// Write writes n's sequence to w.
+func Write(w io.Writer, n Notification) error {
+	if _, err := io.WriteString(w, Sequence(n)); err != nil {
+		return fmt.Errorf("terminal: write: %w", err)
+	}
+	return nil
+}
+
+// Send writes n to the controlling terminal, filling in the protocol from
+// the environment when unset and using tmux passthrough inside tmux.
+func Send(n Notification) error {
+	if n.Protocol == "" {
+		n.Protocol = Detect(os.Getenv)
+	}
+	if os.Getenv("TMUX") != "" {
+		n.Tmux = true
+	}
+	tty, err := openTTY()
+	if err != nil {
+		return fmt.Errorf("terminal: no controlling terminal: %w", err)
+	}
+	defer tty.Close()
+	return Write(tty, n)
+}
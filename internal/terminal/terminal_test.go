@@ -0,0 +1,63 @@
+package terminal
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSequence(t *testing.T) {
+	tests := []struct {
+		name string
+		n    Notification
+		want string
+	}{
+		{"osc9", Notification{Protocol: OSC9, Title: "webapp", Body: "build done"},
+			"\x1b]9;webapp: build done\a"},
+		{"osc9 no title", Notification{Body: "build done"},
+			"\x1b]9;build done\a"},
+		{"osc777", Notification{Protocol: OSC777, Title: "a;b", Body: "x;y"},
+			"\x1b]777;notify;a,b;x;y\a"},
+		{"osc99", Notification{Protocol: OSC99, Title: "webapp", Body: "done"},
+			"\x1b]99;i=notify:d=0:p=title;webapp\x1b\\\x1b]99;i=notify:d=1:p=body;done\x1b\\"},
+		{"bell", Notification{Protocol: OSC9, Body: "done", Bell: true},
+			"\x1b]9;done\a\a"},
+		{"tmux", Notification{Protocol: OSC9, Body: "done", Tmux: true, Bell: true},
+			"\x1bPtmux;\x1b\x1b]9;done\a\x1b\\\a"},
+		{"control chars", Notification{Protocol: OSC9, Body: "line1\nline2\x1b]0;pwned\a"},
+			"\x1b]9;line1 line2 ]0;pwned \a"},
+	}
+	for _, tt := range tests {
+		if got := Sequence(tt.n); got != tt.want {
+			t.Errorf("%s: Sequence = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		env  map[string]string
+		want string
+	}{
+		{map[string]string{"TERM": "xterm-kitty"}, OSC99},
+		{map[string]string{"TERM": "xterm-256color", "KITTY_WINDOW_ID": "1"}, OSC99},
+		{map[string]string{"TERM": "foot"}, OSC777},
+		{map[string]string{"TERM": "rxvt-unicode-256color"}, OSC777},
+		{map[string]string{"TERM": "xterm-256color"}, OSC9},
+		{map[string]string{}, OSC9},
+	}
+	for _, tt := range tests {
+		if got := Detect(func(k string) string { return tt.env[k] }); got != tt.want {
+			t.Errorf("Detect(%v) = %q, want %q", tt.env, got, tt.want)
+		}
+	}
+}
+
+func TestWrite(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, Notification{Protocol: OSC9, Body: "hi"}); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "\x1b]9;hi\a" {
+		t.Errorf("wrote %q", buf.String())
+	}
+}
@@ -0,0 +1,11 @@
+//go:build unix
+
+package terminal
+
+import "os"
+
+// openTTY opens the controlling terminal, which stays the user's terminal
+// even when notify's stdout is a pipe or file.
+func openTTY() (*os.File, error) {
+	return os.OpenFile("/dev/tty", os.O_WRONLY, 0)
+}
@@ -0,0 +1,9 @@
+package terminal
+
+import "os"
+
+// openTTY opens the console's output buffer, which stays the user's
+// terminal even when notify's stdout is redirected.
+func openTTY() (*os.File, error) {
+	return os.OpenFile("CONOUT$", os.O_WRONLY, 0)
+}
@@ -14,6 +14,14 @@ type Vars struct {
 	DateSay     string // spoken: "January 2, 2006"
 	Hostname    string
 	Output      string // last N lines of wrapped command output
+	BatchCount  string // number of notifications combined by batching
+	BatchList   string // their profiles, e.g. "webapp, api, worker"
+	RepeatCount string // repeats collapsed into a dedup follow-up
+	AckID       string // ID to acknowledge an escalating action with
+	Severity    string // action severity: "info", "warning", or "critical"
+	Action      string // action name, e.g. "ready" or "error"
+	ExitCode    string // wrapped command exit code ("" when unknown)
+	TmuxPane    string // tmux pane the wrapped command ran in ("%3"), from $TMUX_PANE
 
 	// Stdin JSON fields (auto-detected from piped JSON input).
 	ClaudeMessage string // from "last_assistant_message" or "message"
@@ -42,6 +50,14 @@ func Expand(s string, v Vars) string {
 	s = strings.ReplaceAll(s, "{date}", v.Date)
 	s = strings.ReplaceAll(s, "{hostname}", v.Hostname)
 	s = strings.ReplaceAll(s, "{output}", v.Output)
+	s = strings.ReplaceAll(s, "{batch_count}", v.BatchCount)
+	s = strings.ReplaceAll(s, "{batch_list}", v.BatchList)
+	s = strings.ReplaceAll(s, "{repeat_count}", v.RepeatCount)
+	s = strings.ReplaceAll(s, "{ack_id}", v.AckID)
+	s = strings.ReplaceAll(s, "{severity}", v.Severity)
+	s = strings.ReplaceAll(s, "{action}", v.Action)
+	s = strings.ReplaceAll(s, "{exit_code}", v.ExitCode)
+	s = strings.ReplaceAll(s, "{tmux_pane}", v.TmuxPane)
 	s = strings.ReplaceAll(s, "{claude_message}", v.ClaudeMessage)
 	s = strings.ReplaceAll(s, "{claude_hook}", v.ClaudeHook)
 	s = strings.ReplaceAll(s, "{claude_json}", v.ClaudeJSON)
@@ -56,6 +72,8 @@ var dynamicVars = []string{
 	"{date}", "{Date}",
 	"{command}",
 	"{output}",
+	"{batch_count}", "{batch_list}", "{repeat_count}", "{ack_id}",
+	"{severity}", "{action}", "{exit_code}", "{tmux_pane}",
 	"{claude_message}", "{claude_hook}", "{claude_json}",
 }
 
@@ -71,6 +89,41 @@ func HasDynamic(text string) bool {
 	return false
 }
 
+// Fields returns the non-empty variables keyed by their placeholder names
+// ("profile", "exit_code"); spoken variants get a "_say" suffix
+// ("duration_say"). Used where the variables are written out as data.
+func Fields(v Vars) map[string]string {
+	all := map[string]string{
+		"profile":        v.Profile,
+		"command":        v.Command,
+		"duration":       v.Duration,
+		"duration_say":   v.DurationSay,
+		"time":           v.Time,
+		"time_say":       v.TimeSay,
+		"date":           v.Date,
+		"date_say":       v.DateSay,
+		"hostname":       v.Hostname,
+		"output":         v.Output,
+		"batch_count":    v.BatchCount,
+		"batch_list":     v.BatchList,
+		"repeat_count":   v.RepeatCount,
+		"ack_id":         v.AckID,
+		"severity":       v.Severity,
+		"action":         v.Action,
+		"exit_code":      v.ExitCode,
+		"tmux_pane":      v.TmuxPane,
+		"claude_message": v.ClaudeMessage,
+		"claude_hook":    v.ClaudeHook,
+		"claude_json":    v.ClaudeJSON,
+	}
+	for k, val := range all {
+		if val == "" {
+			delete(all, k)
+		}
+	}
+	return all
+}
+
 // TitleCase uppercases the first rune of s.
 func TitleCase(s string) string {
 	if s == "" {
@@ -89,6 +89,12 @@ func TestExpand(t *testing.T) {
 		{"spoken vars", "{Date} at {Time}", Vars{TimeSay: "9:00 AM", DateSay: "January 1, 2026"}, "January 1, 2026 at 9:00 AM"},
 		{"output var", "result:\n{output}", Vars{Output: "3 passed, 1 failed"}, "result:\n3 passed, 1 failed"},
 		{"empty output", "{output}", Vars{}, ""},
+		{"batch vars", "{batch_count} done: {batch_list}", Vars{BatchCount: "3", BatchList: "api, web"}, "3 done: api, web"},
+		{"empty batch vars", "{batch_count}{batch_list}", Vars{}, ""},
+		{"severity var", "[{severity}] deploy failed", Vars{Severity: "critical"}, "[critical] deploy failed"},
+		{"action and exit code", "{action}: exit {exit_code}", Vars{Action: "error", ExitCode: "2"}, "error: exit 2"},
+		{"ack_id var", "notify ack {ack_id}", Vars{AckID: "3f9a12bc"}, "notify ack 3f9a12bc"},
+		{"repeat_count var", "{profile} ready ×{repeat_count}", Vars{Profile: "webapp", RepeatCount: "3"}, "webapp ready ×3"},
 		{"claude_message var", "Claude says: {claude_message}", Vars{ClaudeMessage: "Build complete"}, "Claude says: Build complete"},
 		{"claude_hook var", "hook: {claude_hook}", Vars{ClaudeHook: "Stop"}, "hook: Stop"},
 		{"claude_json var", "raw: {claude_json}", Vars{ClaudeJSON: `{"key":"val"}`}, `raw: {"key":"val"}`},
@@ -103,3 +109,16 @@ func TestExpand(t *testing.T) {
 		})
 	}
 }
+
+func TestFields(t *testing.T) {
+	got := Fields(Vars{Profile: "webapp", DurationSay: "2 minutes", ExitCode: "0"})
+	want := map[string]string{"profile": "webapp", "duration_say": "2 minutes", "exit_code": "0"}
+	if len(got) != len(want) {
+		t.Fatalf("Fields = %v, want %v", got, want)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("Fields[%q] = %q, want %q", k, got[k], v)
+		}
+	}
+}
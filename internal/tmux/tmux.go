@@ -0,0 +1,89 @@
+// Package tmux marks the tmux window a command ran in: a status message,
+// a highlighted window, or a renamed window. Marks are cleared by a
+// one-shot pane-focus-in hook when the user switches to the pane.
+package tmux
+
+import (
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+)
+
+// Modes.
+const (
+	ModeFlag    = "flag"    // highlight the window in the status line and set @notify
+	ModeDisplay = "display" // display-message on the attached clients
+	ModeRename  = "rename"  // rename the window, restored on focus
+)
+
+// Modes lists the valid mode names.
+var Modes = []string{ModeFlag, ModeDisplay, ModeRename}
+
+// FlagStyle is the window-status-style set on flagged windows.
+const FlagStyle = "reverse"
+
+// Hooks that clear a mark, one per mode so a flag and a rename on the
+// same pane both get cleared. Fixed indexes mean a second notification
+// replaces the first one's hook instead of adding another.
+const (
+	flagHook   = "pane-focus-in[73]"
+	renameHook = "pane-focus-in[74]"
+)
+
+var paneID = regexp.MustCompile(`^%[0-9]+$`)
+
+// Commands returns the tmux commands, one argument list each, that apply
+// mode to pane with text.
+func Commands(mode, pane, text string) [][]string {
+	switch mode {
+	case ModeDisplay:
+		return [][]string{{"display-message", "-t", pane, escape(text)}}
+	case ModeRename:
+		return [][]string{
+			// Save the name and automatic-rename setting, keeping the
+			// first ones saved when the window is renamed twice.
+			{"set-option", "-w", "-F", "-t", pane, "@notify_auto", "#{?@notify_name,#{@notify_auto},#{automatic-rename}}"},
+			{"set-option", "-w", "-F", "-t", pane, "@notify_name", "#{?@notify_name,#{@notify_name},#{window_name}}"},
+			{"rename-window", "-t", pane, escape(text)},
+			clearOnFocus(pane, renameHook,
+				"if-shell -F -t "+pane+" '#{@notify_auto}' 'set-option -wu -t "+pane+" automatic-rename' 'rename-window -t "+pane+` "#{@notify_name}"'`,
+				"set-option -wu -t "+pane+" @notify_name",
+				"set-option -wu -t "+pane+" @notify_auto"),
+		}
+	}
+	return [][]string{
+		{"set-option", "-w", "-t", pane, "@notify", text},
+		{"set-option", "-w", "-t", pane, "window-status-style", FlagStyle},
+		clearOnFocus(pane, flagHook,
+			"set-option -wu -t "+pane+" @notify",
+			"set-option -wu -t "+pane+" window-status-style"),
+	}
+}
+
+// clearOnFocus installs cmds as a pane hook that removes itself after
+// running once.
+func clearOnFocus(pane, hook string, cmds ...string) []string {
+	cmds = append(cmds, "set-hook -pu -t "+pane+" "+hook)
+	return []string{"set-hook", "-p", "-t", pane, hook, strings.Join(cmds, " ; ")}
+}
+
+// escape doubles '#' so tmux shows text as is instead of expanding it as
+// a format.
+func escape(text string) string {
+	return strings.ReplaceAll(text, "#", "##")
+}
+
+// Notify applies mode to pane (a pane ID such as "%3", from $TMUX_PANE).
+func Notify(mode, pane, text string) error {
+	// The pane is spliced into hook command strings.
+	if !paneID.MatchString(pane) {
+		return fmt.Errorf("tmux: invalid pane %q", pane)
+	}
+	for _, args := range Commands(mode, pane, text) {
+		if out, err := exec.Command("tmux", args...).CombinedOutput(); err != nil {
+			return fmt.Errorf("tmux %s: %w\n%s", args[0], err, out)
+		}
+	}
+	return nil
+}
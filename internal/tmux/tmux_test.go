@@ -0,0 +1,77 @@
+package tmux
+
+import (
+	"os/exec"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestCommands(t *testing.T) {
+	flag := Commands(ModeFlag, "%3", "build #1 done")
+	if len(flag) != 3 || !slices.Equal(flag[0], []string{"set-option", "-w", "-t", "%3", "@notify", "build #1 done"}) {
+		t.Errorf("flag commands = %q", flag)
+	}
+	if hook := flag[2]; hook[0] != "set-hook" || hook[4] != flagHook || !strings.HasSuffix(hook[5], "set-hook -pu -t %3 "+flagHook) {
+		t.Errorf("flag hook = %q", hook)
+	}
+
+	display := Commands(ModeDisplay, "%3", "build #1 done")
+	if !slices.Equal(display[0], []string{"display-message", "-t", "%3", "build ##1 done"}) {
+		t.Errorf("display commands = %q", display)
+	}
+
+	rename := Commands(ModeRename, "%3", "done #1")
+	if len(rename) != 4 || !slices.Equal(rename[2], []string{"rename-window", "-t", "%3", "done ##1"}) {
+		t.Errorf("rename commands = %q", rename)
+	}
+	if hook := rename[3]; hook[4] != renameHook || !strings.Contains(hook[5], "@notify_name") {
+		t.Errorf("rename hook = %q", hook)
+	}
+}
+
+func TestNotifyRejectsInvalidPane(t *testing.T) {
+	for _, pane := range []string{"", "3", "%3;kill-server", "%"} {
+		if err := Notify(ModeFlag, pane, "x"); err == nil {
+			t.Errorf("Notify(%q) accepted", pane)
+		}
+	}
+}
+
+// TestNotifyTmux runs against a private tmux server when tmux is installed.
+func TestNotifyTmux(t *testing.T) {
+	if _, err := exec.LookPath("tmux"); err != nil {
+		t.Skip("tmux not installed")
+	}
+	t.Setenv("TMUX_TMPDIR", t.TempDir())
+	t.Setenv("TMUX", "")
+	tmux := func(args ...string) string {
+		out, err := exec.Command("tmux", args...).CombinedOutput()
+		if err != nil {
+			t.Fatalf("tmux %v: %v\n%s", args, err, out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+	tmux("new-session", "-d", "-s", "test", "-n", "build", "sleep 60")
+	t.Cleanup(func() { exec.Command("tmux", "kill-server").Run() })
+	pane := tmux("display-message", "-p", "-t", "test:build", "#{pane_id}")
+
+	if err := Notify(ModeFlag, pane, "done"); err != nil {
+		t.Fatal(err)
+	}
+	if got := tmux("display-message", "-p", "-t", pane, "#{@notify} #{window-status-style}"); got != "done "+FlagStyle {
+		t.Errorf("flag: got %q", got)
+	}
+
+	for _, name := range []string{"first", "second"} {
+		if err := Notify(ModeRename, pane, name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := tmux("display-message", "-p", "-t", pane, "#{window_name} #{@notify_name}"); got != "second build" {
+		t.Errorf("rename: got %q, want the original name saved once", got)
+	}
+	if hooks := tmux("show-hooks", "-p", "-t", pane); !strings.Contains(hooks, flagHook) || !strings.Contains(hooks, renameHook) {
+		t.Errorf("hooks = %q", hooks)
+	}
+}
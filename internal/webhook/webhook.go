@@ -23,7 +23,9 @@ func Send(url, body string, headers map[string]string) error {
 		req.Header.Set(k, os.ExpandEnv(v))
 	}
 
-	resp, err := httputil.Client.Do(req)
+	resp, err := httputil.Limited(url, func() (*http.Response, error) {
+		return httputil.Client.Do(req)
+	})
 	if err != nil {
 		return fmt.Errorf("webhook: post: %w", err)
 	}